@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/tsdb"
 )
 
 func ValidateOrgAlert(c *middleware.Context) {
@@ -58,6 +59,15 @@ func GetAlerts(c *middleware.Context) Response {
 		query.State = states
 	}
 
+	for _, severity := range c.QueryStrings("severity") {
+		if !models.AlertSeverityType(severity).IsValid() {
+			return ApiError(400, "Invalid severity "+severity, nil)
+		}
+		query.Severity = append(query.Severity, severity)
+	}
+
+	query.Labels = models.ParseAlertLabelFilters(c.QueryStrings("label"))
+
 	if err := bus.Dispatch(&query); err != nil {
 		return ApiError(500, "List alerts failed", err)
 	}
@@ -65,6 +75,12 @@ func GetAlerts(c *middleware.Context) Response {
 	dashboardIds := make([]int64, 0)
 	alertDTOs := make([]*dtos.AlertRule, 0)
 	for _, alert := range query.Result {
+		// alerts saved before severities were added are critical
+		severity := alert.Severity
+		if severity == "" {
+			severity = string(models.AlertSeverityCritical)
+		}
+
 		dashboardIds = append(dashboardIds, alert.DashboardId)
 		alertDTOs = append(alertDTOs, &dtos.AlertRule{
 			Id:             alert.Id,
@@ -72,6 +88,8 @@ func GetAlerts(c *middleware.Context) Response {
 			PanelId:        alert.PanelId,
 			Name:           alert.Name,
 			Message:        alert.Message,
+			Severity:       severity,
+			Labels:         alert.GetLabels(),
 			State:          alert.State,
 			EvalDate:       alert.EvalDate,
 			NewStateDate:   alert.NewStateDate,
@@ -138,6 +156,46 @@ func AlertTest(c *middleware.Context, dto dtos.AlertTestCommand) Response {
 	return Json(200, dtoRes)
 }
 
+// POST /api/alerts/backtest
+func AlertBacktest(c *middleware.Context, dto dtos.AlertBacktestCommand) Response {
+	if dto.From == "" {
+		dto.From = "now-168h"
+	}
+
+	if dto.To == "" {
+		dto.To = "now"
+	}
+
+	timeRange := tsdb.NewTimeRange(dto.From, dto.To)
+	from, err := timeRange.ParseFrom()
+	if err != nil {
+		return ApiError(400, "Invalid from "+dto.From, err)
+	}
+
+	to, err := timeRange.ParseTo()
+	if err != nil || to.IsZero() {
+		return ApiError(400, "Invalid to "+dto.To, err)
+	}
+
+	backendCmd := alerting.AlertBacktestCommand{
+		Ctx:       c.Req.Context(),
+		OrgId:     c.OrgId,
+		Dashboard: dto.Dashboard,
+		PanelId:   dto.PanelId,
+		From:      from,
+		To:        to,
+	}
+
+	if err := bus.Dispatch(&backendCmd); err != nil {
+		if validationErr, ok := err.(alerting.ValidationError); ok {
+			return ApiError(422, validationErr.Error(), nil)
+		}
+		return ApiError(500, "Failed to backtest rule", err)
+	}
+
+	return Json(200, backendCmd.Result)
+}
+
 // GET /api/alerts/:id
 func GetAlert(c *middleware.Context) Response {
 	id := c.ParamsInt64(":alertId")
@@ -147,7 +205,15 @@ func GetAlert(c *middleware.Context) Response {
 		return ApiError(500, "List alerts failed", err)
 	}
 
-	return Json(200, &query.Result)
+	instancesQuery := models.GetAlertInstancesQuery{OrgId: query.Result.OrgId, AlertId: id}
+	if err := bus.Dispatch(&instancesQuery); err != nil {
+		return ApiError(500, "Failed to get alert instances", err)
+	}
+
+	return Json(200, &dtos.AlertWithInstances{
+		Alert:     query.Result,
+		Instances: instancesQuery.Result,
+	})
 }
 
 // DEL /api/alerts/:id
@@ -179,12 +245,13 @@ func GetAlertNotifications(c *middleware.Context) Response {
 
 	for _, notification := range query.Result {
 		result = append(result, &dtos.AlertNotification{
-			Id:        notification.Id,
-			Name:      notification.Name,
-			Type:      notification.Type,
-			IsDefault: notification.IsDefault,
-			Created:   notification.Created,
-			Updated:   notification.Updated,
+			Id:           notification.Id,
+			Name:         notification.Name,
+			Type:         notification.Type,
+			IsDefault:    notification.IsDefault,
+			SendReminder: notification.SendReminder,
+			Created:      notification.Created,
+			Updated:      notification.Updated,
 		})
 	}
 
@@ -201,6 +268,42 @@ func GetAlertNotificationById(c *middleware.Context) Response {
 		return ApiError(500, "Failed to get alert notifications", err)
 	}
 
+	if query.Result == nil {
+		return ApiError(404, "Alert notification not found", nil)
+	}
+
+	return Json(200, dtos.NewAlertNotification(query.Result))
+}
+
+func GetAlertNotificationDeliveries(c *middleware.Context) Response {
+	notificationQuery := &models.GetAlertNotificationsQuery{
+		OrgId: c.OrgId,
+		Id:    c.ParamsInt64("notificationId"),
+	}
+
+	if err := bus.Dispatch(notificationQuery); err != nil {
+		return ApiError(500, "Failed to get alert notifications", err)
+	}
+
+	if notificationQuery.Result == nil {
+		return ApiError(404, "Alert notification not found", nil)
+	}
+
+	query := &models.GetAlertNotificationDeliveriesQuery{
+		OrgId:      c.OrgId,
+		NotifierId: notificationQuery.Result.Id,
+		Status:     models.AlertNotificationDeliveryStatus(c.Query("status")),
+		Limit:      c.QueryInt("limit"),
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return ApiError(500, "Failed to get alert notification deliveries", err)
+	}
+
 	return Json(200, query.Result)
 }
 
@@ -208,20 +311,26 @@ func CreateAlertNotification(c *middleware.Context, cmd models.CreateAlertNotifi
 	cmd.OrgId = c.OrgId
 
 	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrNotificationFrequencyNotFound || err == models.ErrNotificationFrequencyInvalid {
+			return ApiError(400, err.Error(), err)
+		}
 		return ApiError(500, "Failed to create alert notification", err)
 	}
 
-	return Json(200, cmd.Result)
+	return Json(200, dtos.NewAlertNotification(cmd.Result))
 }
 
 func UpdateAlertNotification(c *middleware.Context, cmd models.UpdateAlertNotificationCommand) Response {
 	cmd.OrgId = c.OrgId
 
 	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrNotificationFrequencyNotFound || err == models.ErrNotificationFrequencyInvalid {
+			return ApiError(400, err.Error(), err)
+		}
 		return ApiError(500, "Failed to update alert notification", err)
 	}
 
-	return Json(200, cmd.Result)
+	return Json(200, dtos.NewAlertNotification(cmd.Result))
 }
 
 func DeleteAlertNotification(c *middleware.Context) Response {
@@ -246,6 +355,9 @@ func NotificationTest(c *middleware.Context, dto dtos.NotificationTestCommand) R
 	}
 
 	if err := bus.Dispatch(cmd); err != nil {
+		if validationErr, ok := err.(alerting.ValidationError); ok {
+			return ApiError(422, validationErr.Error(), nil)
+		}
 		return ApiError(500, "Failed to send alert notifications", err)
 	}
 
@@ -279,3 +391,31 @@ func PauseAlert(c *middleware.Context, dto dtos.PauseAlertCommand) Response {
 
 	return Json(200, result)
 }
+
+// GET /api/alerts/:alertId/history
+func GetAlertHistory(c *middleware.Context) Response {
+	query := &models.GetAlertHistoryQuery{
+		OrgId:   c.OrgId,
+		AlertId: c.ParamsInt64(":alertId"),
+		From:    c.QueryInt64("from"),
+		To:      c.QueryInt64("to"),
+		State:   c.QueryStrings("state"),
+		Type:    models.AlertHistoryEntryType(c.Query("type")),
+		Page:    c.QueryInt("page"),
+		PerPage: c.QueryInt("perpage"),
+	}
+
+	if query.Type != "" && !query.Type.IsValid() {
+		return ApiError(400, "Invalid history entry type "+string(query.Type), nil)
+	}
+
+	if query.PerPage > 1000 {
+		query.PerPage = 1000
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return ApiError(500, "Failed to get alert history", err)
+	}
+
+	return Json(200, query.Result)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func validateAlertSilence(startsAt, endsAt time.Time, nameRegex string) Response {
+	if !endsAt.After(startsAt) {
+		return ApiError(400, models.ErrAlertSilenceInvalidTimeRange.Error(), nil)
+	}
+
+	if nameRegex != "" {
+		if _, err := regexp.Compile(nameRegex); err != nil {
+			return ApiError(400, "Invalid name regex", err)
+		}
+	}
+
+	return nil
+}
+
+// GET /api/alerts/silences
+func GetAlertSilences(c *middleware.Context) Response {
+	query := &models.GetAlertSilencesQuery{OrgId: c.OrgId}
+
+	if c.Query("active") == "true" {
+		query.ActiveAt = time.Now()
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return ApiError(500, "Failed to get alert silences", err)
+	}
+
+	return Json(200, query.Result)
+}
+
+// GET /api/alerts/silences/:silenceId
+func GetAlertSilenceById(c *middleware.Context) Response {
+	query := &models.GetAlertSilenceByIdQuery{
+		Id:    c.ParamsInt64(":silenceId"),
+		OrgId: c.OrgId,
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		if err == models.ErrAlertSilenceNotFound {
+			return ApiError(404, err.Error(), nil)
+		}
+		return ApiError(500, "Failed to get alert silence", err)
+	}
+
+	return Json(200, query.Result)
+}
+
+// POST /api/alerts/silences
+func CreateAlertSilence(c *middleware.Context, cmd models.CreateAlertSilenceCommand) Response {
+	if rsp := validateAlertSilence(cmd.StartsAt, cmd.EndsAt, cmd.NameRegex); rsp != nil {
+		return rsp
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return ApiError(500, "Failed to create alert silence", err)
+	}
+
+	return Json(200, cmd.Result)
+}
+
+// PUT /api/alerts/silences/:silenceId
+func UpdateAlertSilence(c *middleware.Context, cmd models.UpdateAlertSilenceCommand) Response {
+	if rsp := validateAlertSilence(cmd.StartsAt, cmd.EndsAt, cmd.NameRegex); rsp != nil {
+		return rsp
+	}
+
+	cmd.Id = c.ParamsInt64(":silenceId")
+	cmd.OrgId = c.OrgId
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrAlertSilenceNotFound {
+			return ApiError(404, err.Error(), nil)
+		}
+		return ApiError(500, "Failed to update alert silence", err)
+	}
+
+	return Json(200, cmd.Result)
+}
+
+// DELETE /api/alerts/silences/:silenceId
+func DeleteAlertSilence(c *middleware.Context) Response {
+	cmd := &models.DeleteAlertSilenceCommand{
+		Id:    c.ParamsInt64(":silenceId"),
+		OrgId: c.OrgId,
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		return ApiError(500, "Failed to delete alert silence", err)
+	}
+
+	return ApiSuccess("Alert silence deleted")
+}
@@ -252,8 +252,15 @@ func Register(r *macaron.Macaron) {
 
 		r.Group("/alerts", func() {
 			r.Post("/test", bind(dtos.AlertTestCommand{}), wrap(AlertTest))
+			r.Post("/backtest", reqEditorRole, bind(dtos.AlertBacktestCommand{}), wrap(AlertBacktest))
+			r.Get("/silences", wrap(GetAlertSilences))
+			r.Post("/silences", reqEditorRole, bind(m.CreateAlertSilenceCommand{}), wrap(CreateAlertSilence))
+			r.Get("/silences/:silenceId", wrap(GetAlertSilenceById))
+			r.Put("/silences/:silenceId", reqEditorRole, bind(m.UpdateAlertSilenceCommand{}), wrap(UpdateAlertSilence))
+			r.Delete("/silences/:silenceId", reqEditorRole, wrap(DeleteAlertSilence))
 			r.Post("/:alertId/pause", bind(dtos.PauseAlertCommand{}), wrap(PauseAlert), reqEditorRole)
 			r.Get("/:alertId", ValidateOrgAlert, wrap(GetAlert))
+			r.Get("/:alertId/history", ValidateOrgAlert, wrap(GetAlertHistory))
 			r.Get("/", wrap(GetAlerts))
 			r.Get("/states-for-dashboard", wrap(GetAlertStatesForDashboard))
 		})
@@ -265,6 +272,7 @@ func Register(r *macaron.Macaron) {
 			r.Post("/", bind(m.CreateAlertNotificationCommand{}), wrap(CreateAlertNotification))
 			r.Put("/:notificationId", bind(m.UpdateAlertNotificationCommand{}), wrap(UpdateAlertNotification))
 			r.Get("/:notificationId", wrap(GetAlertNotificationById))
+			r.Get("/:notificationId/deliveries", wrap(GetAlertNotificationDeliveries))
 			r.Delete("/:notificationId", wrap(DeleteAlertNotification))
 		}, reqEditorRole)
 
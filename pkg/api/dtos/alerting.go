@@ -1,6 +1,7 @@
 package dtos
 
 import (
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -8,25 +9,66 @@ import (
 )
 
 type AlertRule struct {
-	Id             int64            `json:"id"`
-	DashboardId    int64            `json:"dashboardId"`
-	PanelId        int64            `json:"panelId"`
-	Name           string           `json:"name"`
-	Message        string           `json:"message"`
-	State          m.AlertStateType `json:"state"`
-	NewStateDate   time.Time        `json:"newStateDate"`
-	EvalDate       time.Time        `json:"evalDate"`
-	ExecutionError string           `json:"executionError"`
-	DashbboardUri  string           `json:"dashboardUri"`
+	Id             int64             `json:"id"`
+	DashboardId    int64             `json:"dashboardId"`
+	PanelId        int64             `json:"panelId"`
+	Name           string            `json:"name"`
+	Message        string            `json:"message"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"labels"`
+	State          m.AlertStateType  `json:"state"`
+	NewStateDate   time.Time         `json:"newStateDate"`
+	EvalDate       time.Time         `json:"evalDate"`
+	ExecutionError string            `json:"executionError"`
+	DashbboardUri  string            `json:"dashboardUri"`
+}
+
+type AlertWithInstances struct {
+	*m.Alert
+	Instances []*m.AlertInstance `json:"instances"`
 }
 
 type AlertNotification struct {
-	Id        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`
-	IsDefault bool      `json:"isDefault"`
-	Created   time.Time `json:"created"`
-	Updated   time.Time `json:"updated"`
+	Id           int64            `json:"id"`
+	Name         string           `json:"name"`
+	Type         string           `json:"type"`
+	IsDefault    bool             `json:"isDefault"`
+	SendReminder bool             `json:"sendReminder"`
+	Frequency    string           `json:"frequency"`
+	Settings     *simplejson.Json `json:"settings,omitempty"`
+	Created      time.Time        `json:"created"`
+	Updated      time.Time        `json:"updated"`
+}
+
+func NewAlertNotification(notification *m.AlertNotification) *AlertNotification {
+	return &AlertNotification{
+		Id:           notification.Id,
+		Name:         notification.Name,
+		Type:         notification.Type,
+		IsDefault:    notification.IsDefault,
+		SendReminder: notification.SendReminder,
+		Frequency:    formatFrequency(notification.Frequency),
+		Settings:     notification.Settings,
+		Created:      notification.Created,
+		Updated:      notification.Updated,
+	}
+}
+
+// formatFrequency formats a duration like 10m0s as 10m
+func formatFrequency(frequency time.Duration) string {
+	if frequency == 0 {
+		return ""
+	}
+
+	result := frequency.String()
+	if strings.HasSuffix(result, "m0s") {
+		result = strings.TrimSuffix(result, "0s")
+	}
+	if strings.HasSuffix(result, "h0m") {
+		result = strings.TrimSuffix(result, "0m")
+	}
+
+	return result
 }
 
 type AlertTestCommand struct {
@@ -34,6 +76,13 @@ type AlertTestCommand struct {
 	PanelId   int64            `json:"panelId" binding:"Required"`
 }
 
+type AlertBacktestCommand struct {
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+	PanelId   int64            `json:"panelId" binding:"Required"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+}
+
 type AlertTestResult struct {
 	Firing      bool                  `json:"firing"`
 	TimeMs      string                `json:"timeMs"`
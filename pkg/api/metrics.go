@@ -1,43 +1,150 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/metrics"
 	"github.com/grafana/grafana/pkg/middleware"
+	m "github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/tsdb/testdata"
 	"github.com/grafana/grafana/pkg/util"
 )
 
+// grafanaTestDataSource runs queries without a data source, like those of
+// the built in Grafana data source.
+var grafanaTestDataSource = &m.DataSource{Name: "Grafana TestDataDB", Type: "grafana-testdata-datasource"}
+
+// rawSqlDataSourceTypes are the data sources whose queries are raw sql,
+// which only editors and admins may run.
+var rawSqlDataSourceTypes = map[string]bool{"mysql": true, "postgres": true}
+
 // POST /api/tsdb/query
+//
+// Queries are run by the data source of their datasourceId, which must
+// belong to the org of the user. Queries of each data source are run as a
+// request of their own, so errors are returned in the results of the queries
+// that failed. Raw sql queries are only run for editors and admins.
 func QueryMetrics(c *middleware.Context, reqDto dtos.MetricRequest) Response {
+	if len(reqDto.Queries) == 0 {
+		return ApiError(400, "No queries found in query", nil)
+	}
+
 	timeRange := tsdb.NewTimeRange(reqDto.From, reqDto.To)
+	if _, err := timeRange.ParseFrom(); err != nil {
+		return ApiError(400, "Invalid time range", err)
+	}
+	if _, err := timeRange.ParseTo(); err != nil {
+		return ApiError(400, "Invalid time range", err)
+	}
 
-	request := &tsdb.Request{TimeRange: timeRange}
+	response := &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}
+	requests := make(map[int64]*tsdb.Request)
+	datasources := make(map[int64]*m.DataSource)
+	refIds := make(map[string]bool)
 
 	for _, query := range reqDto.Queries {
+		refId := query.Get("refId").MustString("A")
+		if refIds[refId] {
+			return ApiError(400, "Duplicate query refId "+refId, nil)
+		}
+		refIds[refId] = true
+
+		datasourceId := query.Get("datasourceId").MustInt64()
+
+		ds, ok := datasources[datasourceId]
+		if !ok && datasourceId == 0 {
+			ds = grafanaTestDataSource
+		} else if !ok {
+			dsQuery := m.GetDataSourceByIdQuery{Id: datasourceId, OrgId: c.OrgId}
+			if err := bus.Dispatch(&dsQuery); err != nil {
+				response.Results[refId] = &tsdb.QueryResult{RefId: refId, Error: err}
+				continue
+			}
+			ds = dsQuery.Result
+			datasources[datasourceId] = ds
+		}
+
+		if rawSqlDataSourceTypes[ds.Type] && c.OrgRole != m.ROLE_EDITOR && c.OrgRole != m.ROLE_ADMIN {
+			return ApiError(403, "Only editors and admins can run sql queries", nil)
+		}
+
+		maxDataPoints := query.Get("maxDataPoints").MustInt64(100)
+		intervalMs := query.Get("intervalMs").MustInt64(0)
+		if intervalMs <= 0 {
+			intervalMs = calculateIntervalMs(timeRange, maxDataPoints)
+		}
+
+		request, ok := requests[ds.Id]
+		if !ok {
+			request = &tsdb.Request{TimeRange: timeRange}
+			requests[ds.Id] = request
+		}
+
 		request.Queries = append(request.Queries, &tsdb.Query{
-			RefId:         query.Get("refId").MustString("A"),
-			MaxDataPoints: query.Get("maxDataPoints").MustInt64(100),
-			IntervalMs:    query.Get("intervalMs").MustInt64(1000),
+			RefId:         refId,
+			MaxDataPoints: maxDataPoints,
+			IntervalMs:    intervalMs,
 			Model:         query,
-			DataSource: &tsdb.DataSourceInfo{
-				Name:     "Grafana TestDataDB",
-				PluginId: "grafana-testdata-datasource",
-			},
+			DataSource:    tsdb.NewDataSourceInfo(ds),
 		})
 	}
 
-	resp, err := tsdb.HandleRequest(context.TODO(), request)
-	if err != nil {
-		return ApiError(500, "Metric request error", err)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, request := range requests {
+		wg.Add(1)
+		go func(request *tsdb.Request) {
+			defer wg.Done()
+
+			resp, err := tsdb.HandleRequest(c.Req.Context(), request)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				for _, query := range request.Queries {
+					response.Results[query.RefId] = &tsdb.QueryResult{RefId: query.RefId, Error: err}
+				}
+				return
+			}
+
+			response.BatchTimings = append(response.BatchTimings, resp.BatchTimings...)
+			for refId, result := range resp.Results {
+				response.Results[refId] = result
+			}
+		}(request)
+	}
+
+	wg.Wait()
+
+	for refId, result := range response.Results {
+		result.RefId = refId
+		if result.Error != nil {
+			result.ErrorString = result.Error.Error()
+		}
+	}
+
+	return Json(200, &response)
+}
+
+// calculateIntervalMs spreads the max data points over the time range.
+func calculateIntervalMs(timeRange *tsdb.TimeRange, maxDataPoints int64) int64 {
+	if maxDataPoints <= 0 {
+		maxDataPoints = 100
+	}
+
+	intervalMs := (timeRange.GetToAsMsEpoch() - timeRange.GetFromAsMsEpoch()) / maxDataPoints
+	if intervalMs < 1 {
+		return 1
 	}
 
-	return Json(200, &resp)
+	return intervalMs
 }
 
 // GET /api/tsdb/testdata/scenarios
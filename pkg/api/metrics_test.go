@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/middleware"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+	macaron "gopkg.in/macaron.v1"
+)
+
+type fakeQueryExecutor struct {
+	dsInfo *tsdb.DataSourceInfo
+}
+
+func (e *fakeQueryExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) *tsdb.BatchResult {
+	result := &tsdb.BatchResult{QueryResults: make(map[string]*tsdb.QueryResult)}
+	for _, query := range queries {
+		queryRes := tsdb.NewQueryResult()
+		queryRes.Series = append(queryRes.Series, tsdb.NewTimeSeries(e.dsInfo.Name, tsdb.NewTimeSeriesPointsFromArgs(float64(query.IntervalMs), 0)))
+		result.QueryResults[query.RefId] = queryRes
+	}
+	return result
+}
+
+func TestQueryMetrics(t *testing.T) {
+	Convey("Given a tsdb query request", t, func() {
+		tsdb.RegisterExecutor("fake-tsdb", func(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+			return &fakeQueryExecutor{dsInfo: dsInfo}
+		})
+		tsdb.RegisterExecutor("grafana-testdata-datasource", func(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+			return &fakeQueryExecutor{dsInfo: dsInfo}
+		})
+
+		bus.ClearBusHandlers()
+		bus.AddHandler("test", func(query *m.GetDataSourceByIdQuery) error {
+			if query.OrgId != 1 {
+				return m.ErrDataSourceNotFound
+			}
+			switch query.Id {
+			case 1:
+				query.Result = &m.DataSource{Id: 1, OrgId: 1, Name: "fake", Type: "fake-tsdb"}
+			case 2:
+				query.Result = &m.DataSource{Id: 2, OrgId: 1, Name: "unknown", Type: "unknown"}
+			case 3:
+				query.Result = &m.DataSource{Id: 3, OrgId: 1, Name: "kpi", Type: "mysql"}
+			default:
+				return m.ErrDataSourceNotFound
+			}
+			return nil
+		})
+
+		req, _ := http.NewRequest("POST", "/api/tsdb/query", nil)
+		c := &middleware.Context{
+			Context:      &macaron.Context{Req: macaron.Request{Request: req}},
+			SignedInUser: &m.SignedInUser{OrgId: 1, OrgRole: m.ROLE_VIEWER},
+		}
+
+		newQuery := func(refId string, datasourceId int64) *simplejson.Json {
+			return simplejson.NewFromAny(map[string]interface{}{
+				"refId":         refId,
+				"datasourceId":  datasourceId,
+				"maxDataPoints": 60,
+			})
+		}
+
+		Convey("runs queries with their data source and returns errors per query", func() {
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{newQuery("A", 1), newQuery("B", 2), newQuery("C", 4)},
+			}).(*NormalResponse)
+
+			So(res.status, ShouldEqual, 200)
+
+			body, err := simplejson.NewJson(res.body)
+			So(err, ShouldBeNil)
+
+			results := body.Get("results")
+			So(results.GetPath("A", "series").GetIndex(0).Get("name").MustString(), ShouldEqual, "fake")
+			So(results.GetPath("A", "series").GetIndex(0).Get("points").GetIndex(0).GetIndex(0).MustFloat64(), ShouldEqual, 60000)
+			So(results.GetPath("A", "error").MustString(), ShouldEqual, "")
+			So(results.GetPath("B", "error").MustString(), ShouldContainSubstring, "Could not find executor")
+			So(results.GetPath("C", "error").MustString(), ShouldEqual, m.ErrDataSourceNotFound.Error())
+		})
+
+		Convey("does not run queries of data sources in other orgs", func() {
+			c.SignedInUser.OrgId = 2
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{newQuery("A", 1)},
+			}).(*NormalResponse)
+
+			body, _ := simplejson.NewJson(res.body)
+			So(body.GetPath("results", "A", "error").MustString(), ShouldEqual, m.ErrDataSourceNotFound.Error())
+		})
+
+		Convey("runs queries without data source with the test data source", func() {
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{simplejson.NewFromAny(map[string]interface{}{"refId": "A"})},
+			}).(*NormalResponse)
+
+			body, _ := simplejson.NewJson(res.body)
+			So(body.GetPath("results", "A", "series").GetIndex(0).Get("name").MustString(), ShouldEqual, "Grafana TestDataDB")
+		})
+
+		Convey("rejects sql queries of viewers", func() {
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{newQuery("A", 1), newQuery("B", 3)},
+			}).(*NormalResponse)
+
+			So(res.status, ShouldEqual, 403)
+		})
+
+		Convey("runs sql queries of editors", func() {
+			c.SignedInUser.OrgRole = m.ROLE_EDITOR
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{newQuery("A", 3)},
+			}).(*NormalResponse)
+
+			So(res.status, ShouldEqual, 200)
+		})
+
+		Convey("rejects queries with the same refId", func() {
+			res := QueryMetrics(c, dtos.MetricRequest{
+				From:    "1h",
+				To:      "now",
+				Queries: []*simplejson.Json{newQuery("A", 1), newQuery("A", 2)},
+			}).(*NormalResponse)
+
+			So(res.status, ShouldEqual, 400)
+		})
+
+		Convey("rejects requests without queries", func() {
+			res := QueryMetrics(c, dtos.MetricRequest{From: "1h", To: "now"}).(*NormalResponse)
+			So(res.status, ShouldEqual, 400)
+		})
+	})
+}
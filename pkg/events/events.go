@@ -89,3 +89,17 @@ type UserUpdated struct {
 	Login     string    `json:"login"`
 	Email     string    `json:"email"`
 }
+
+type AlertUpdated struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+}
+
+type AlertDeleted struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+}
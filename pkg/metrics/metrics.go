@@ -46,17 +46,31 @@ var (
 	M_Alerting_Notification_Sent_Email   		Counter
 	M_Alerting_Notification_Sent_Webhook 		Counter
 	M_Alerting_Notification_Sent_PagerDuty	Counter
+	M_Alerting_Notification_Sent_OpsGenie	Counter
+	M_Alerting_Notification_Sent_VictorOps	Counter
+	M_Alerting_Notification_Sent_Teams	Counter
+	M_Alerting_Notification_Sent_Telegram	Counter
+	M_Alerting_Notification_Sent_Alertmanager	Counter
+	M_Alerting_Jobs_Dropped              		Counter
+	M_Alerting_Evaluations_Skipped       		Counter
+	M_Alerting_Notification_Delivery_Retried	Counter
+	M_Alerting_Notification_Delivery_Failed	Counter
+	M_Tsdb_Query_Cache_Hit               		Counter
+	M_Tsdb_Query_Cache_Miss              		Counter
 
 
 	// Timers
 	M_DataSource_ProxyReq_Timer Timer
 	M_Alerting_Exeuction_Time   Timer
+	M_Alerting_Queue_Wait_Time  Timer
 
 	// StatTotals
 	M_StatTotal_Dashboards Gauge
 	M_StatTotal_Users      Gauge
 	M_StatTotal_Orgs       Gauge
 	M_StatTotal_Playlists  Gauge
+
+	M_Alerting_Queue_Depth Gauge
 )
 
 func initMetricVars(settings *MetricSettings) {
@@ -110,14 +124,29 @@ func initMetricVars(settings *MetricSettings) {
 	M_Alerting_Notification_Sent_Email = RegCounter("alerting.notifications_sent", "type", "email")
 	M_Alerting_Notification_Sent_Webhook = RegCounter("alerting.notifications_sent", "type", "webhook")
 	M_Alerting_Notification_Sent_PagerDuty = RegCounter("alerting.notifications_sent", "type", "pagerduty")
+	M_Alerting_Notification_Sent_OpsGenie = RegCounter("alerting.notifications_sent", "type", "opsgenie")
+	M_Alerting_Notification_Sent_VictorOps = RegCounter("alerting.notifications_sent", "type", "victorops")
+	M_Alerting_Notification_Sent_Teams = RegCounter("alerting.notifications_sent", "type", "teams")
+	M_Alerting_Notification_Sent_Telegram = RegCounter("alerting.notifications_sent", "type", "telegram")
+	M_Alerting_Notification_Sent_Alertmanager = RegCounter("alerting.notifications_sent", "type", "prometheus-alertmanager")
+	M_Alerting_Jobs_Dropped = RegCounter("alerting.jobs_dropped")
+	M_Alerting_Evaluations_Skipped = RegCounter("alerting.evaluations_skipped")
+	M_Alerting_Notification_Delivery_Retried = RegCounter("alerting.notification_deliveries_retried")
+	M_Alerting_Notification_Delivery_Failed = RegCounter("alerting.notification_deliveries_failed")
+
+	M_Tsdb_Query_Cache_Hit = RegCounter("tsdb.query_cache", "result", "hit")
+	M_Tsdb_Query_Cache_Miss = RegCounter("tsdb.query_cache", "result", "miss")
 
 	// Timers
 	M_DataSource_ProxyReq_Timer = RegTimer("api.dataproxy.request.all")
 	M_Alerting_Exeuction_Time = RegTimer("alerting.execution_time")
+	M_Alerting_Queue_Wait_Time = RegTimer("alerting.queue_wait_time")
 
 	// StatTotals
 	M_StatTotal_Dashboards = RegGauge("stat_totals", "stat", "dashboards")
 	M_StatTotal_Users = RegGauge("stat_totals", "stat", "users")
 	M_StatTotal_Orgs = RegGauge("stat_totals", "stat", "orgs")
 	M_StatTotal_Playlists = RegGauge("stat_totals", "stat", "playlists")
+
+	M_Alerting_Queue_Depth = RegGauge("alerting.queue_depth")
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -19,6 +20,13 @@ const (
 	AlertStatePending  AlertStateType = "pending"
 )
 
+const (
+	AlertSeverityCritical AlertSeverityType = "critical"
+	AlertSeverityError    AlertSeverityType = "error"
+	AlertSeverityWarning  AlertSeverityType = "warning"
+	AlertSeverityInfo     AlertSeverityType = "info"
+)
+
 const (
 	NoDataSetNoData   NoDataOption = "no_data"
 	NoDataSetAlerting NoDataOption = "alerting"
@@ -34,6 +42,10 @@ func (s AlertStateType) IsValid() bool {
 	return s == AlertStateOK || s == AlertStateNoData || s == AlertStatePaused || s == AlertStatePending
 }
 
+func (s AlertSeverityType) IsValid() bool {
+	return s == AlertSeverityCritical || s == AlertSeverityError || s == AlertSeverityWarning || s == AlertSeverityInfo
+}
+
 func (s NoDataOption) IsValid() bool {
 	return s == NoDataSetNoData || s == NoDataSetAlerting || s == NoDataKeepState
 }
@@ -68,6 +80,7 @@ type Alert struct {
 	EvalData     *simplejson.Json
 	EvalDate     time.Time
 	NewStateDate time.Time
+	PendingSince time.Time
 	StateChanges int
 
 	Created time.Time
@@ -104,6 +117,65 @@ func (this *Alert) ContainsUpdates(other *Alert) bool {
 	return result
 }
 
+// GetLabels returns the key/value labels from the alert settings.
+func (alert *Alert) GetLabels() map[string]string {
+	labels := make(map[string]string)
+	if alert.Settings == nil {
+		return labels
+	}
+
+	for key, value := range alert.Settings.Get("labels").MustMap() {
+		if str, ok := value.(string); ok {
+			labels[key] = str
+		}
+	}
+
+	return labels
+}
+
+// ParseAlertLabelFilters parses label filters like "team=payments". A
+// filter without a value only requires the label to be present, which is
+// stored as an empty value.
+func ParseAlertLabelFilters(filters []string) map[string]string {
+	result := make(map[string]string)
+	for _, filter := range filters {
+		filter = strings.TrimSpace(filter)
+		if filter == "" {
+			continue
+		}
+
+		parts := strings.SplitN(filter, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+
+		if len(parts) == 2 {
+			result[key] = strings.TrimSpace(parts[1])
+		} else {
+			result[key] = ""
+		}
+	}
+
+	return result
+}
+
+// MatchAlertLabels returns true when the labels satisfy every filter.
+func MatchAlertLabels(filters map[string]string, labels map[string]string) bool {
+	for key, value := range filters {
+		actual, exists := labels[key]
+		if !exists {
+			return false
+		}
+
+		if value != "" && actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
 type AlertingClusterInfo struct {
 	ServerId       string
 	ClusterSize    int
@@ -119,7 +191,9 @@ type HeartBeat struct {
 
 type HeartBeatCommand struct {
 	ServerId string
-	Result   AlertingClusterInfo
+	Timeout  time.Duration
+
+	Result AlertingClusterInfo
 }
 
 type SaveAlertsCommand struct {
@@ -137,11 +211,12 @@ type PauseAlertCommand struct {
 }
 
 type SetAlertStateCommand struct {
-	AlertId  int64
-	OrgId    int64
-	State    AlertStateType
-	Error    string
-	EvalData *simplejson.Json
+	AlertId      int64
+	OrgId        int64
+	State        AlertStateType
+	Error        string
+	EvalData     *simplejson.Json
+	PendingSince time.Time
 
 	Timestamp time.Time
 }
@@ -156,6 +231,8 @@ type GetAlertsQuery struct {
 	State       []string
 	DashboardId int64
 	PanelId     int64
+	Severity    []string
+	Labels      map[string]string
 	Limit       int64
 
 	Result []*Alert
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type AlertHistoryEntryType string
+
+const (
+	AlertHistoryStateChange  AlertHistoryEntryType = "state_change"
+	AlertHistoryError        AlertHistoryEntryType = "error"
+	AlertHistoryNotification AlertHistoryEntryType = "notification"
+)
+
+func (t AlertHistoryEntryType) IsValid() bool {
+	return t == AlertHistoryStateChange || t == AlertHistoryError || t == AlertHistoryNotification
+}
+
+// AlertHistoryEntry is a point in the timeline of an alert rule: an
+// evaluation that changed its state or failed, or the outcome of sending
+// one of its notifications. Epoch is in milliseconds.
+type AlertHistoryEntry struct {
+	Id                 int64                           `json:"id"`
+	OrgId              int64                           `json:"-"`
+	AlertId            int64                           `json:"alertId"`
+	Type               AlertHistoryEntryType           `json:"type"`
+	PrevState          AlertStateType                  `json:"prevState"`
+	NewState           AlertStateType                  `json:"newState"`
+	EvalMatches        *simplejson.Json                `json:"evalMatches"`
+	Error              string                          `json:"error"`
+	NotifierId         int64                           `json:"notifierId"`
+	NotificationStatus AlertNotificationDeliveryStatus `json:"notificationStatus"`
+	Epoch              int64                           `json:"time"`
+}
+
+type SaveAlertHistoryEntryCommand struct {
+	OrgId              int64
+	AlertId            int64
+	Type               AlertHistoryEntryType
+	PrevState          AlertStateType
+	NewState           AlertStateType
+	EvalMatches        *simplejson.Json
+	Error              string
+	NotifierId         int64
+	NotificationStatus AlertNotificationDeliveryStatus
+	Epoch              int64
+}
+
+type DeleteExpiredAlertHistoryCommand struct {
+	OlderThan time.Time
+}
+
+// GetAlertHistoryQuery returns a page of the alert timeline, newest first.
+// From and To are epochs in milliseconds like the entries, states match
+// the new state of the entries and pages start at 1.
+type GetAlertHistoryQuery struct {
+	OrgId   int64
+	AlertId int64
+	From    int64
+	To      int64
+	State   []string
+	Type    AlertHistoryEntryType
+	Page    int
+	PerPage int
+
+	Result *AlertHistoryResult
+}
+
+type AlertHistoryResult struct {
+	TotalCount int64                `json:"totalCount"`
+	Page       int                  `json:"page"`
+	PerPage    int                  `json:"perPage"`
+	Entries    []*AlertHistoryEntry `json:"entries"`
+}
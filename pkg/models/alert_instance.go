@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AlertInstance is the state of a single series of an alert rule,
+// identified by the metric name and tags of its eval match.
+type AlertInstance struct {
+	Id           int64             `json:"-"`
+	OrgId        int64             `json:"-"`
+	AlertId      int64             `json:"-"`
+	InstanceHash string            `json:"-"`
+	InstanceKey  string            `json:"key"`
+	Metric       string            `json:"metric"`
+	Tags         map[string]string `json:"tags"`
+	State        AlertStateType    `json:"state"`
+	Value        float64           `json:"value"`
+	FirstSeen    time.Time         `json:"firstSeen"`
+	ResolvedAt   time.Time         `json:"resolvedAt"`
+	Updated      time.Time         `json:"updated"`
+}
+
+// SaveAlertInstancesCommand inserts or updates the instances that changed
+// state and deletes the instances of RemovedIds.
+type SaveAlertInstancesCommand struct {
+	OrgId      int64
+	AlertId    int64
+	Instances  []*AlertInstance
+	RemovedIds []int64
+}
+
+type GetAlertInstancesQuery struct {
+	OrgId   int64
+	AlertId int64
+
+	Result []*AlertInstance
+}
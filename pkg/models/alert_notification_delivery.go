@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type AlertNotificationDeliveryStatus string
+
+const (
+	AlertNotificationDeliveryPending AlertNotificationDeliveryStatus = "pending"
+	AlertNotificationDeliverySent    AlertNotificationDeliveryStatus = "sent"
+	AlertNotificationDeliveryFailed  AlertNotificationDeliveryStatus = "failed"
+	// superseded deliveries were still pending when a newer notification
+	// for the same alert and channel was created, so they are not sent
+	AlertNotificationDeliverySuperseded AlertNotificationDeliveryStatus = "superseded"
+)
+
+var ErrAlertNotificationDeliveryNotFound = errors.New("Alert notification delivery not found")
+
+// AlertNotificationDelivery is an entry in the notification outbox. The
+// payload holds a snapshot of the evaluation so the notification can be
+// sent again after a failure.
+type AlertNotificationDelivery struct {
+	Id          int64                           `json:"id"`
+	OrgId       int64                           `json:"-"`
+	AlertId     int64                           `json:"alertId"`
+	NotifierId  int64                           `json:"notifierId"`
+	Status      AlertNotificationDeliveryStatus `json:"status"`
+	Attempts    int                             `json:"attempts"`
+	LastError   string                          `json:"lastError"`
+	Payload     *simplejson.Json                `json:"-"`
+	NextAttempt time.Time                       `json:"nextAttempt"`
+	Created     time.Time                       `json:"created"`
+	Updated     time.Time                       `json:"updated"`
+}
+
+type CreateAlertNotificationDeliveryCommand struct {
+	OrgId       int64
+	AlertId     int64
+	NotifierId  int64
+	Payload     *simplejson.Json
+	NextAttempt time.Time
+
+	Result *AlertNotificationDelivery
+}
+
+type UpdateAlertNotificationDeliveryCommand struct {
+	Id          int64
+	Status      AlertNotificationDeliveryStatus
+	Attempts    int
+	LastError   string
+	NextAttempt time.Time
+}
+
+// ClaimAlertNotificationDeliveryCommand moves the next attempt of a due
+// pending delivery to Until, so only one server sends it. Claimed is false
+// when another server claimed the delivery first or it is no longer pending.
+type ClaimAlertNotificationDeliveryCommand struct {
+	Id    int64
+	Now   time.Time
+	Until time.Time
+
+	Claimed bool
+}
+
+type DeleteExpiredAlertNotificationDeliveriesCommand struct {
+	OlderThan time.Time
+}
+
+// GetPendingAlertNotificationDeliveriesQuery returns pending deliveries
+// that are due for another attempt, oldest first.
+type GetPendingAlertNotificationDeliveriesQuery struct {
+	DueBefore time.Time
+	Limit     int
+
+	Result []*AlertNotificationDelivery
+}
+
+type GetAlertNotificationDeliveriesQuery struct {
+	OrgId      int64
+	NotifierId int64
+	Status     AlertNotificationDeliveryStatus
+	Limit      int
+
+	Result []*AlertNotificationDelivery
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAlertSilenceNotFound         = errors.New("Alert silence not found")
+	ErrAlertSilenceInvalidTimeRange = errors.New("Alert silence must end after it starts")
+)
+
+// AlertSilence mutes notifications for all alerts matching its
+// matchers between StartsAt and EndsAt. Empty matchers match any alert.
+type AlertSilence struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"-"`
+	DashboardId int64     `json:"dashboardId"`
+	AlertId     int64     `json:"alertId"`
+	Tag         string    `json:"tag"`
+	NameRegex   string    `json:"nameRegex"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	Comment     string    `json:"comment"`
+	CreatedBy   int64     `json:"createdBy"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (s *AlertSilence) IsActive(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+type CreateAlertSilenceCommand struct {
+	DashboardId int64     `json:"dashboardId"`
+	AlertId     int64     `json:"alertId"`
+	Tag         string    `json:"tag"`
+	NameRegex   string    `json:"nameRegex"`
+	StartsAt    time.Time `json:"startsAt" binding:"Required"`
+	EndsAt      time.Time `json:"endsAt" binding:"Required"`
+	Comment     string    `json:"comment"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+	Result *AlertSilence
+}
+
+type UpdateAlertSilenceCommand struct {
+	Id          int64     `json:"id"`
+	DashboardId int64     `json:"dashboardId"`
+	AlertId     int64     `json:"alertId"`
+	Tag         string    `json:"tag"`
+	NameRegex   string    `json:"nameRegex"`
+	StartsAt    time.Time `json:"startsAt" binding:"Required"`
+	EndsAt      time.Time `json:"endsAt" binding:"Required"`
+	Comment     string    `json:"comment"`
+
+	OrgId  int64 `json:"-"`
+	Result *AlertSilence
+}
+
+type DeleteAlertSilenceCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+type GetAlertSilenceByIdQuery struct {
+	Id    int64
+	OrgId int64
+
+	Result *AlertSilence
+}
+
+type GetAlertSilencesQuery struct {
+	OrgId    int64
+	ActiveAt time.Time
+
+	Result []*AlertSilence
+}
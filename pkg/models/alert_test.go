@@ -35,5 +35,20 @@ func TestAlertingModelTest(t *testing.T) {
 			rule1.Settings = json2
 			So(rule1.ContainsUpdates(rule2), ShouldBeTrue)
 		})
+
+		Convey("Labels are read from settings", func() {
+			rule1.Settings, _ = simplejson.NewJson([]byte(`{ "labels": { "team": "payments", "invalid": 1 } }`))
+			So(rule1.GetLabels(), ShouldResemble, map[string]string{"team": "payments"})
+		})
+	})
+
+	Convey("Testing alert label filters", t, func() {
+		filters := ParseAlertLabelFilters([]string{"team = payments", "env", "", "=prod"})
+		So(filters, ShouldResemble, map[string]string{"team": "payments", "env": ""})
+
+		So(MatchAlertLabels(filters, map[string]string{"team": "payments", "env": "prod"}), ShouldBeTrue)
+		So(MatchAlertLabels(filters, map[string]string{"team": "payments"}), ShouldBeFalse)
+		So(MatchAlertLabels(filters, map[string]string{"team": "ops", "env": "prod"}), ShouldBeFalse)
+		So(MatchAlertLabels(map[string]string{}, map[string]string{}), ShouldBeTrue)
 	})
 }
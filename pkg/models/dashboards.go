@@ -160,6 +160,11 @@ type GetDashboardTagsQuery struct {
 	Result []*DashboardTagCloudItem
 }
 
+type GetDashboardTagsByIdQuery struct {
+	DashboardId int64
+	Result      []string
+}
+
 type GetDashboardsQuery struct {
 	DashboardIds []int64
 	Result       []*Dashboard
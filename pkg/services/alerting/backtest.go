@@ -0,0 +1,215 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	backtestMaxEvaluations int = 20000
+	backtestConcurrency    int = 10
+	backtestTimeout            = time.Minute * 5
+)
+
+// AlertBacktestCommand evaluates the alert rule of a panel at its frequency
+// over a time range in the past and simulates the state changes it would
+// have made. The backtest stops when Ctx is done or after backtestTimeout.
+type AlertBacktestCommand struct {
+	Ctx       context.Context
+	Dashboard *simplejson.Json
+	PanelId   int64
+	OrgId     int64
+	From      time.Time
+	To        time.Time
+
+	Result *BacktestResult
+}
+
+type BacktestResult struct {
+	Evaluations   int                    `json:"evaluations"`
+	Errors        int                    `json:"errors"`
+	Notifications int                    `json:"notifications"`
+	StateChanges  []*BacktestStateChange `json:"stateChanges"`
+}
+
+type BacktestStateChange struct {
+	Time        time.Time        `json:"time"`
+	PrevState   m.AlertStateType `json:"prevState"`
+	NewState    m.AlertStateType `json:"newState"`
+	EvalMatches []*EvalMatch     `json:"evalMatches"`
+	Error       string           `json:"error,omitempty"`
+}
+
+func init() {
+	bus.AddHandler("alerting", handleAlertBacktestCommand)
+}
+
+func handleAlertBacktestCommand(cmd *AlertBacktestCommand) error {
+	dash := m.NewDashboardFromJson(cmd.Dashboard)
+
+	extractor := NewDashAlertExtractor(dash, cmd.OrgId)
+	alerts, err := extractor.GetAlerts()
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		if alert.PanelId == cmd.PanelId {
+			rule, err := NewRuleFromDBAlert(alert)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			ctx, cancelFn := context.WithTimeout(ctx, backtestTimeout)
+			defer cancelFn()
+
+			result, err := backtestAlertRule(ctx, rule, cmd.From, cmd.To)
+			if err == context.DeadlineExceeded {
+				return ValidationError{Reason: fmt.Sprintf("Backtest did not finish within %s, use a shorter time range", backtestTimeout)}
+			}
+			if err != nil {
+				return err
+			}
+
+			cmd.Result = result
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Could not find alert with panel id %d", cmd.PanelId)
+}
+
+// getBacktestTimes returns the times the rule would have been evaluated at
+// between from and to.
+func getBacktestTimes(rule *Rule, from, to time.Time) ([]time.Time, error) {
+	if !from.Before(to) {
+		return nil, ValidationError{Reason: "Backtest time range must start before it ends"}
+	}
+
+	if rule.Frequency <= 0 {
+		return nil, ValidationError{Reason: "Backtest needs a rule frequency"}
+	}
+
+	frequency := time.Duration(rule.Frequency) * time.Second
+	if int64(to.Sub(from)/frequency) >= int64(backtestMaxEvaluations) {
+		return nil, ValidationError{Reason: fmt.Sprintf("Backtest is limited to %d evaluations, use a shorter time range", backtestMaxEvaluations)}
+	}
+
+	times := make([]time.Time, 0)
+	for t := from; !t.After(to); t = t.Add(frequency) {
+		times = append(times, t)
+	}
+
+	return times, nil
+}
+
+// backtestAlertRule evaluates the rule at each point in time with a clock
+// set to that time. Evaluations do not depend on each other so they run
+// concurrently, the states are then folded in order using the same rules
+// as the result handler.
+func backtestAlertRule(ctx context.Context, rule *Rule, from, to time.Time) (*BacktestResult, error) {
+	times, err := getBacktestTimes(rule, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make([]*EvalContext, len(times))
+	sem := make(chan struct{}, backtestConcurrency)
+	var wg sync.WaitGroup
+
+	for i, evalTime := range times {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		evalTime := evalTime
+		contexts[i] = NewEvalContext(ctx, rule)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(evalContext *EvalContext) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			handler := newEvalHandlerWithClock(func() time.Time { return evalTime })
+			handler.Eval(evalContext)
+		}(contexts[i])
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return simulateStateChanges(rule, contexts), nil
+}
+
+// simulateStateChanges folds the evaluations into state changes, starting
+// from the pending state a new rule has. Each step gets a context of its own
+// holding the evaluation result and the simulated rule state.
+func simulateStateChanges(rule *Rule, contexts []*EvalContext) *BacktestResult {
+	result := &BacktestResult{StateChanges: make([]*BacktestStateChange, 0)}
+	resultHandler := NewResultHandler()
+
+	simulated := *rule
+	simulated.State = m.AlertStatePending
+	simulated.PendingSince = time.Time{}
+
+	for _, evaluated := range contexts {
+		evalContext := &EvalContext{
+			Firing:         evaluated.Firing,
+			NoDataFound:    evaluated.NoDataFound,
+			Error:          evaluated.Error,
+			EvalMatches:    evaluated.EvalMatches,
+			StartTime:      evaluated.StartTime,
+			Rule:           &simulated,
+			PrevAlertState: simulated.State,
+			log:            evaluated.log,
+		}
+
+		simulated.State = resultHandler.GetStateFromEvaluation(evalContext)
+		if simulated.State != m.AlertStatePending {
+			simulated.PendingSince = time.Time{}
+		}
+
+		result.Evaluations++
+		if evalContext.Error != nil {
+			result.Errors++
+		}
+
+		if !evalContext.ShouldUpdateAlertState() {
+			continue
+		}
+
+		change := &BacktestStateChange{
+			Time:        evalContext.StartTime,
+			PrevState:   evalContext.PrevAlertState,
+			NewState:    simulated.State,
+			EvalMatches: evalContext.EvalMatches,
+		}
+
+		if evalContext.Error != nil {
+			change.Error = evalContext.Error.Error()
+		}
+
+		result.StateChanges = append(result.StateChanges, change)
+
+		if evalContext.ShouldSendNotification() {
+			result.Notifications++
+		}
+	}
+
+	return result
+}
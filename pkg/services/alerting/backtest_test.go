@@ -0,0 +1,96 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// clockConditionStub fires while the evaluation time is within one of the
+// firing windows.
+type clockConditionStub struct {
+	firing [][2]time.Time
+}
+
+func (c *clockConditionStub) Eval(context *EvalContext) (*ConditionResult, error) {
+	for _, window := range c.firing {
+		if !context.StartTime.Before(window[0]) && context.StartTime.Before(window[1]) {
+			return &ConditionResult{Firing: true, EvalMatches: []*EvalMatch{{Metric: "cpu", Value: 95}}}, nil
+		}
+	}
+
+	return &ConditionResult{}, nil
+}
+
+func TestAlertBacktest(t *testing.T) {
+	Convey("Backtesting alert rules", t, func() {
+		from := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(time.Hour)
+
+		rule := &Rule{
+			Frequency: 60,
+			State:     m.AlertStateAlerting,
+			Conditions: []Condition{&clockConditionStub{firing: [][2]time.Time{
+				{from.Add(10 * time.Minute), from.Add(20 * time.Minute)},
+				{from.Add(40 * time.Minute), from.Add(42 * time.Minute)},
+			}}},
+		}
+
+		Convey("evaluates the rule at its frequency", func() {
+			times, err := getBacktestTimes(rule, from, to)
+			So(err, ShouldBeNil)
+			So(len(times), ShouldEqual, 61)
+			So(times[1], ShouldResemble, from.Add(time.Minute))
+		})
+
+		Convey("rejects invalid time ranges", func() {
+			_, err := getBacktestTimes(rule, to, from)
+			So(err, ShouldNotBeNil)
+
+			_, err = getBacktestTimes(rule, from, from.Add(time.Duration(backtestMaxEvaluations)*time.Minute))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("simulates state changes", func() {
+			result, err := backtestAlertRule(context.TODO(), rule, from, to)
+			So(err, ShouldBeNil)
+
+			So(result.Evaluations, ShouldEqual, 61)
+			So(len(result.StateChanges), ShouldEqual, 5)
+			So(result.StateChanges[0].NewState, ShouldEqual, m.AlertStateOK)
+			So(result.StateChanges[0].Time, ShouldResemble, from)
+			So(result.StateChanges[1].NewState, ShouldEqual, m.AlertStateAlerting)
+			So(result.StateChanges[1].Time, ShouldResemble, from.Add(10*time.Minute))
+			So(result.StateChanges[1].EvalMatches[0].Metric, ShouldEqual, "cpu")
+			So(result.StateChanges[2].NewState, ShouldEqual, m.AlertStateOK)
+			So(result.StateChanges[2].Time, ShouldResemble, from.Add(20*time.Minute))
+			So(result.Notifications, ShouldEqual, 4)
+		})
+
+		Convey("stops when the context is done", func() {
+			ctx, cancelFn := context.WithCancel(context.Background())
+			cancelFn()
+
+			_, err := backtestAlertRule(ctx, rule, from, to)
+			So(err, ShouldEqual, context.Canceled)
+		})
+
+		Convey("keeps rule pending for the configured duration", func() {
+			rule.For = 5 * time.Minute
+			result, err := backtestAlertRule(context.TODO(), rule, from, to)
+			So(err, ShouldBeNil)
+
+			// the second window is shorter than the for duration
+			So(len(result.StateChanges), ShouldEqual, 6)
+			So(result.StateChanges[1].NewState, ShouldEqual, m.AlertStatePending)
+			So(result.StateChanges[2].NewState, ShouldEqual, m.AlertStateAlerting)
+			So(result.StateChanges[2].Time, ShouldResemble, from.Add(15*time.Minute))
+			So(result.StateChanges[4].NewState, ShouldEqual, m.AlertStatePending)
+			So(result.StateChanges[5].NewState, ShouldEqual, m.AlertStateOK)
+			So(result.Notifications, ShouldEqual, 2)
+		})
+	})
+}
@@ -2,9 +2,11 @@ package conditions
 
 import (
 	"encoding/json"
+	"math"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/tsdb"
 	"gopkg.in/guregu/null.v3"
 )
 
@@ -17,6 +19,14 @@ type AlertEvaluator interface {
 	Eval(reducedValue null.Float) bool
 }
 
+// SeriesEvaluator is implemented by evaluators that compare the latest
+// value of a series against a baseline computed from the earlier values of
+// the same series. They return the latest value and whether it matched.
+type SeriesEvaluator interface {
+	AlertEvaluator
+	EvalSeries(series *tsdb.TimeSeries) (null.Float, bool)
+}
+
 type NoDataEvaluator struct{}
 
 func (e *NoDataEvaluator) Eval(reducedValue null.Float) bool {
@@ -104,6 +114,254 @@ func (e *RangedEvaluator) Eval(reducedValue null.Float) bool {
 	return false
 }
 
+// ZScoreEvaluator matches when the latest value is more than Deviations
+// standard deviations away from the mean of the Window values before it.
+// A window of 0 uses all earlier values.
+type ZScoreEvaluator struct {
+	Deviations float64
+	Window     int
+}
+
+func newZScoreEvaluator(model *simplejson.Json) (*ZScoreEvaluator, error) {
+	params := model.Get("params").MustArray()
+
+	deviations, err := getFloatParam(params, 0, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := getFloatParam(params, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if deviations <= 0 || window < 0 {
+		return nil, alerting.ValidationError{Reason: "Evaluator zscore needs a positive number of deviations and window"}
+	}
+
+	return &ZScoreEvaluator{Deviations: deviations, Window: int(window)}, nil
+}
+
+func (e *ZScoreEvaluator) Eval(reducedValue null.Float) bool {
+	return false
+}
+
+func (e *ZScoreEvaluator) EvalSeries(series *tsdb.TimeSeries) (null.Float, bool) {
+	latest, baseline := splitLatestValue(series)
+	if !latest.Valid {
+		return latest, false
+	}
+
+	if e.Window > 0 && len(baseline) > e.Window {
+		baseline = baseline[len(baseline)-e.Window:]
+	}
+
+	values := make([]float64, 0, len(baseline))
+	for _, value := range baseline {
+		if value.Valid {
+			values = append(values, value.Float64)
+		}
+	}
+
+	if len(values) < 2 {
+		return latest, false
+	}
+
+	mean, stddev := meanAndStddev(values)
+	return latest, isOutsideBand(latest.Float64, mean, e.Deviations*stddev)
+}
+
+// HoltWintersEvaluator forecasts the latest value with additive Holt-Winters
+// smoothing over the earlier values and matches when it is more than
+// Deviations standard deviations of the forecast errors away from the
+// forecast. SeasonLength is the number of points in a season, for example
+// 24 for a day of hourly points, and at least two seasons are needed.
+type HoltWintersEvaluator struct {
+	Deviations   float64
+	SeasonLength int
+	Alpha        float64
+	Beta         float64
+	Gamma        float64
+}
+
+func newHoltWintersEvaluator(model *simplejson.Json) (*HoltWintersEvaluator, error) {
+	params := model.Get("params").MustArray()
+	defaults := []float64{3, 0, 0.5, 0.1, 0.1}
+	values := make([]float64, len(defaults))
+
+	for i, defaultValue := range defaults {
+		value, err := getFloatParam(params, i, defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	evaluator := &HoltWintersEvaluator{
+		Deviations:   values[0],
+		SeasonLength: int(values[1]),
+		Alpha:        values[2],
+		Beta:         values[3],
+		Gamma:        values[4],
+	}
+
+	if evaluator.Deviations <= 0 {
+		return nil, alerting.ValidationError{Reason: "Evaluator holt_winters needs a positive number of deviations"}
+	}
+
+	if evaluator.SeasonLength < 2 {
+		return nil, alerting.ValidationError{Reason: "Evaluator holt_winters needs a season length of at least 2 points"}
+	}
+
+	for _, factor := range values[2:] {
+		if factor < 0 || factor > 1 {
+			return nil, alerting.ValidationError{Reason: "Evaluator holt_winters smoothing factors must be between 0 and 1"}
+		}
+	}
+
+	return evaluator, nil
+}
+
+func (e *HoltWintersEvaluator) Eval(reducedValue null.Float) bool {
+	return false
+}
+
+func (e *HoltWintersEvaluator) EvalSeries(series *tsdb.TimeSeries) (null.Float, bool) {
+	latest, baseline := splitLatestValue(series)
+	if !latest.Valid {
+		return latest, false
+	}
+
+	forecast, stddev, ok := e.forecast(baseline)
+	if !ok {
+		return latest, false
+	}
+
+	return latest, isOutsideBand(latest.Float64, forecast, e.Deviations*stddev)
+}
+
+// forecast returns the forecast for the point after the baseline and the
+// standard deviation of the one step forecast errors. Level and trend are
+// initialized from the first two seasons, null values are replaced by
+// their forecast.
+func (e *HoltWintersEvaluator) forecast(baseline []null.Float) (float64, float64, bool) {
+	m := e.SeasonLength
+	if len(baseline) < 2*m {
+		return 0, 0, false
+	}
+
+	firstMean, firstOk := meanOfValid(baseline[:m])
+	secondMean, secondOk := meanOfValid(baseline[m : 2*m])
+	if !firstOk || !secondOk {
+		return 0, 0, false
+	}
+
+	level := firstMean
+	trend := (secondMean - firstMean) / float64(m)
+	seasonal := make([]float64, m)
+	for i, value := range baseline[:m] {
+		if value.Valid {
+			seasonal[i] = value.Float64 - level
+		}
+	}
+
+	errors := make([]float64, 0, len(baseline)-m)
+	for t := m; t < len(baseline); t++ {
+		season := t % m
+		forecast := level + trend + seasonal[season]
+
+		value := forecast
+		if baseline[t].Valid {
+			value = baseline[t].Float64
+			errors = append(errors, value-forecast)
+		}
+
+		prevLevel := level
+		level = e.Alpha*(value-seasonal[season]) + (1-e.Alpha)*(level+trend)
+		trend = e.Beta*(level-prevLevel) + (1-e.Beta)*trend
+		seasonal[season] = e.Gamma*(value-level) + (1-e.Gamma)*seasonal[season]
+	}
+
+	if len(errors) < 2 {
+		return 0, 0, false
+	}
+
+	stddev := 0.0
+	for _, err := range errors {
+		stddev += err * err
+	}
+	stddev = math.Sqrt(stddev / float64(len(errors)))
+
+	return level + trend + seasonal[len(baseline)%m], stddev, true
+}
+
+// splitLatestValue returns the last non null value of the series and the
+// values before it.
+func splitLatestValue(series *tsdb.TimeSeries) (null.Float, []null.Float) {
+	for i := len(series.Points) - 1; i >= 0; i-- {
+		if series.Points[i][0].Valid {
+			baseline := make([]null.Float, i)
+			for j := 0; j < i; j++ {
+				baseline[j] = series.Points[j][0]
+			}
+			return series.Points[i][0], baseline
+		}
+	}
+
+	return null.FloatFromPtr(nil), nil
+}
+
+func meanOfValid(values []null.Float) (float64, bool) {
+	sum := 0.0
+	count := 0
+	for _, value := range values {
+		if value.Valid {
+			sum += value.Float64
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	return sum / float64(count), true
+}
+
+func meanAndStddev(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, value := range values {
+		mean += value
+	}
+	mean = mean / float64(len(values))
+
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// isOutsideBand allows for rounding errors when the band is empty, which
+// happens when the baseline is perfectly flat or perfectly predicted.
+func isOutsideBand(value, center, width float64) bool {
+	return math.Abs(value-center) > math.Max(width, 1e-9)
+}
+
+func getFloatParam(params []interface{}, index int, defaultValue float64) (float64, error) {
+	if len(params) <= index {
+		return defaultValue, nil
+	}
+
+	param, ok := params[index].(json.Number)
+	if !ok {
+		return 0, alerting.ValidationError{Reason: "Evaluator has invalid parameter"}
+	}
+
+	return param.Float64()
+}
+
 func NewAlertEvaluator(model *simplejson.Json) (AlertEvaluator, error) {
 	typ := model.Get("type").MustString()
 	if typ == "" {
@@ -122,6 +380,14 @@ func NewAlertEvaluator(model *simplejson.Json) (AlertEvaluator, error) {
 		return &NoDataEvaluator{}, nil
 	}
 
+	if typ == "zscore" {
+		return newZScoreEvaluator(model)
+	}
+
+	if typ == "holt_winters" {
+		return newHoltWintersEvaluator(model)
+	}
+
 	return nil, alerting.ValidationError{Reason: "Evaluator invalid evaluator type: " + typ}
 }
 
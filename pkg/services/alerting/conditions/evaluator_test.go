@@ -6,6 +6,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -19,6 +20,32 @@ func evalutorScenario(json string, reducedValue float64, datapoints ...float64)
 	return evaluator.Eval(null.FloatFrom(reducedValue))
 }
 
+func seriesEvalutorScenario(json string, datapoints ...null.Float) (null.Float, bool) {
+	jsonModel, err := simplejson.NewJson([]byte(json))
+	So(err, ShouldBeNil)
+
+	evaluator, err := NewAlertEvaluator(jsonModel)
+	So(err, ShouldBeNil)
+
+	seriesEvaluator, ok := evaluator.(SeriesEvaluator)
+	So(ok, ShouldBeTrue)
+
+	points := make(tsdb.TimeSeriesPoints, 0)
+	for i, value := range datapoints {
+		points = append(points, tsdb.NewTimePoint(value, float64(i*1000)))
+	}
+
+	return seriesEvaluator.EvalSeries(tsdb.NewTimeSeries("test", points))
+}
+
+func floats(values ...float64) []null.Float {
+	result := make([]null.Float, 0, len(values))
+	for _, value := range values {
+		result = append(result, null.FloatFrom(value))
+	}
+	return result
+}
+
 func TestEvalutors(t *testing.T) {
 	Convey("greater then", t, func() {
 		So(evalutorScenario(`{"type": "gt", "params": [1] }`, 3), ShouldBeTrue)
@@ -55,4 +82,113 @@ func TestEvalutors(t *testing.T) {
 
 		So(evaluator.Eval(null.FloatFromPtr(nil)), ShouldBeTrue)
 	})
+
+	Convey("zscore", t, func() {
+		baseline := floats(10, 12, 10, 12, 10, 12)
+
+		value, match := seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, append(baseline, null.FloatFrom(13))...)
+		So(value.Float64, ShouldEqual, 13)
+		So(match, ShouldBeFalse)
+
+		_, match = seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, append(baseline, null.FloatFrom(20))...)
+		So(match, ShouldBeTrue)
+
+		_, match = seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, append(baseline, null.FloatFrom(5))...)
+		So(match, ShouldBeTrue)
+
+		Convey("uses the last non null value", func() {
+			value, match := seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, append(baseline, null.FloatFrom(20), null.FloatFromPtr(nil))...)
+			So(value.Float64, ShouldEqual, 20)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("only looks at the window", func() {
+			series := append(floats(100, 0, 100, 0), baseline...)
+			series = append(series, null.FloatFrom(20))
+
+			_, match := seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, series...)
+			So(match, ShouldBeFalse)
+
+			_, match = seriesEvalutorScenario(`{"type": "zscore", "params": [3, 6] }`, series...)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("flat baseline", func() {
+			_, match := seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, floats(5, 5, 5, 5)...)
+			So(match, ShouldBeFalse)
+
+			_, match = seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, floats(5, 5, 5, 6)...)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("needs a baseline", func() {
+			_, match := seriesEvalutorScenario(`{"type": "zscore", "params": [3] }`, floats(5, 100)...)
+			So(match, ShouldBeFalse)
+		})
+
+		Convey("validates params", func() {
+			model, _ := simplejson.NewJson([]byte(`{"type": "zscore", "params": [0] }`))
+			_, err := NewAlertEvaluator(model)
+			So(err, ShouldNotBeNil)
+
+			model, _ = simplejson.NewJson([]byte(`{"type": "zscore", "params": ["abc"] }`))
+			_, err = NewAlertEvaluator(model)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("holt_winters", t, func() {
+		season := []float64{1, 5, 3, 7}
+		baseline := make([]null.Float, 0)
+		for i := 0; i < 4; i++ {
+			baseline = append(baseline, floats(season...)...)
+		}
+
+		Convey("follows the season", func() {
+			value, match := seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(baseline, null.FloatFrom(1))...)
+			So(value.Float64, ShouldEqual, 1)
+			So(match, ShouldBeFalse)
+
+			// within the range of the series but not where the season is
+			_, match = seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(baseline, null.FloatFrom(7))...)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("uses the spread of the forecast errors", func() {
+			noise := []float64{0.3, -0.3, 0}
+			noisy := make([]null.Float, len(baseline))
+			for i, value := range baseline {
+				noisy[i] = null.FloatFrom(value.Float64 + noise[i%3])
+			}
+
+			_, match := seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(noisy, null.FloatFrom(2))...)
+			So(match, ShouldBeFalse)
+
+			_, match = seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(noisy, null.FloatFrom(5))...)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("fills null values with the forecast", func() {
+			series := append([]null.Float{}, baseline...)
+			series[10] = null.FloatFromPtr(nil)
+
+			_, match := seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(series, null.FloatFrom(1))...)
+			So(match, ShouldBeFalse)
+		})
+
+		Convey("needs two seasons of baseline", func() {
+			_, match := seriesEvalutorScenario(`{"type": "holt_winters", "params": [3, 4] }`, append(baseline[:7], null.FloatFrom(100))...)
+			So(match, ShouldBeFalse)
+		})
+
+		Convey("validates params", func() {
+			model, _ := simplejson.NewJson([]byte(`{"type": "holt_winters", "params": [3] }`))
+			_, err := NewAlertEvaluator(model)
+			So(err, ShouldNotBeNil)
+
+			model, _ = simplejson.NewJson([]byte(`{"type": "holt_winters", "params": [3, 4, 1.5] }`))
+			_, err = NewAlertEvaluator(model)
+			So(err, ShouldNotBeNil)
+		})
+	})
 }
@@ -0,0 +1,198 @@
+package conditions
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/expression"
+	"gopkg.in/guregu/null.v3"
+)
+
+func init() {
+	alerting.RegisterCondition("expression", func(model *simplejson.Json, index int) (alerting.Condition, error) {
+		return NewExpressionCondition(model, index)
+	})
+}
+
+// ExpressionCondition calculates an expression over the results of the
+// query conditions in the same rule, referenced by their query refId.
+//
+// Without a reducer each reference is the reduced value of its query
+// condition. With a reducer the expression is calculated point by point
+// over the raw series, and the result is reduced afterwards.
+type ExpressionCondition struct {
+	Index      int
+	Expression *expression.Expression
+	Reducer    QueryReducer
+	Evaluator  AlertEvaluator
+	Operator   string
+}
+
+func (c *ExpressionCondition) Eval(context *alerting.EvalContext) (*alerting.ConditionResult, error) {
+	refs, err := c.getReferencedConditions(context)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesList, err := c.calculate(context, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	emptySerieCount := 0
+	evalMatchCount := 0
+	var matches []*alerting.EvalMatch
+	for _, series := range seriesList {
+		reducedValue, evalMatch := c.evaluate(series)
+		if reducedValue.Valid == false {
+			emptySerieCount++
+			continue
+		}
+
+		if context.IsTestRun {
+			context.Logs = append(context.Logs, &alerting.ResultLogEntry{
+				Message: fmt.Sprintf("Condition[%d]: Eval: %v, Metric: %s, Value: %1.3f", c.Index, evalMatch, series.Name, reducedValue.Float64),
+			})
+		}
+
+		if evalMatch {
+			evalMatchCount++
+
+			matches = append(matches, &alerting.EvalMatch{
+				Metric: series.Name,
+				Value:  reducedValue.Float64,
+			})
+		}
+	}
+
+	return &alerting.ConditionResult{
+		Firing:      evalMatchCount > 0,
+		NoDataFound: emptySerieCount == len(seriesList),
+		Operator:    c.Operator,
+		EvalMatches: matches,
+	}, nil
+}
+
+func (c *ExpressionCondition) getReferencedConditions(context *alerting.EvalContext) (map[string]*QueryCondition, error) {
+	refs := make(map[string]*QueryCondition)
+
+	for _, refId := range c.Expression.Refs {
+		for _, condition := range context.Rule.Conditions {
+			if queryCondition, ok := condition.(*QueryCondition); ok && queryCondition.Query.RefId == refId {
+				refs[refId] = queryCondition
+				break
+			}
+		}
+
+		ref, exists := refs[refId]
+		if !exists {
+			return nil, fmt.Errorf("Expression references query %s which is not used by any query condition", refId)
+		}
+
+		first := refs[c.Expression.Refs[0]]
+		if ref.Query.From != first.Query.From || ref.Query.To != first.Query.To {
+			return nil, fmt.Errorf("Expression references queries with different time ranges")
+		}
+	}
+
+	return refs, nil
+}
+
+// calculate evaluates the expression over the series of the referenced
+// query conditions, which are only queried when they did not run yet.
+func (c *ExpressionCondition) calculate(context *alerting.EvalContext, refs map[string]*QueryCondition) (tsdb.TimeSeriesSlice, error) {
+	vars := make(map[string]tsdb.TimeSeriesSlice)
+	for refId, ref := range refs {
+		series, err := ref.getSeries(context)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Reducer != nil {
+			vars[refId] = series
+		} else {
+			vars[refId] = reduceSeries(ref.Reducer, series)
+		}
+	}
+
+	return c.Expression.Evaluate(vars)
+}
+
+// reduceSeries replaces each series with a single point holding its reduced
+// value, so series from different data sources line up in the expression.
+func reduceSeries(reducer QueryReducer, seriesList tsdb.TimeSeriesSlice) tsdb.TimeSeriesSlice {
+	result := make(tsdb.TimeSeriesSlice, 0, len(seriesList))
+	for _, series := range seriesList {
+		point := tsdb.NewTimePoint(reducer.Reduce(series), 0)
+		result = append(result, tsdb.NewTimeSeries(series.Name, tsdb.TimeSeriesPoints{point}))
+	}
+	return result
+}
+
+func (c *ExpressionCondition) reduce(series *tsdb.TimeSeries) null.Float {
+	if c.Reducer != nil {
+		return c.Reducer.Reduce(series)
+	}
+
+	if len(series.Points) == 0 {
+		return null.FloatFromPtr(nil)
+	}
+	return series.Points[0][0]
+}
+
+// evaluate treats any non zero value as a match when the condition has no
+// evaluator, which is what comparisons in the expression return. Series
+// evaluators need the condition to have a reducer, otherwise every series
+// of the expression is a single reduced value without a baseline.
+func (c *ExpressionCondition) evaluate(series *tsdb.TimeSeries) (null.Float, bool) {
+	if seriesEvaluator, ok := c.Evaluator.(SeriesEvaluator); ok {
+		return seriesEvaluator.EvalSeries(series)
+	}
+
+	value := c.reduce(series)
+	if c.Evaluator == nil {
+		return value, value.Float64 != 0
+	}
+	return value, c.Evaluator.Eval(value)
+}
+
+func NewExpressionCondition(model *simplejson.Json, index int) (*ExpressionCondition, error) {
+	condition := ExpressionCondition{}
+	condition.Index = index
+
+	expr, err := expression.Parse(model.Get("expression").MustString())
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: "Condition invalid expression: " + err.Error()}
+	}
+
+	if len(expr.Refs) == 0 {
+		return nil, alerting.ValidationError{Reason: "Condition expression must reference at least one query"}
+	}
+	condition.Expression = expr
+
+	if reducerJson, exists := model.CheckGet("reducer"); exists {
+		reducer, err := NewQueryReducer(reducerJson)
+		if err != nil {
+			return nil, err
+		}
+		condition.Reducer = reducer
+	}
+
+	if evaluatorJson, exists := model.CheckGet("evaluator"); exists {
+		evaluator, err := NewAlertEvaluator(evaluatorJson)
+		if err != nil {
+			return nil, err
+		}
+		condition.Evaluator = evaluator
+	}
+
+	operator, err := getOperator(model)
+	if err != nil {
+		return nil, err
+	}
+
+	condition.Operator = operator
+	return &condition, nil
+}
@@ -0,0 +1,188 @@
+package conditions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type expressionTestExecutor struct {
+	series map[string]tsdb.TimeSeriesSlice
+	calls  int
+}
+
+func (e *expressionTestExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, context *tsdb.QueryContext) *tsdb.BatchResult {
+	e.calls++
+	result := &tsdb.BatchResult{QueryResults: make(map[string]*tsdb.QueryResult)}
+	for _, query := range queries {
+		target := query.Model.Get("target").MustString()
+		result.QueryResults[query.RefId] = &tsdb.QueryResult{RefId: query.RefId, Series: e.series[target]}
+	}
+	return result
+}
+
+func newTestQueryCondition(refId string, from string, reducer string, exclude bool) *QueryCondition {
+	return &QueryCondition{
+		Query:         AlertQuery{RefId: refId, DatasourceId: 1, From: from, To: "now", Model: simplejson.NewFromAny(map[string]interface{}{"target": refId})},
+		Reducer:       NewSimpleReducer(reducer),
+		Exclude:       exclude,
+		HandleRequest: tsdb.HandleRequest,
+	}
+}
+
+func newTestExpressionCondition(json string) (*ExpressionCondition, error) {
+	jsonModel, err := simplejson.NewJson([]byte(json))
+	So(err, ShouldBeNil)
+
+	return NewExpressionCondition(jsonModel, 2)
+}
+
+func TestExpressionCondition(t *testing.T) {
+	Convey("When reading expression condition", t, func() {
+		Convey("Can read expression, reducer and evaluator", func() {
+			condition, err := newTestExpressionCondition(`{
+				"type": "expression",
+				"expression": "$A / $B",
+				"reducer": {"type": "avg", "params": []},
+				"evaluator": {"type": "gt", "params": [5]},
+				"operator": {"type": "or"}
+			}`)
+
+			So(err, ShouldBeNil)
+			So(condition.Expression.Refs, ShouldResemble, []string{"A", "B"})
+			So(condition.Reducer, ShouldNotBeNil)
+			So(condition.Evaluator, ShouldNotBeNil)
+			So(condition.Operator, ShouldEqual, "or")
+		})
+
+		Convey("Reducer and evaluator are optional", func() {
+			condition, err := newTestExpressionCondition(`{"type": "expression", "expression": "$A > 5"}`)
+
+			So(err, ShouldBeNil)
+			So(condition.Reducer, ShouldBeNil)
+			So(condition.Evaluator, ShouldBeNil)
+		})
+
+		Convey("Should fail on invalid expression", func() {
+			_, err := newTestExpressionCondition(`{"type": "expression", "expression": "$A /"}`)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should fail on expression without references", func() {
+			_, err := newTestExpressionCondition(`{"type": "expression", "expression": "1 > 0"}`)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When evaluating expression condition", t, func() {
+		executor := &expressionTestExecutor{series: map[string]tsdb.TimeSeriesSlice{
+			"A": {
+				tsdb.NewTimeSeries("server1", tsdb.NewTimeSeriesPointsFromArgs(2, 1, 4, 2)),
+				tsdb.NewTimeSeries("server2", tsdb.NewTimeSeriesPointsFromArgs(10, 1, 20, 2)),
+			},
+			"B": {
+				tsdb.NewTimeSeries("requests", tsdb.NewTimeSeriesPointsFromArgs(100, 1, 200, 2)),
+			},
+		}}
+		tsdb.RegisterExecutor("expression-test", func(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+			return executor
+		})
+
+		bus.AddHandler("test", func(query *m.GetDataSourceByIdQuery) error {
+			query.Result = &m.DataSource{Id: query.Id, Type: "expression-test"}
+			return nil
+		})
+
+		ctx := alerting.NewEvalContext(context.TODO(), &alerting.Rule{
+			Conditions: []alerting.Condition{
+				newTestQueryCondition("A", "5m", "sum", true),
+				newTestQueryCondition("B", "5m", "sum", true),
+			},
+		})
+
+		Convey("Should compare reduced values of referenced conditions", func() {
+			condition, err := newTestExpressionCondition(`{
+				"type": "expression",
+				"expression": "$A / $B * 100",
+				"evaluator": {"type": "gt", "params": [5]}
+			}`)
+			So(err, ShouldBeNil)
+
+			cr, err := condition.Eval(ctx)
+			So(err, ShouldBeNil)
+			So(cr.Firing, ShouldBeTrue)
+			So(len(cr.EvalMatches), ShouldEqual, 1)
+			So(cr.EvalMatches[0].Metric, ShouldEqual, "server2")
+			So(cr.EvalMatches[0].Value, ShouldEqual, 10)
+		})
+
+		Convey("Should use comparison result without evaluator", func() {
+			condition, err := newTestExpressionCondition(`{"type": "expression", "expression": "$A > 50"}`)
+			So(err, ShouldBeNil)
+
+			cr, err := condition.Eval(ctx)
+			So(err, ShouldBeNil)
+			So(cr.Firing, ShouldBeFalse)
+		})
+
+		Convey("Should calculate series point by point and reduce the result", func() {
+			condition, err := newTestExpressionCondition(`{
+				"type": "expression",
+				"expression": "$A / $B * 100",
+				"reducer": {"type": "max", "params": []},
+				"evaluator": {"type": "lt", "params": [5]}
+			}`)
+			So(err, ShouldBeNil)
+
+			cr, err := condition.Eval(ctx)
+			So(err, ShouldBeNil)
+			So(cr.Firing, ShouldBeTrue)
+			So(len(cr.EvalMatches), ShouldEqual, 1)
+			So(cr.EvalMatches[0].Metric, ShouldEqual, "server1")
+			So(cr.EvalMatches[0].Value, ShouldEqual, 2)
+		})
+
+		Convey("Should fail when referenced query has no condition", func() {
+			condition, _ := newTestExpressionCondition(`{"type": "expression", "expression": "$A / $C"}`)
+
+			_, err := condition.Eval(ctx)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should fail when referenced conditions have different time ranges", func() {
+			ctx.Rule.Conditions = append(ctx.Rule.Conditions, newTestQueryCondition("C", "1h", "sum", false))
+			condition, _ := newTestExpressionCondition(`{"type": "expression", "expression": "$A / $C"}`)
+
+			_, err := condition.Eval(ctx)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should reuse the series of query conditions evaluated before", func() {
+			queryCondition := newTestQueryCondition("A", "5m", "sum", false)
+			queryCondition.Evaluator = &ThresholdEvaluator{Type: "gt", Threshold: 5}
+			ctx.Rule.Conditions[0] = queryCondition
+			condition, _ := newTestExpressionCondition(`{"type": "expression", "expression": "$A > 5"}`)
+
+			_, err := ctx.Rule.Conditions[0].Eval(ctx)
+			So(err, ShouldBeNil)
+			So(executor.calls, ShouldEqual, 1)
+
+			cr, err := condition.Eval(ctx)
+			So(err, ShouldBeNil)
+			So(cr.Firing, ShouldBeTrue)
+			So(executor.calls, ShouldEqual, 1)
+		})
+
+		Convey("Excluded query condition should not be evaluated", func() {
+			cr, err := ctx.Rule.Conditions[0].Eval(ctx)
+			So(err, ShouldBeNil)
+			So(cr.Excluded, ShouldBeTrue)
+		})
+	})
+}
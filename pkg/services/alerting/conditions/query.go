@@ -10,6 +10,7 @@ import (
 	m "github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/tsdb"
+	"gopkg.in/guregu/null.v3"
 )
 
 func init() {
@@ -21,22 +22,29 @@ func init() {
 type QueryCondition struct {
 	Index         int
 	Query         AlertQuery
+	Operator      string
 	Reducer       QueryReducer
+	Column        string
 	Evaluator     AlertEvaluator
+	Exclude       bool
 	HandleRequest tsdb.HandleRequestFunc
 }
 
 type AlertQuery struct {
 	Model        *simplejson.Json
 	DatasourceId int64
+	RefId        string
 	From         string
 	To           string
 }
 
 func (c *QueryCondition) Eval(context *alerting.EvalContext) (*alerting.ConditionResult, error) {
-	timeRange := tsdb.NewTimeRange(c.Query.From, c.Query.To)
+	// excluded queries are only used as input to expression conditions
+	if c.Exclude {
+		return &alerting.ConditionResult{Excluded: true}, nil
+	}
 
-	seriesList, err := c.executeQuery(context, timeRange)
+	seriesList, err := c.getSeries(context)
 	if err != nil {
 		return nil, err
 	}
@@ -45,8 +53,7 @@ func (c *QueryCondition) Eval(context *alerting.EvalContext) (*alerting.Conditio
 	evalMatchCount := 0
 	var matches []*alerting.EvalMatch
 	for _, series := range seriesList {
-		reducedValue := c.Reducer.Reduce(series)
-		evalMatch := c.Evaluator.Eval(reducedValue)
+		reducedValue, evalMatch := evaluateSeries(c.Reducer, c.Evaluator, series)
 
 		if reducedValue.Valid == false {
 			emptySerieCount++
@@ -72,24 +79,67 @@ func (c *QueryCondition) Eval(context *alerting.EvalContext) (*alerting.Conditio
 	return &alerting.ConditionResult{
 		Firing:      evalMatchCount > 0,
 		NoDataFound: emptySerieCount == len(seriesList),
+		Operator:    c.Operator,
 		EvalMatches: matches,
 	}, nil
 }
 
-func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *tsdb.TimeRange) (tsdb.TimeSeriesSlice, error) {
-	getDsInfo := &m.GetDataSourceByIdQuery{
-		Id:    c.Query.DatasourceId,
-		OrgId: context.Rule.OrgId,
+// evaluateSeries returns the value the evaluator compared and whether it
+// matched. Series evaluators look at the whole series, so the reducer is
+// not used for them.
+func evaluateSeries(reducer QueryReducer, evaluator AlertEvaluator, series *tsdb.TimeSeries) (null.Float, bool) {
+	if seriesEvaluator, ok := evaluator.(SeriesEvaluator); ok {
+		return seriesEvaluator.EvalSeries(series)
 	}
 
-	if err := bus.Dispatch(getDsInfo); err != nil {
-		return nil, fmt.Errorf("Could not find datasource")
+	reducedValue := reducer.Reduce(series)
+	return reducedValue, evaluator.Eval(reducedValue)
+}
+
+// newTimeRange returns the query time range relative to the time of the
+// evaluation, which is in the past when backtesting a rule.
+func newTimeRange(context *alerting.EvalContext, from, to string) *tsdb.TimeRange {
+	timeRange := tsdb.NewTimeRange(from, to)
+	if !context.StartTime.IsZero() {
+		timeRange.Now = context.StartTime
 	}
 
-	req := c.getRequestForAlertRule(getDsInfo.Result, timeRange)
+	return timeRange
+}
+
+// getSeries returns the series of the query, which runs once per evaluation
+// also when other conditions use the query with the same time range.
+func (c *QueryCondition) getSeries(context *alerting.EvalContext) (tsdb.TimeSeriesSlice, error) {
+	key := fmt.Sprintf("%s:%s:%s", c.Query.RefId, c.Query.From, c.Query.To)
+	if series, ok := context.GetQuerySeries(key); ok {
+		return series, nil
+	}
+
+	series, err := c.executeQuery(context, newTimeRange(context, c.Query.From, c.Query.To))
+	if err != nil {
+		return nil, err
+	}
+
+	context.SetQuerySeries(key, series)
+	return series, nil
+}
+
+func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *tsdb.TimeRange) (tsdb.TimeSeriesSlice, error) {
+	datasource, err := getDataSource(context, c.Query.DatasourceId)
+	if err != nil {
+		return nil, err
+	}
+
+	req := c.getRequestForAlertRule(datasource, timeRange)
 	result := make(tsdb.TimeSeriesSlice, 0)
 
+	release, err := context.AcquireDataSource(datasource.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := c.HandleRequest(context.Ctx, req)
+	release()
 	if err != nil {
 		return nil, fmt.Errorf("tsdb.HandleRequest() error %v", err)
 	}
@@ -99,12 +149,21 @@ func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *
 			return nil, fmt.Errorf("tsdb.HandleRequest() response error %v", v)
 		}
 
-		result = append(result, v.Series...)
+		series := v.Series
+		for _, table := range v.Tables {
+			tableSeries, err := tableToSeries(table, c.Column)
+			if err != nil {
+				return nil, err
+			}
+			series = append(series, tableSeries...)
+		}
+
+		result = append(result, series...)
 
 		if context.IsTestRun {
 			context.Logs = append(context.Logs, &alerting.ResultLogEntry{
 				Message: fmt.Sprintf("Condition[%d]: Query Result", c.Index),
-				Data:    v.Series,
+				Data:    series,
 			})
 		}
 	}
@@ -116,29 +175,34 @@ func (c *QueryCondition) getRequestForAlertRule(datasource *m.DataSource, timeRa
 	req := &tsdb.Request{
 		TimeRange: timeRange,
 		Queries: []*tsdb.Query{
-			{
-				RefId: "A",
-				Model: c.Query.Model,
-				DataSource: &tsdb.DataSourceInfo{
-					Id:                datasource.Id,
-					Name:              datasource.Name,
-					PluginId:          datasource.Type,
-					Url:               datasource.Url,
-					User:              datasource.User,
-					Password:          datasource.Password,
-					Database:          datasource.Database,
-					BasicAuth:         datasource.BasicAuth,
-					BasicAuthUser:     datasource.BasicAuthUser,
-					BasicAuthPassword: datasource.BasicAuthPassword,
-					JsonData:          datasource.JsonData,
-				},
-			},
+			newTsdbQuery("A", c.Query.Model, datasource),
 		},
 	}
 
 	return req
 }
 
+func getDataSource(context *alerting.EvalContext, datasourceId int64) (*m.DataSource, error) {
+	getDsInfo := &m.GetDataSourceByIdQuery{
+		Id:    datasourceId,
+		OrgId: context.Rule.OrgId,
+	}
+
+	if err := bus.Dispatch(getDsInfo); err != nil {
+		return nil, fmt.Errorf("Could not find datasource")
+	}
+
+	return getDsInfo.Result, nil
+}
+
+func newTsdbQuery(refId string, model *simplejson.Json, datasource *m.DataSource) *tsdb.Query {
+	return &tsdb.Query{
+		RefId:      refId,
+		Model:      model,
+		DataSource: tsdb.NewDataSourceInfo(datasource),
+	}
+}
+
 func NewQueryCondition(model *simplejson.Json, index int) (*QueryCondition, error) {
 	condition := QueryCondition{}
 	condition.Index = index
@@ -147,6 +211,7 @@ func NewQueryCondition(model *simplejson.Json, index int) (*QueryCondition, erro
 	queryJson := model.Get("query")
 
 	condition.Query.Model = queryJson.Get("model")
+	condition.Query.RefId, _ = queryJson.Get("params").MustArray()[0].(string)
 	condition.Query.From = queryJson.Get("params").MustArray()[1].(string)
 	condition.Query.To = queryJson.Get("params").MustArray()[2].(string)
 
@@ -160,8 +225,12 @@ func NewQueryCondition(model *simplejson.Json, index int) (*QueryCondition, erro
 
 	condition.Query.DatasourceId = queryJson.Get("datasourceId").MustInt64()
 
-	reducerJson := model.Get("reducer")
-	condition.Reducer = NewSimpleReducer(reducerJson.Get("type").MustString())
+	reducer, err := NewQueryReducer(model.Get("reducer"))
+	if err != nil {
+		return nil, err
+	}
+	condition.Reducer = reducer
+	condition.Column = model.Get("reducer").Get("column").MustString()
 
 	evaluatorJson := model.Get("evaluator")
 	evaluator, err := NewAlertEvaluator(evaluatorJson)
@@ -170,9 +239,25 @@ func NewQueryCondition(model *simplejson.Json, index int) (*QueryCondition, erro
 	}
 
 	condition.Evaluator = evaluator
+
+	operator, err := getOperator(model)
+	if err != nil {
+		return nil, err
+	}
+
+	condition.Operator = operator
+	condition.Exclude = model.Get("exclude").MustBool()
 	return &condition, nil
 }
 
+func getOperator(model *simplejson.Json) (string, error) {
+	operator := model.Get("operator").Get("type").MustString("and")
+	if operator != "and" && operator != "or" {
+		return "", alerting.ValidationError{Reason: "Condition invalid operator type: " + operator}
+	}
+	return operator, nil
+}
+
 func validateFromValue(from string) error {
 	fromRaw := strings.Replace(from, "now-", "", 1)
 
@@ -41,6 +41,10 @@ func TestQueryCondition(t *testing.T) {
 					So(ok, ShouldBeTrue)
 					So(evaluator.Type, ShouldEqual, "gt")
 				})
+
+				Convey("Defaults operator to and", func() {
+					So(ctx.condition.Operator, ShouldEqual, "and")
+				})
 			})
 
 			Convey("should fire when avg is above 100", func() {
@@ -107,6 +111,41 @@ func TestQueryCondition(t *testing.T) {
 				})
 			})
 		})
+
+		queryConditionScenario("Given avg() of a table column and > 100", func(ctx *queryConditionTestContext) {
+			ctx.reducer = `{"type": "avg", "column": "latency"}`
+			ctx.evaluator = `{"type": "gt", "params": [100]}`
+
+			table := tsdb.NewTable(
+				tsdb.TableColumn{Text: "time", Type: tsdb.ColumnTypeTime},
+				tsdb.TableColumn{Text: "host", Type: tsdb.ColumnTypeString},
+				tsdb.TableColumn{Text: "latency", Type: tsdb.ColumnTypeNumber},
+				tsdb.TableColumn{Text: "requests", Type: tsdb.ColumnTypeNumber},
+			)
+			table.Rows = append(table.Rows,
+				tsdb.RowValues{float64(1000), "server1", float64(150), float64(1)},
+				tsdb.RowValues{float64(1000), "server2", float64(50), float64(500)},
+			)
+
+			Convey("Should fire for the series of the column", func() {
+				ctx.tables = []*tsdb.Table{table}
+				cr, err := ctx.exec()
+
+				So(err, ShouldBeNil)
+				So(ctx.condition.Column, ShouldEqual, "latency")
+				So(cr.Firing, ShouldBeTrue)
+				So(len(cr.EvalMatches), ShouldEqual, 1)
+				So(cr.EvalMatches[0].Metric, ShouldEqual, "server1")
+			})
+
+			Convey("Should return error for unknown column", func() {
+				ctx.reducer = `{"type": "avg", "column": "errors"}`
+				ctx.tables = []*tsdb.Table{table}
+				_, err := ctx.exec()
+
+				So(err, ShouldNotBeNil)
+			})
+		})
 	})
 }
 
@@ -114,6 +153,7 @@ type queryConditionTestContext struct {
 	reducer   string
 	evaluator string
 	series    tsdb.TimeSeriesSlice
+	tables    []*tsdb.Table
 	result    *alerting.EvalContext
 	condition *QueryCondition
 }
@@ -141,7 +181,7 @@ func (ctx *queryConditionTestContext) exec() (*alerting.ConditionResult, error)
 	condition.HandleRequest = func(context context.Context, req *tsdb.Request) (*tsdb.Response, error) {
 		return &tsdb.Response{
 			Results: map[string]*tsdb.QueryResult{
-				"A": {Series: ctx.series},
+				"A": {Series: ctx.series, Tables: ctx.tables},
 			},
 		}, nil
 	}
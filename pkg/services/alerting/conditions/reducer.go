@@ -1,18 +1,28 @@
 package conditions
 
 import (
+	"encoding/json"
 	"math"
+	"sort"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"gopkg.in/guregu/null.v3"
 )
 
+var (
+	simpleReducerTypes  []string = []string{"avg", "sum", "min", "max", "count", "last", "median", "diff", "percent_diff", "stddev", "count_non_null"}
+	percentileShortcuts          = map[string]float64{"p90": 90, "p95": 95, "p99": 99}
+)
+
 type QueryReducer interface {
 	Reduce(timeSeries *tsdb.TimeSeries) null.Float
 }
 
 type SimpleReducer struct {
-	Type string
+	Type       string
+	Percentile float64
 }
 
 func (s *SimpleReducer) Reduce(series *tsdb.TimeSeries) null.Float {
@@ -25,13 +35,17 @@ func (s *SimpleReducer) Reduce(series *tsdb.TimeSeries) null.Float {
 
 	switch s.Type {
 	case "avg":
+		validPointsCount := 0
 		for _, point := range series.Points {
 			if point[0].Valid {
 				value += point[0].Float64
+				validPointsCount++
 				allNull = false
 			}
 		}
-		value = value / float64(len(series.Points))
+		if validPointsCount > 0 {
+			value = value / float64(validPointsCount)
+		}
 	case "sum":
 		for _, point := range series.Points {
 			if point[0].Valid {
@@ -62,6 +76,13 @@ func (s *SimpleReducer) Reduce(series *tsdb.TimeSeries) null.Float {
 	case "count":
 		value = float64(len(series.Points))
 		allNull = false
+	case "count_non_null":
+		for _, point := range series.Points {
+			if point[0].Valid {
+				value++
+				allNull = false
+			}
+		}
 	case "last":
 		points := series.Points
 		for i := len(points) - 1; i >= 0; i-- {
@@ -71,6 +92,37 @@ func (s *SimpleReducer) Reduce(series *tsdb.TimeSeries) null.Float {
 				break
 			}
 		}
+	case "median":
+		values := validValues(series)
+		if len(values) > 0 {
+			value = percentile(values, 50)
+			allNull = false
+		}
+	case "percentile":
+		values := validValues(series)
+		if len(values) > 0 {
+			value = percentile(values, s.Percentile)
+			allNull = false
+		}
+	case "diff":
+		values := validValues(series)
+		if len(values) > 0 {
+			value = values[len(values)-1] - values[0]
+			allNull = false
+		}
+	case "percent_diff":
+		values := validValues(series)
+		// a diff relative to zero is undefined, treat it as no value
+		if len(values) > 0 && values[0] != 0 {
+			value = (values[len(values)-1] - values[0]) / math.Abs(values[0]) * 100
+			allNull = false
+		}
+	case "stddev":
+		values := validValues(series)
+		if len(values) > 0 {
+			value = stddev(values)
+			allNull = false
+		}
 	}
 
 	if allNull {
@@ -80,6 +132,81 @@ func (s *SimpleReducer) Reduce(series *tsdb.TimeSeries) null.Float {
 	return null.FloatFrom(value)
 }
 
+// validValues returns the non null values of the series in time order.
+func validValues(series *tsdb.TimeSeries) []float64 {
+	values := make([]float64, 0, len(series.Points))
+	for _, point := range series.Points {
+		if point[0].Valid {
+			values = append(values, point[0].Float64)
+		}
+	}
+	return values
+}
+
+// percentile uses linear interpolation between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func stddev(values []float64) float64 {
+	mean := float64(0)
+	for _, v := range values {
+		mean += v
+	}
+	mean = mean / float64(len(values))
+
+	variance := float64(0)
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+
+	return math.Sqrt(variance / float64(len(values)))
+}
+
 func NewSimpleReducer(typ string) *SimpleReducer {
 	return &SimpleReducer{Type: typ}
 }
+
+func NewQueryReducer(model *simplejson.Json) (QueryReducer, error) {
+	typ := model.Get("type").MustString()
+	if typ == "" {
+		return nil, alerting.ValidationError{Reason: "Reducer missing type property"}
+	}
+
+	if inSlice(typ, simpleReducerTypes) {
+		return NewSimpleReducer(typ), nil
+	}
+
+	if p, ok := percentileShortcuts[typ]; ok {
+		return &SimpleReducer{Type: "percentile", Percentile: p}, nil
+	}
+
+	if typ == "percentile" {
+		params := model.Get("params").MustArray()
+		if len(params) == 0 {
+			return nil, alerting.ValidationError{Reason: "Reducer missing percentile parameter"}
+		}
+
+		param, ok := params[0].(json.Number)
+		if !ok {
+			return nil, alerting.ValidationError{Reason: "Reducer has invalid parameter"}
+		}
+
+		p, err := param.Float64()
+		if err != nil || p < 0 || p > 100 {
+			return nil, alerting.ValidationError{Reason: "Reducer percentile must be between 0 and 100"}
+		}
+
+		return &SimpleReducer{Type: "percentile", Percentile: p}, nil
+	}
+
+	return nil, alerting.ValidationError{Reason: "Reducer invalid reducer type: " + typ}
+}
@@ -5,6 +5,7 @@ import (
 
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -41,11 +42,118 @@ func TestSimpleReducer(t *testing.T) {
 			So(result, ShouldEqual, float64(3000))
 		})
 
+		Convey("extended reducers", func() {
+			tests := []struct {
+				typ        string
+				datapoints []float64
+				expected   float64
+			}{
+				{"median", []float64{5, 1, 3}, 3},
+				{"median", []float64{4, 1, 3, 2}, 2.5},
+				{"diff", []float64{30, 40, 10}, -20},
+				{"percent_diff", []float64{50, 10, 75}, 50},
+				{"percent_diff", []float64{-50, 10, -25}, 50},
+				{"stddev", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 2},
+				{"count_non_null", []float64{1, 2, 3}, 3},
+				{"p90", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 10},
+				{"p99", []float64{10, 20}, 19.9},
+			}
+
+			for _, test := range tests {
+				So(testReducer(test.typ, test.datapoints...), ShouldAlmostEqual, test.expected)
+			}
+		})
+
+		Convey("configurable percentile", func() {
+			reducer := &SimpleReducer{Type: "percentile", Percentile: 25}
+			series := testSeries(null.FloatFrom(1), null.FloatFrom(2), null.FloatFrom(3), null.FloatFrom(4), null.FloatFrom(5))
+
+			So(reducer.Reduce(series).Float64, ShouldEqual, float64(2))
+		})
 	})
+
+	Convey("Test simple reducer with null values", t, func() {
+		series := testSeries(null.FloatFrom(2), null.FloatFromPtr(nil), null.FloatFrom(4), null.FloatFromPtr(nil), null.FloatFrom(10))
+
+		tests := []struct {
+			typ      string
+			expected float64
+		}{
+			{"avg", 16.0 / 3},
+			{"median", 4},
+			{"diff", 8},
+			{"percent_diff", 400},
+			{"count", 5},
+			{"count_non_null", 3},
+		}
+
+		for _, test := range tests {
+			result := NewSimpleReducer(test.typ).Reduce(series)
+			So(result.Valid, ShouldBeTrue)
+			So(result.Float64, ShouldAlmostEqual, test.expected)
+		}
+
+		Convey("all null series reduce to null", func() {
+			series := testSeries(null.FloatFromPtr(nil), null.FloatFromPtr(nil))
+
+			for _, typ := range []string{"avg", "median", "diff", "percent_diff", "stddev", "count_non_null"} {
+				So(NewSimpleReducer(typ).Reduce(series).Valid, ShouldBeFalse)
+			}
+		})
+
+		Convey("percent_diff from zero is null", func() {
+			series := testSeries(null.FloatFrom(0), null.FloatFrom(10))
+			So(NewSimpleReducer("percent_diff").Reduce(series).Valid, ShouldBeFalse)
+		})
+	})
+
+	Convey("Test reducer validation", t, func() {
+		tests := []struct {
+			json  string
+			valid bool
+		}{
+			{`{"type": "avg", "params": []}`, true},
+			{`{"type": "stddev", "params": []}`, true},
+			{`{"type": "p95", "params": []}`, true},
+			{`{"type": "percentile", "params": [75]}`, true},
+			{`{"type": "percentile", "params": []}`, false},
+			{`{"type": "percentile", "params": [120]}`, false},
+			{`{"type": "percentile", "params": ["high"]}`, false},
+			{`{"type": "unknown", "params": []}`, false},
+			{`{"params": []}`, false},
+		}
+
+		for _, test := range tests {
+			jsonModel, err := simplejson.NewJson([]byte(test.json))
+			So(err, ShouldBeNil)
+
+			_, err = NewQueryReducer(jsonModel)
+			So(err == nil, ShouldEqual, test.valid)
+		}
+
+		Convey("percentile parameter is used", func() {
+			jsonModel, _ := simplejson.NewJson([]byte(`{"type": "percentile", "params": [75]}`))
+			reducer, _ := NewQueryReducer(jsonModel)
+
+			So(reducer.(*SimpleReducer).Percentile, ShouldEqual, float64(75))
+		})
+	})
+}
+
+func testSeries(points ...null.Float) *tsdb.TimeSeries {
+	series := &tsdb.TimeSeries{
+		Name: "test time serie",
+	}
+
+	for idx := range points {
+		series.Points = append(series.Points, tsdb.NewTimePoint(points[idx], 1234134))
+	}
+
+	return series
 }
 
 func testReducer(typ string, datapoints ...float64) float64 {
-	reducer := NewSimpleReducer(typ)
+	reducer, _ := NewQueryReducer(simplejson.NewFromAny(map[string]interface{}{"type": typ}))
 	series := &tsdb.TimeSeries{
 		Name: "test time serie",
 	}
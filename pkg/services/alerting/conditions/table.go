@@ -0,0 +1,124 @@
+package conditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+	"gopkg.in/guregu/null.v3"
+)
+
+// tableToSeries turns a table result into series the reducers can work on.
+// Values come from the named column, or from every number column when no
+// column is set. Rows are grouped by their string columns, which also name
+// the series.
+func tableToSeries(table *tsdb.Table, column string) (tsdb.TimeSeriesSlice, error) {
+	timeIndex := -1
+	valueIndexes := make([]int, 0)
+	groupIndexes := make([]int, 0)
+
+	for i, c := range table.Columns {
+		switch c.Type {
+		case tsdb.ColumnTypeTime:
+			if timeIndex == -1 {
+				timeIndex = i
+			}
+		case tsdb.ColumnTypeNumber:
+			if column == "" {
+				valueIndexes = append(valueIndexes, i)
+			}
+		default:
+			if c.Text != column {
+				groupIndexes = append(groupIndexes, i)
+			}
+		}
+	}
+
+	if column != "" {
+		index := table.ColumnIndex(column)
+		if index == -1 {
+			return nil, fmt.Errorf("Could not find column %s in query result", column)
+		}
+
+		columnType := table.Columns[index].Type
+		if columnType != tsdb.ColumnTypeNumber && columnType != "" {
+			return nil, fmt.Errorf("Column %s is not a number column", column)
+		}
+
+		valueIndexes = append(valueIndexes, index)
+	}
+
+	seriesByName := make(map[string]*tsdb.TimeSeries)
+	result := make(tsdb.TimeSeriesSlice, 0)
+
+	for _, row := range table.Rows {
+		groupValues := make([]string, 0, len(groupIndexes))
+		for _, index := range groupIndexes {
+			if row[index] != nil {
+				groupValues = append(groupValues, fmt.Sprintf("%v", row[index]))
+			}
+		}
+		group := strings.Join(groupValues, " ")
+
+		timestamp := float64(0)
+		if timeIndex != -1 {
+			if t, err := toTableFloat(row[timeIndex]); err == nil && t.Valid {
+				timestamp = t.Float64
+			}
+		}
+
+		for _, index := range valueIndexes {
+			value, err := toTableFloat(row[index])
+			if err != nil {
+				return nil, fmt.Errorf("Column %s: %v", table.Columns[index].Text, err)
+			}
+
+			name := table.Columns[index].Text
+			if group != "" && len(valueIndexes) == 1 {
+				name = group
+			} else if group != "" {
+				name = group + " " + name
+			}
+
+			series, ok := seriesByName[name]
+			if !ok {
+				series = tsdb.NewTimeSeries(name, make(tsdb.TimeSeriesPoints, 0))
+				seriesByName[name] = series
+				result = append(result, series)
+			}
+
+			series.Points = append(series.Points, tsdb.NewTimePoint(value, timestamp))
+		}
+	}
+
+	return result, nil
+}
+
+func toTableFloat(value interface{}) (null.Float, error) {
+	switch v := value.(type) {
+	case nil:
+		return null.FloatFromPtr(nil), nil
+	case float64:
+		return null.FloatFrom(v), nil
+	case int64:
+		return null.FloatFrom(float64(v)), nil
+	case int:
+		return null.FloatFrom(float64(v)), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return null.Float{}, err
+		}
+		return null.FloatFrom(f), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return null.Float{}, fmt.Errorf("Value %s is not a number", v)
+		}
+		return null.FloatFrom(f), nil
+	default:
+		return null.Float{}, fmt.Errorf("Value %v is not a number", v)
+	}
+}
@@ -0,0 +1,57 @@
+package conditions
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTableToSeries(t *testing.T) {
+	Convey("Table results", t, func() {
+		table := tsdb.NewTable(
+			tsdb.TableColumn{Text: "Time", Type: tsdb.ColumnTypeTime},
+			tsdb.TableColumn{Text: "host", Type: tsdb.ColumnTypeString},
+			tsdb.TableColumn{Text: "min", Type: tsdb.ColumnTypeNumber},
+			tsdb.TableColumn{Text: "max", Type: tsdb.ColumnTypeNumber},
+		)
+		table.Rows = append(table.Rows,
+			tsdb.RowValues{float64(1000), "server1", float64(1), float64(5)},
+			tsdb.RowValues{float64(2000), "server1", nil, float64(7)},
+			tsdb.RowValues{float64(1000), "server2", float64(3), float64(4)},
+		)
+
+		Convey("can convert a column to series grouped by string columns", func() {
+			series, err := tableToSeries(table, "max")
+			So(err, ShouldBeNil)
+			So(len(series), ShouldEqual, 2)
+			So(series[0].Name, ShouldEqual, "server1")
+			So(series[0].Points, ShouldResemble, tsdb.NewTimeSeriesPointsFromArgs(5, 1000, 7, 2000))
+			So(series[1].Name, ShouldEqual, "server2")
+		})
+
+		Convey("uses every number column when no column is set", func() {
+			series, err := tableToSeries(table, "")
+			So(err, ShouldBeNil)
+			So(len(series), ShouldEqual, 4)
+			So(series[0].Name, ShouldEqual, "server1 min")
+			So(series[0].Points[1][0].Valid, ShouldBeFalse)
+			So(series[1].Name, ShouldEqual, "server1 max")
+		})
+
+		Convey("returns error for columns that are not numbers", func() {
+			_, err := tableToSeries(table, "host")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("can convert untyped columns", func() {
+			untyped := tsdb.NewTable(tsdb.TableColumn{Text: "key"}, tsdb.TableColumn{Text: "value"})
+			untyped.Rows = append(untyped.Rows, tsdb.RowValues{"a", "12.5"})
+
+			series, err := tableToSeries(untyped, "value")
+			So(err, ShouldBeNil)
+			So(series[0].Name, ShouldEqual, "a")
+			So(series[0].Points[0][0].Float64, ShouldEqual, 12.5)
+		})
+	})
+}
@@ -0,0 +1,368 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	deliveryRetryInterval  time.Duration = time.Second * 10
+	deliveryRetryBaseDelay time.Duration = time.Minute * 1
+	deliveryRetryMaxDelay  time.Duration = time.Hour * 1
+	deliveryMaxAttempts    int           = 6
+	deliveryBatchSize      int           = 100
+	deliveryClaimDuration  time.Duration = time.Minute * 1
+)
+
+// deliverySnapshot is the part of an evaluation that notifiers use,
+// stored with each delivery so failed notifications can be sent again.
+type deliverySnapshot struct {
+	RuleId            int64
+	OrgId             int64
+	DashboardId       int64
+	PanelId           int64
+	Name              string
+	Message           string
+	Severity          m.AlertSeverityType
+	Labels            map[string]string
+	State             m.AlertStateType
+	PrevState         m.AlertStateType
+	Error             string
+	EvalMatches       []*EvalMatch
+	ImagePublicUrl    string
+	ImageOnDiskPath   string
+	StartTime         time.Time
+	NewInstances      []*m.AlertInstance
+	ResolvedInstances []*m.AlertInstance
+}
+
+func newDeliverySnapshot(c *EvalContext) *deliverySnapshot {
+	snapshot := &deliverySnapshot{
+		RuleId:            c.Rule.Id,
+		OrgId:             c.Rule.OrgId,
+		DashboardId:       c.Rule.DashboardId,
+		PanelId:           c.Rule.PanelId,
+		Name:              c.Rule.Name,
+		Message:           c.Rule.Message,
+		Severity:          c.Rule.Severity,
+		Labels:            c.Rule.Labels,
+		State:             c.Rule.State,
+		PrevState:         c.PrevAlertState,
+		EvalMatches:       c.EvalMatches,
+		ImagePublicUrl:    c.ImagePublicUrl,
+		ImageOnDiskPath:   c.ImageOnDiskPath,
+		StartTime:         c.StartTime,
+		NewInstances:      c.NewInstances,
+		ResolvedInstances: c.ResolvedInstances,
+	}
+
+	if c.Error != nil {
+		snapshot.Error = c.Error.Error()
+	}
+
+	return snapshot
+}
+
+func (s *deliverySnapshot) toJson() (*simplejson.Json, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return simplejson.NewJson(data)
+}
+
+func deliverySnapshotFromJson(payload *simplejson.Json) (*deliverySnapshot, error) {
+	data, err := payload.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &deliverySnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// newEvalContext restores the evaluation the snapshot was taken from.
+func (s *deliverySnapshot) newEvalContext(ctx context.Context) *EvalContext {
+	rule := &Rule{
+		Id:          s.RuleId,
+		OrgId:       s.OrgId,
+		DashboardId: s.DashboardId,
+		PanelId:     s.PanelId,
+		Name:        s.Name,
+		Message:     s.Message,
+		Severity:    s.Severity,
+		Labels:      s.Labels,
+		State:       s.State,
+	}
+
+	evalContext := NewEvalContext(ctx, rule)
+	evalContext.PrevAlertState = s.PrevState
+	evalContext.Firing = s.State == m.AlertStateAlerting
+	evalContext.EvalMatches = s.EvalMatches
+	evalContext.ImagePublicUrl = s.ImagePublicUrl
+	evalContext.ImageOnDiskPath = s.ImageOnDiskPath
+	evalContext.StartTime = s.StartTime
+	evalContext.NewInstances = s.NewInstances
+	evalContext.ResolvedInstances = s.ResolvedInstances
+
+	if s.Error != "" {
+		evalContext.Error = errors.New(s.Error)
+	}
+
+	return evalContext
+}
+
+// deliveryBackoff returns how long to wait after the given number of
+// failed attempts, doubling the delay up to deliveryRetryMaxDelay.
+func deliveryBackoff(attempts int) time.Duration {
+	delay := deliveryRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= deliveryRetryMaxDelay {
+			return deliveryRetryMaxDelay
+		}
+	}
+
+	return delay
+}
+
+// nextDeliveryState returns the update to store after an attempt to send
+// a delivery has finished.
+func nextDeliveryState(delivery *m.AlertNotificationDelivery, sendErr error, now time.Time) *m.UpdateAlertNotificationDeliveryCommand {
+	cmd := &m.UpdateAlertNotificationDeliveryCommand{
+		Id:          delivery.Id,
+		Attempts:    delivery.Attempts + 1,
+		NextAttempt: now,
+	}
+
+	switch {
+	case sendErr == nil:
+		cmd.Status = m.AlertNotificationDeliverySent
+	case cmd.Attempts >= deliveryMaxAttempts:
+		cmd.Status = m.AlertNotificationDeliveryFailed
+		cmd.LastError = sendErr.Error()
+	default:
+		cmd.Status = m.AlertNotificationDeliveryPending
+		cmd.LastError = sendErr.Error()
+		cmd.NextAttempt = now.Add(deliveryBackoff(cmd.Attempts))
+	}
+
+	return cmd
+}
+
+// createDelivery adds the notification to the outbox before it is sent. The
+// first retry is scheduled already so a notification is not lost if the
+// server stops while sending it.
+func (n *RootNotifier) createDelivery(context *EvalContext, notifier Notifier) *m.AlertNotificationDelivery {
+	payload, err := newDeliverySnapshot(context).toJson()
+	if err != nil {
+		n.log.Error("Failed to create notification delivery payload", "error", err)
+		return nil
+	}
+
+	cmd := &m.CreateAlertNotificationDeliveryCommand{
+		OrgId:       context.Rule.OrgId,
+		AlertId:     context.Rule.Id,
+		NotifierId:  notifier.GetNotifierId(),
+		Payload:     payload,
+		NextAttempt: time.Now().Add(deliveryBackoff(1)),
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		n.log.Error("Failed to save notification delivery", "notifierId", cmd.NotifierId, "error", err)
+		return nil
+	}
+
+	return cmd.Result
+}
+
+// deliver sends the notification and records the outcome on the delivery.
+func (n *RootNotifier) deliver(context *EvalContext, notifier Notifier, delivery *m.AlertNotificationDelivery) error {
+	err := notifier.Notify(context)
+	if err == nil {
+		n.setNotificationSent(context, notifier)
+	} else {
+		n.log.Error("Failed to send notification", "type", notifier.GetType(), "id", notifier.GetNotifierId(), "error", err)
+	}
+
+	if delivery == nil {
+		status := m.AlertNotificationDeliverySent
+		if err != nil {
+			status = m.AlertNotificationDeliveryFailed
+		}
+
+		n.saveHistory(context, notifier.GetNotifierId(), status, err)
+		return err
+	}
+
+	cmd := nextDeliveryState(delivery, err, time.Now())
+	if cmd.Status == m.AlertNotificationDeliveryFailed {
+		metrics.M_Alerting_Notification_Delivery_Failed.Inc(1)
+		n.log.Error("Giving up on notification", "alertId", delivery.AlertId, "notifierId", delivery.NotifierId, "attempts", cmd.Attempts)
+	}
+
+	if updateErr := bus.Dispatch(cmd); updateErr == m.ErrAlertNotificationDeliveryNotFound {
+		n.log.Debug("Notification delivery was superseded", "id", delivery.Id)
+	} else if updateErr != nil {
+		n.log.Error("Failed to update notification delivery", "id", delivery.Id, "error", updateErr)
+	}
+
+	n.saveHistory(context, delivery.NotifierId, cmd.Status, err)
+	return err
+}
+
+// saveHistory adds the outcome of a notification to the alert history. A
+// pending status means another attempt is scheduled.
+func (n *RootNotifier) saveHistory(context *EvalContext, notifierId int64, status m.AlertNotificationDeliveryStatus, sendErr error) {
+	cmd := newNotificationHistoryEntry(context, notifierId, status, sendErr)
+	if err := bus.Dispatch(cmd); err != nil {
+		n.log.Error("Failed to save alert history", "alertId", context.Rule.Id, "error", err)
+	}
+}
+
+// DeliveryRetrier sends notifications from the outbox again after they
+// failed, for the alerts this server is responsible for.
+type DeliveryRetrier struct {
+	notifier   *RootNotifier
+	isAssigned func(alertId int64) bool
+	log        log.Logger
+}
+
+func NewDeliveryRetrier(isAssigned func(alertId int64) bool) *DeliveryRetrier {
+	return &DeliveryRetrier{
+		notifier:   NewRootNotifier(),
+		isAssigned: isAssigned,
+		log:        log.New("alerting.deliveryRetrier"),
+	}
+}
+
+func (r *DeliveryRetrier) Run(ctx context.Context) error {
+	ticker := time.NewTicker(deliveryRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			r.retryPending(ctx)
+		}
+	}
+}
+
+func (r *DeliveryRetrier) retryPending(ctx context.Context) {
+	defer func() {
+		if err := recover(); err != nil {
+			r.log.Error("Delivery retry panic", "error", err, "stack", log.Stack(1))
+		}
+	}()
+
+	query := &m.GetPendingAlertNotificationDeliveriesQuery{DueBefore: time.Now(), Limit: deliveryBatchSize}
+	if err := bus.Dispatch(query); err != nil {
+		r.log.Error("Failed to load pending notification deliveries", "error", err)
+		return
+	}
+
+	for _, delivery := range query.Result {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !r.isAssigned(delivery.AlertId) {
+			continue
+		}
+
+		r.retry(ctx, delivery)
+	}
+}
+
+// retry sends the delivery again once this server has claimed it, so two
+// servers never send the same retry, e.g. while alerts are reassigned.
+func (r *DeliveryRetrier) retry(ctx context.Context, delivery *m.AlertNotificationDelivery) {
+	now := time.Now()
+	claim := &m.ClaimAlertNotificationDeliveryCommand{Id: delivery.Id, Now: now, Until: now.Add(deliveryClaimDuration)}
+	if err := bus.Dispatch(claim); err != nil {
+		r.log.Error("Failed to claim notification delivery", "id", delivery.Id, "error", err)
+		return
+	}
+
+	if !claim.Claimed {
+		r.log.Debug("Notification delivery claimed by another server or superseded", "id", delivery.Id)
+		return
+	}
+
+	r.log.Info("Retrying notification", "alertId", delivery.AlertId, "notifierId", delivery.NotifierId, "attempts", delivery.Attempts)
+
+	notificationQuery := &m.GetAlertNotificationsQuery{OrgId: delivery.OrgId, Id: delivery.NotifierId}
+	if err := bus.Dispatch(notificationQuery); err != nil {
+		r.log.Error("Failed to load notification", "notifierId", delivery.NotifierId, "error", err)
+		return
+	}
+
+	if notificationQuery.Result == nil {
+		r.giveUp(delivery, "Notification channel no longer exists")
+		return
+	}
+
+	notifier, err := r.notifier.createNotifierFor(notificationQuery.Result)
+	if err != nil {
+		r.giveUp(delivery, err.Error())
+		return
+	}
+
+	snapshot, err := deliverySnapshotFromJson(delivery.Payload)
+	if err != nil {
+		r.giveUp(delivery, err.Error())
+		return
+	}
+
+	alertCtx, cancelFn := context.WithTimeout(ctx, alertTimeout)
+	defer cancelFn()
+
+	metrics.M_Alerting_Notification_Delivery_Retried.Inc(1)
+	r.notifier.deliver(snapshot.newEvalContext(alertCtx), notifier, delivery)
+}
+
+func (r *DeliveryRetrier) giveUp(delivery *m.AlertNotificationDelivery, reason string) {
+	r.log.Error("Giving up on notification", "alertId", delivery.AlertId, "notifierId", delivery.NotifierId, "reason", reason)
+	metrics.M_Alerting_Notification_Delivery_Failed.Inc(1)
+
+	cmd := &m.UpdateAlertNotificationDeliveryCommand{
+		Id:          delivery.Id,
+		Status:      m.AlertNotificationDeliveryFailed,
+		Attempts:    delivery.Attempts,
+		LastError:   reason,
+		NextAttempt: time.Now(),
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		r.log.Error("Failed to update notification delivery", "id", delivery.Id, "error", err)
+	}
+
+	historyCmd := &m.SaveAlertHistoryEntryCommand{
+		OrgId:              delivery.OrgId,
+		AlertId:            delivery.AlertId,
+		Type:               m.AlertHistoryNotification,
+		NotifierId:         delivery.NotifierId,
+		NotificationStatus: m.AlertNotificationDeliveryFailed,
+		Error:              reason,
+		Epoch:              toEpochMillis(time.Now()),
+	}
+
+	if err := bus.Dispatch(historyCmd); err != nil {
+		r.log.Error("Failed to save alert history", "alertId", delivery.AlertId, "error", err)
+	}
+}
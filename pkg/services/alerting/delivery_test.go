@@ -0,0 +1,223 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type deliveryNotifierStub struct {
+	FakeNotifier
+	err      error
+	contexts []*EvalContext
+}
+
+func (n *deliveryNotifierStub) GetNotifierId() int64 { return 4 }
+
+func (n *deliveryNotifierStub) Notify(evalContext *EvalContext) error {
+	n.contexts = append(n.contexts, evalContext)
+	return n.err
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	Convey("Notification delivery", t, func() {
+		Convey("backoff doubles up to the max delay", func() {
+			So(deliveryBackoff(1), ShouldEqual, time.Minute)
+			So(deliveryBackoff(2), ShouldEqual, 2*time.Minute)
+			So(deliveryBackoff(4), ShouldEqual, 8*time.Minute)
+			So(deliveryBackoff(20), ShouldEqual, time.Hour)
+		})
+
+		Convey("next delivery state", func() {
+			now := time.Now()
+			delivery := &m.AlertNotificationDelivery{Id: 1, Attempts: 1}
+
+			Convey("is sent when notify succeeds", func() {
+				cmd := nextDeliveryState(delivery, nil, now)
+				So(cmd.Status, ShouldEqual, m.AlertNotificationDeliverySent)
+				So(cmd.Attempts, ShouldEqual, 2)
+				So(cmd.LastError, ShouldEqual, "")
+			})
+
+			Convey("is pending with backoff when notify fails", func() {
+				cmd := nextDeliveryState(delivery, errors.New("502 Bad Gateway"), now)
+				So(cmd.Status, ShouldEqual, m.AlertNotificationDeliveryPending)
+				So(cmd.LastError, ShouldEqual, "502 Bad Gateway")
+				So(cmd.NextAttempt, ShouldResemble, now.Add(2*time.Minute))
+			})
+
+			Convey("is failed after max attempts", func() {
+				delivery.Attempts = deliveryMaxAttempts - 1
+				cmd := nextDeliveryState(delivery, errors.New("timeout"), now)
+				So(cmd.Status, ShouldEqual, m.AlertNotificationDeliveryFailed)
+				So(cmd.Attempts, ShouldEqual, deliveryMaxAttempts)
+			})
+		})
+
+		Convey("snapshot restores eval context", func() {
+			ctx := NewEvalContext(context.TODO(), &Rule{Id: 1, OrgId: 2, DashboardId: 3, PanelId: 4, Name: "cpu", Message: "high", State: m.AlertStateAlerting, Labels: map[string]string{"team": "payments"}})
+			ctx.PrevAlertState = m.AlertStateOK
+			ctx.Error = errors.New("query failed")
+			ctx.ImagePublicUrl = "http://image"
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server1", Value: 10, Tags: map[string]string{"host": "a"}}}
+
+			payload, err := newDeliverySnapshot(ctx).toJson()
+			So(err, ShouldBeNil)
+
+			snapshot, err := deliverySnapshotFromJson(payload)
+			So(err, ShouldBeNil)
+
+			restored := snapshot.newEvalContext(context.TODO())
+			So(restored.Rule.Id, ShouldEqual, 1)
+			So(restored.Rule.OrgId, ShouldEqual, 2)
+			So(restored.Rule.Name, ShouldEqual, "cpu")
+			So(restored.Rule.Message, ShouldEqual, "high")
+			So(restored.Rule.State, ShouldEqual, m.AlertStateAlerting)
+			So(restored.Rule.Labels, ShouldResemble, map[string]string{"team": "payments"})
+			So(restored.PrevAlertState, ShouldEqual, m.AlertStateOK)
+			So(restored.Firing, ShouldBeTrue)
+			So(restored.Error.Error(), ShouldEqual, "query failed")
+			So(restored.ImagePublicUrl, ShouldEqual, "http://image")
+			So(restored.EvalMatches[0].Tags["host"], ShouldEqual, "a")
+			So(restored.GetNotificationTitle(), ShouldEqual, ctx.GetNotificationTitle())
+		})
+
+		Convey("retrier", func() {
+			bus.ClearBusHandlers()
+
+			ctx := NewEvalContext(context.TODO(), &Rule{Id: 1, OrgId: 2, Name: "cpu", State: m.AlertStateAlerting})
+			payload, _ := newDeliverySnapshot(ctx).toJson()
+
+			pending := []*m.AlertNotificationDelivery{
+				{Id: 10, OrgId: 2, AlertId: 1, NotifierId: 4, Attempts: 1, Payload: payload},
+				{Id: 11, OrgId: 2, AlertId: 2, NotifierId: 4, Attempts: 1, Payload: payload},
+			}
+
+			bus.AddHandler("test", func(query *m.GetPendingAlertNotificationDeliveriesQuery) error {
+				query.Result = pending
+				return nil
+			})
+
+			bus.AddHandler("test", func(query *m.GetAlertNotificationsQuery) error {
+				query.Result = &m.AlertNotification{Id: query.Id, OrgId: query.OrgId, Type: "delivery_stub"}
+				return nil
+			})
+
+			bus.AddHandler("test", func(cmd *m.SetAlertNotificationStateCommand) error {
+				return nil
+			})
+
+			claimedElsewhere := false
+			bus.AddHandler("test", func(cmd *m.ClaimAlertNotificationDeliveryCommand) error {
+				cmd.Claimed = !claimedElsewhere
+				return nil
+			})
+
+			var updates []*m.UpdateAlertNotificationDeliveryCommand
+			bus.AddHandler("test", func(cmd *m.UpdateAlertNotificationDeliveryCommand) error {
+				updates = append(updates, cmd)
+				return nil
+			})
+
+			stub := &deliveryNotifierStub{}
+			RegisterNotifier("delivery_stub", func(model *m.AlertNotification) (Notifier, error) {
+				return stub, nil
+			})
+
+			retrier := NewDeliveryRetrier(func(alertId int64) bool { return alertId == 1 })
+
+			Convey("only retries deliveries of assigned alerts", func() {
+				retrier.retryPending(context.TODO())
+
+				So(len(stub.contexts), ShouldEqual, 1)
+				So(stub.contexts[0].Rule.Name, ShouldEqual, "cpu")
+				So(len(updates), ShouldEqual, 1)
+				So(updates[0].Id, ShouldEqual, 10)
+				So(updates[0].Status, ShouldEqual, m.AlertNotificationDeliverySent)
+				So(updates[0].Attempts, ShouldEqual, 2)
+			})
+
+			Convey("skips deliveries claimed by another server", func() {
+				claimedElsewhere = true
+				retrier.retryPending(context.TODO())
+
+				So(len(stub.contexts), ShouldEqual, 0)
+				So(len(updates), ShouldEqual, 0)
+			})
+
+			Convey("keeps delivery pending when notify fails again", func() {
+				stub.err = errors.New("connection refused")
+				retrier.retryPending(context.TODO())
+
+				So(len(updates), ShouldEqual, 1)
+				So(updates[0].Status, ShouldEqual, m.AlertNotificationDeliveryPending)
+				So(updates[0].LastError, ShouldEqual, "connection refused")
+			})
+
+			Convey("gives up when notification channel is gone", func() {
+				bus.AddHandler("test", func(query *m.GetAlertNotificationsQuery) error {
+					query.Result = nil
+					return nil
+				})
+
+				retrier.retryPending(context.TODO())
+
+				So(len(stub.contexts), ShouldEqual, 0)
+				So(len(updates), ShouldEqual, 1)
+				So(updates[0].Status, ShouldEqual, m.AlertNotificationDeliveryFailed)
+			})
+		})
+
+		Convey("sending notifications adds them to the outbox", func() {
+			bus.ClearBusHandlers()
+
+			var created *m.CreateAlertNotificationDeliveryCommand
+			bus.AddHandler("test", func(cmd *m.CreateAlertNotificationDeliveryCommand) error {
+				created = cmd
+				cmd.Result = &m.AlertNotificationDelivery{Id: 20, NotifierId: cmd.NotifierId, Payload: cmd.Payload}
+				return nil
+			})
+
+			var updated *m.UpdateAlertNotificationDeliveryCommand
+			bus.AddHandler("test", func(cmd *m.UpdateAlertNotificationDeliveryCommand) error {
+				updated = cmd
+				return nil
+			})
+
+			var history *m.SaveAlertHistoryEntryCommand
+			bus.AddHandler("test", func(cmd *m.SaveAlertHistoryEntryCommand) error {
+				history = cmd
+				return nil
+			})
+
+			ctx := NewEvalContext(context.TODO(), &Rule{Id: 1, OrgId: 2, Name: "cpu", State: m.AlertStateAlerting})
+			stub := &deliveryNotifierStub{err: errors.New("503 Service Unavailable")}
+
+			err := NewRootNotifier().sendNotifications(ctx, []Notifier{stub})
+
+			So(err, ShouldNotBeNil)
+			So(created.NotifierId, ShouldEqual, 4)
+			So(created.Payload.Get("Name").MustString(), ShouldEqual, "cpu")
+			So(updated.Id, ShouldEqual, 20)
+			So(updated.Status, ShouldEqual, m.AlertNotificationDeliveryPending)
+			So(updated.Attempts, ShouldEqual, 1)
+			So(history.Type, ShouldEqual, m.AlertHistoryNotification)
+			So(history.NotifierId, ShouldEqual, 4)
+			So(history.NotificationStatus, ShouldEqual, m.AlertNotificationDeliveryPending)
+			So(history.Error, ShouldEqual, "503 Service Unavailable")
+
+			Convey("test runs are not added", func() {
+				created = nil
+				ctx.IsTestRun = true
+				NewRootNotifier().sendNotifications(ctx, []Notifier{stub})
+
+				So(created, ShouldBeNil)
+			})
+		})
+	})
+}
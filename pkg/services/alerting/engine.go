@@ -5,32 +5,50 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	"github.com/grafana/grafana/pkg/setting"
 	"golang.org/x/sync/errgroup"
 )
 
 type Engine struct {
-	execQueue     chan *Job
-	clock         clock.Clock
-	ticker        *Ticker
-	scheduler     Scheduler
-	evalHandler   EvalHandler
-	ruleReader    RuleReader
-	log           log.Logger
-	resultHandler ResultHandler
+	execQueue         chan *Job
+	clock             clock.Clock
+	ticker            *Ticker
+	scheduler         Scheduler
+	evalHandler       EvalHandler
+	ruleReader        RuleReader
+	log               log.Logger
+	resultHandler     ResultHandler
+	workerCount       int
+	dataSourceLimiter *dataSourceLimiter
+	deliveryRetrier   *DeliveryRetrier
 }
 
 func NewEngine() *Engine {
 	e := &Engine{
-		ticker:        NewTicker(time.Now(), time.Second*0, clock.New()),
-		execQueue:     make(chan *Job, 1000),
-		scheduler:     NewScheduler(),
-		evalHandler:   NewEvalHandler(),
-		ruleReader:    NewRuleReader(),
-		log:           log.New("alerting.engine"),
-		resultHandler: NewResultHandler(),
+		ticker:            NewTicker(time.Now(), time.Second*0, clock.New()),
+		execQueue:         make(chan *Job, 1000),
+		scheduler:         NewScheduler(),
+		evalHandler:       NewEvalHandler(),
+		ruleReader:        NewRuleReader(),
+		log:               log.New("alerting.engine"),
+		resultHandler:     NewResultHandler(),
+		workerCount:       setting.AlertingMaxConcurrentEvaluations,
+		dataSourceLimiter: newDataSourceLimiter(setting.AlertingMaxConcurrentEvaluationsPerDataSource),
 	}
 
+	e.deliveryRetrier = NewDeliveryRetrier(e.ruleReader.IsAssigned)
+
+	if e.workerCount < 1 {
+		e.workerCount = 1
+	}
+
+	bus.AddEventListener(e.alertUpdated)
+	bus.AddEventListener(e.alertDeleted)
+
 	return e
 }
 
@@ -41,6 +59,7 @@ func (e *Engine) Run(ctx context.Context) error {
 
 	alertGroup.Go(func() error { return e.alertingTicker(ctx) })
 	alertGroup.Go(func() error { return e.runJobDispatcher(ctx) })
+	alertGroup.Go(func() error { return e.deliveryRetrier.Run(ctx) })
 
 	err := alertGroup.Wait()
 
@@ -55,33 +74,60 @@ func (e *Engine) alertingTicker(grafanaCtx context.Context) error {
 		}
 	}()
 
-	tickIndex := 0
+	var lastResync time.Time
 
 	for {
 		select {
 		case <-grafanaCtx.Done():
 			return grafanaCtx.Err()
 		case tick := <-e.ticker.C:
-			// TEMP SOLUTION update rules ever tenth tick
-			if tickIndex%10 == 0 {
+			// rule changes are applied through events, this full resync
+			// is a safety net for missed events and cluster changes
+			if tick.Sub(lastResync) >= ruleResyncInterval {
 				e.scheduler.Update(e.ruleReader.Fetch())
+				lastResync = tick
 			}
 
 			e.scheduler.Tick(tick, e.execQueue)
-			tickIndex++
 		}
 	}
 }
 
+func (e *Engine) alertUpdated(evt *events.AlertUpdated) error {
+	if rule := e.ruleReader.FetchOne(evt.Id); rule != nil {
+		e.scheduler.UpdateJob(rule)
+	} else {
+		e.scheduler.RemoveJob(evt.Id)
+	}
+
+	return nil
+}
+
+func (e *Engine) alertDeleted(evt *events.AlertDeleted) error {
+	e.scheduler.RemoveJob(evt.Id)
+	return nil
+}
+
+// runJobDispatcher evaluates queued jobs with a fixed number of workers.
 func (e *Engine) runJobDispatcher(grafanaCtx context.Context) error {
 	dispatcherGroup, alertCtx := errgroup.WithContext(grafanaCtx)
 
+	for i := 0; i < e.workerCount; i++ {
+		dispatcherGroup.Go(func() error { return e.runWorker(grafanaCtx, alertCtx) })
+	}
+
+	return dispatcherGroup.Wait()
+}
+
+func (e *Engine) runWorker(grafanaCtx context.Context, alertCtx context.Context) error {
 	for {
 		select {
 		case <-grafanaCtx.Done():
-			return dispatcherGroup.Wait()
+			return nil
 		case job := <-e.execQueue:
-			dispatcherGroup.Go(func() error { return e.processJob(alertCtx, job) })
+			if err := e.processJob(alertCtx, job); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -89,33 +135,46 @@ func (e *Engine) runJobDispatcher(grafanaCtx context.Context) error {
 var (
 	unfinishedWorkTimeout time.Duration = time.Second * 5
 	alertTimeout          time.Duration = time.Second * 30
+	ruleResyncInterval    time.Duration = time.Minute * 1
 )
 
+// processJob evaluates the job and waits for the evaluation until the alert
+// timeout has passed. A hung evaluation then keeps running on its own and
+// the job stays running until it returns, so the rule is not evaluated twice
+// at the same time.
 func (e *Engine) processJob(grafanaCtx context.Context, job *Job) error {
+	evaluating := false
 	defer func() {
 		if err := recover(); err != nil {
 			e.log.Error("Alert Panic", "error", err, "stack", log.Stack(1))
+			if !evaluating {
+				e.scheduler.JobDone(job)
+			}
 		}
 	}()
 
+	metrics.M_Alerting_Queue_Wait_Time.UpdateSince(job.Queued)
+
 	alertCtx, cancelFn := context.WithTimeout(context.TODO(), alertTimeout)
+	defer cancelFn()
 
-	job.Running = true
 	evalContext := NewEvalContext(alertCtx, job.Rule)
+	evalContext.dataSourceLimiter = e.dataSourceLimiter
 
 	done := make(chan struct{})
 
+	evaluating = true
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
 				e.log.Error("Alert Panic", "error", err, "stack", log.Stack(1))
-				close(done)
 			}
+			e.scheduler.JobDone(job)
+			close(done)
 		}()
 
 		e.evalHandler.Eval(evalContext)
 		e.resultHandler.Handle(evalContext)
-		close(done)
 	}()
 
 	var err error = nil
@@ -123,15 +182,19 @@ func (e *Engine) processJob(grafanaCtx context.Context, job *Job) error {
 	case <-grafanaCtx.Done():
 		select {
 		case <-time.After(unfinishedWorkTimeout):
-			cancelFn()
 			err = grafanaCtx.Err()
 		case <-done:
 		}
+	case <-alertCtx.Done():
+		select {
+		case <-time.After(unfinishedWorkTimeout):
+			e.log.Error("Alert evaluation did not finish in time, releasing worker", "alertId", job.Rule.Id, "name", job.Rule.Name)
+			return nil
+		case <-done:
+		}
 	case <-done:
 	}
 
 	e.log.Debug("Job Execution completed", "timeMs", evalContext.GetDurationMs(), "alertId", evalContext.Rule.Id, "name", evalContext.Rule.Name, "firing", evalContext.Firing)
-	job.Running = false
-	cancelFn()
 	return err
 }
@@ -0,0 +1,67 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type blockingEvalHandler struct {
+	release chan bool
+}
+
+func (h *blockingEvalHandler) Eval(evalContext *EvalContext) {
+	<-h.release
+}
+
+type fakeResultHandler struct{}
+
+func (h *fakeResultHandler) Handle(evalContext *EvalContext) error {
+	return nil
+}
+
+func TestEngineProcessJob(t *testing.T) {
+	Convey("Given an engine with a hung evaluation", t, func() {
+		prevAlertTimeout, prevUnfinishedWorkTimeout := alertTimeout, unfinishedWorkTimeout
+		alertTimeout, unfinishedWorkTimeout = 10*time.Millisecond, 10*time.Millisecond
+		defer func() { alertTimeout, unfinishedWorkTimeout = prevAlertTimeout, prevUnfinishedWorkTimeout }()
+
+		evalHandler := &blockingEvalHandler{release: make(chan bool)}
+		scheduler := NewScheduler().(*SchedulerImpl)
+		engine := &Engine{
+			scheduler:     scheduler,
+			evalHandler:   evalHandler,
+			resultHandler: &fakeResultHandler{},
+			log:           log.New("alerting.engine"),
+		}
+
+		scheduler.UpdateJob(&Rule{Id: 1, Frequency: 10})
+		execQueue := make(chan *Job, 1)
+		scheduler.Tick(time.Unix(100, 0), execQueue)
+		job := <-execQueue
+
+		isRunning := func() bool {
+			scheduler.mtx.Lock()
+			defer scheduler.mtx.Unlock()
+			return job.Running
+		}
+
+		Convey("Should release the worker after the alert timeout", func() {
+			err := engine.processJob(context.Background(), job)
+			So(err, ShouldBeNil)
+
+			Convey("And keep the job running until the evaluation returns", func() {
+				So(isRunning(), ShouldBeTrue)
+
+				close(evalHandler.release)
+				for isRunning() {
+					time.Sleep(time.Millisecond)
+				}
+				So(isRunning(), ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -3,12 +3,14 @@ package alerting
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/log"
 	m "github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
 )
 
 type EvalContext struct {
@@ -23,10 +25,22 @@ type EvalContext struct {
 	Rule            *Rule
 	log             log.Logger
 	dashboardSlug   string
+	dashboardTags   []string
+	dashboardMtx    sync.Mutex
 	ImagePublicUrl  string
 	ImageOnDiskPath string
 	NoDataFound     bool
 	PrevAlertState  m.AlertStateType
+	SilencedBy      *m.AlertSilence
+
+	NewInstances      []*m.AlertInstance
+	ResolvedInstances []*m.AlertInstance
+
+	dataSourceLimiter *dataSourceLimiter
+	querySeries       map[string]tsdb.TimeSeriesSlice
+
+	templateData     *TemplateData
+	templateDataOnce sync.Once
 
 	Ctx context.Context
 }
@@ -66,6 +80,11 @@ func (c *EvalContext) GetStateModel() *StateDescription {
 			Color: "#D63232",
 			Text:  "Alerting",
 		}
+	case m.AlertStatePending:
+		return &StateDescription{
+			Color: "#FFA500",
+			Text:  "Pending",
+		}
 	default:
 		panic("Unknown rule state " + c.Rule.State)
 	}
@@ -80,18 +99,74 @@ func (c *EvalContext) ShouldSendNotification() bool {
 		return false
 	}
 
+	if c.Rule.State == m.AlertStatePending {
+		return false
+	}
+
+	if c.SilencedBy != nil {
+		return false
+	}
+
 	return true
 }
 
+// ShouldSendReminder returns true when the alert is still alerting and
+// notifications with reminders enabled should be sent again.
+func (c *EvalContext) ShouldSendReminder() bool {
+	return c.Rule.State == m.AlertStateAlerting && !c.ShouldUpdateAlertState() && c.SilencedBy == nil && !c.HasInstanceChanges()
+}
+
+// HasInstanceChanges is true when series started or stopped firing
+// while the rule as a whole kept alerting.
+func (c *EvalContext) HasInstanceChanges() bool {
+	if c.Rule.State != m.AlertStateAlerting || c.ShouldUpdateAlertState() {
+		return false
+	}
+
+	return len(c.NewInstances) > 0 || len(c.ResolvedInstances) > 0
+}
+
 func (a *EvalContext) GetDurationMs() float64 {
 	return float64(a.EndTime.Nanosecond()-a.StartTime.Nanosecond()) / float64(1000000)
 }
 
 func (c *EvalContext) GetNotificationTitle() string {
-	return "[" + c.GetStateModel().Text + "] " + c.Rule.Name
+	title := "[" + c.GetStateModel().Text + "] " + c.Rule.Name
+
+	if c.HasInstanceChanges() {
+		title += fmt.Sprintf(" (%d new, %d resolved)", len(c.NewInstances), len(c.ResolvedInstances))
+	}
+
+	return title
+}
+
+// AcquireDataSource waits until a query can be sent to the data source
+// without going over the concurrency limit. Call the returned func when
+// the query is done.
+func (c *EvalContext) AcquireDataSource(datasourceId int64) (func(), error) {
+	return c.dataSourceLimiter.acquire(c.Ctx, datasourceId)
+}
+
+// GetQuerySeries returns the series a query returned earlier in the
+// evaluation, the key identifies the query and its time range.
+func (c *EvalContext) GetQuerySeries(key string) (tsdb.TimeSeriesSlice, bool) {
+	series, ok := c.querySeries[key]
+	return series, ok
+}
+
+// SetQuerySeries keeps the series the query returned, so conditions using
+// the same query do not run it again.
+func (c *EvalContext) SetQuerySeries(key string, series tsdb.TimeSeriesSlice) {
+	if c.querySeries == nil {
+		c.querySeries = make(map[string]tsdb.TimeSeriesSlice)
+	}
+	c.querySeries[key] = series
 }
 
 func (c *EvalContext) GetDashboardSlug() (string, error) {
+	c.dashboardMtx.Lock()
+	defer c.dashboardMtx.Unlock()
+
 	if c.dashboardSlug != "" {
 		return c.dashboardSlug, nil
 	}
@@ -105,6 +180,27 @@ func (c *EvalContext) GetDashboardSlug() (string, error) {
 	return c.dashboardSlug, nil
 }
 
+// GetDashboardTags is safe to call from notifiers sending at the same time.
+func (c *EvalContext) GetDashboardTags() ([]string, error) {
+	c.dashboardMtx.Lock()
+	defer c.dashboardMtx.Unlock()
+
+	if c.dashboardTags != nil {
+		return c.dashboardTags, nil
+	}
+
+	tagsQuery := &m.GetDashboardTagsByIdQuery{DashboardId: c.Rule.DashboardId}
+	if err := bus.Dispatch(tagsQuery); err != nil {
+		return nil, err
+	}
+
+	c.dashboardTags = tagsQuery.Result
+	if c.dashboardTags == nil {
+		c.dashboardTags = []string{}
+	}
+	return c.dashboardTags, nil
+}
+
 func (c *EvalContext) GetRuleUrl() (string, error) {
 	if c.IsTestRun {
 		return setting.AppUrl, nil
@@ -43,6 +43,43 @@ func TestAlertingEvalContext(t *testing.T) {
 
 				So(ctx.ShouldSendNotification(), ShouldBeTrue)
 			})
+
+			Convey("ok -> pending", func() {
+				ctx.PrevAlertState = models.AlertStateOK
+				ctx.Rule.State = models.AlertStatePending
+
+				So(ctx.ShouldSendNotification(), ShouldBeFalse)
+			})
+
+			Convey("pending -> alerting", func() {
+				ctx.PrevAlertState = models.AlertStatePending
+				ctx.Rule.State = models.AlertStateAlerting
+
+				So(ctx.ShouldSendNotification(), ShouldBeTrue)
+			})
+		})
+
+		Convey("Should send reminders", func() {
+			Convey("alerting -> alerting", func() {
+				ctx.PrevAlertState = models.AlertStateAlerting
+				ctx.Rule.State = models.AlertStateAlerting
+
+				So(ctx.ShouldSendReminder(), ShouldBeTrue)
+			})
+
+			Convey("ok -> alerting", func() {
+				ctx.PrevAlertState = models.AlertStateOK
+				ctx.Rule.State = models.AlertStateAlerting
+
+				So(ctx.ShouldSendReminder(), ShouldBeFalse)
+			})
+
+			Convey("ok -> ok", func() {
+				ctx.PrevAlertState = models.AlertStateOK
+				ctx.Rule.State = models.AlertStateOK
+
+				So(ctx.ShouldSendReminder(), ShouldBeFalse)
+			})
 		})
 	})
 }
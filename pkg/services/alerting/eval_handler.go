@@ -1,6 +1,8 @@
 package alerting
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/log"
@@ -10,18 +12,33 @@ import (
 type DefaultEvalHandler struct {
 	log             log.Logger
 	alertJobTimeout time.Duration
+	now             func() time.Time
 }
 
 func NewEvalHandler() *DefaultEvalHandler {
+	return newEvalHandlerWithClock(time.Now)
+}
+
+// newEvalHandlerWithClock returns a handler that evaluates rules at the
+// time returned by now, which is used to evaluate rules in the past.
+func newEvalHandlerWithClock(now func() time.Time) *DefaultEvalHandler {
 	return &DefaultEvalHandler{
 		log:             log.New("alerting.evalHandler"),
 		alertJobTimeout: time.Second * 5,
+		now:             now,
 	}
 }
 
 func (e *DefaultEvalHandler) Eval(context *EvalContext) {
-	firing := true
-	for _, condition := range context.Rule.Conditions {
+	started := time.Now()
+	context.StartTime = e.now()
+
+	firing := false
+	first := true
+
+	// condition results are folded in order, each one combined with
+	// the result so far using its own operator
+	for i, condition := range context.Rule.Conditions {
 		cr, err := condition.Eval(context)
 		if err != nil {
 			context.Error = err
@@ -32,17 +49,44 @@ func (e *DefaultEvalHandler) Eval(context *EvalContext) {
 			break
 		}
 
-		// break if result has not triggered yet
-		if cr.Firing == false {
-			firing = false
-			break
+		// excluded conditions do not take part in the rule result
+		if cr.Excluded {
+			continue
+		}
+
+		prevFiring := firing
+		if first {
+			firing = cr.Firing
+		} else if cr.Operator == "or" {
+			firing = firing || cr.Firing
+		} else {
+			firing = firing && cr.Firing
 		}
 
-		context.EvalMatches = append(context.EvalMatches, cr.EvalMatches...)
+		if context.IsTestRun {
+			message := fmt.Sprintf("Condition[%d]: Firing: %v", i, firing)
+			if !first {
+				message = fmt.Sprintf("Condition[%d]: %v %s %v = %v", i, prevFiring, strings.ToUpper(getOperator(cr)), cr.Firing, firing)
+			}
+			context.Logs = append(context.Logs, &ResultLogEntry{Message: message})
+		}
+
+		first = false
+
+		if cr.Firing {
+			context.EvalMatches = append(context.EvalMatches, cr.EvalMatches...)
+		}
 	}
 
 	context.Firing = firing
-	context.EndTime = time.Now()
-	elapsedTime := context.EndTime.Sub(context.StartTime) / time.Millisecond
+	context.EndTime = e.now()
+	elapsedTime := time.Since(started) / time.Millisecond
 	metrics.M_Alerting_Exeuction_Time.Update(elapsedTime)
 }
+
+func getOperator(cr *ConditionResult) string {
+	if cr.Operator == "or" {
+		return "or"
+	}
+	return "and"
+}
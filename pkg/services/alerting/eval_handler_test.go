@@ -8,12 +8,15 @@ import (
 )
 
 type conditionStub struct {
-	firing  bool
-	matches []*EvalMatch
+	firing   bool
+	operator string
+	noData   bool
+	excluded bool
+	matches  []*EvalMatch
 }
 
 func (c *conditionStub) Eval(context *EvalContext) (*ConditionResult, error) {
-	return &ConditionResult{Firing: c.firing, EvalMatches: c.matches}, nil
+	return &ConditionResult{Firing: c.firing, EvalMatches: c.matches, Operator: c.operator, NoDataFound: c.noData, Excluded: c.excluded}, nil
 }
 
 func TestAlertingExecutor(t *testing.T) {
@@ -42,5 +45,72 @@ func TestAlertingExecutor(t *testing.T) {
 			handler.Eval(context)
 			So(context.Firing, ShouldEqual, false)
 		})
+
+		Convey("Show return true if any of the conditions combined with or is passing", func() {
+			context := NewEvalContext(context.TODO(), &Rule{
+				Conditions: []Condition{
+					&conditionStub{firing: false},
+					&conditionStub{firing: true, operator: "or", matches: []*EvalMatch{&EvalMatch{}}},
+				},
+			})
+
+			handler.Eval(context)
+			So(context.Firing, ShouldEqual, true)
+			So(len(context.EvalMatches), ShouldEqual, 1)
+		})
+
+		Convey("Show fold conditions in order", func() {
+			// (true AND false) OR true AND false = false
+			context := NewEvalContext(context.TODO(), &Rule{
+				Conditions: []Condition{
+					&conditionStub{firing: true},
+					&conditionStub{firing: false, operator: "and"},
+					&conditionStub{firing: true, operator: "or"},
+					&conditionStub{firing: false, operator: "and"},
+				},
+			})
+
+			handler.Eval(context)
+			So(context.Firing, ShouldEqual, false)
+		})
+
+		Convey("Show not set no data for conditions without data", func() {
+			context := NewEvalContext(context.TODO(), &Rule{
+				Conditions: []Condition{
+					&conditionStub{noData: true},
+					&conditionStub{noData: true, operator: "or"},
+				},
+			})
+
+			handler.Eval(context)
+			So(context.NoDataFound, ShouldEqual, false)
+		})
+
+		Convey("Show ignore excluded conditions", func() {
+			context := NewEvalContext(context.TODO(), &Rule{
+				Conditions: []Condition{
+					&conditionStub{excluded: true},
+					&conditionStub{firing: true, operator: "and"},
+				},
+			})
+
+			handler.Eval(context)
+			So(context.Firing, ShouldEqual, true)
+		})
+
+		Convey("Show log the fold for test runs", func() {
+			context := NewEvalContext(context.TODO(), &Rule{
+				Conditions: []Condition{
+					&conditionStub{firing: false},
+					&conditionStub{firing: true, operator: "or"},
+				},
+			})
+			context.IsTestRun = true
+
+			handler.Eval(context)
+			So(len(context.Logs), ShouldEqual, 2)
+			So(context.Logs[0].Message, ShouldEqual, "Condition[0]: Firing: false")
+			So(context.Logs[1].Message, ShouldEqual, "Condition[1]: false OR true = true")
+		})
 	})
 }
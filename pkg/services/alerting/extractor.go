@@ -95,12 +95,18 @@ func (e *DashAlertExtractor) GetAlerts() ([]*m.Alert, error) {
 				Name:        jsonAlert.Get("name").MustString(),
 				Handler:     jsonAlert.Get("handler").MustInt64(),
 				Message:     jsonAlert.Get("message").MustString(),
+				Severity:    jsonAlert.Get("severity").MustString(string(m.AlertSeverityCritical)),
 				Frequency:   frequency,
 			}
 
 			for _, condition := range jsonAlert.Get("conditions").MustArray() {
 				jsonCondition := simplejson.NewFromAny(condition)
 
+				// only query conditions refer to panel queries
+				if jsonCondition.Get("type").MustString("query") != "query" {
+					continue
+				}
+
 				jsonQuery := jsonCondition.Get("query")
 				queryRefId := jsonQuery.Get("params").MustArray()[0].(string)
 				panelQuery := findPanelQueryByRefId(panel, queryRefId)
@@ -134,6 +140,10 @@ func (e *DashAlertExtractor) GetAlerts() ([]*m.Alert, error) {
 
 			// validate
 			_, err = NewRuleFromDBAlert(alert)
+			if err == nil {
+				err = ValidateTemplate(alert.Message)
+			}
+
 			if err == nil && alert.ValidToSave() {
 				alerts = append(alerts, alert)
 			} else {
@@ -0,0 +1,58 @@
+package alerting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func toEpochMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// newEvaluationHistoryEntry records an evaluation that changed the state
+// of the rule or failed to execute.
+func newEvaluationHistoryEntry(c *EvalContext) *m.SaveAlertHistoryEntryCommand {
+	cmd := &m.SaveAlertHistoryEntryCommand{
+		OrgId:       c.Rule.OrgId,
+		AlertId:     c.Rule.Id,
+		Type:        m.AlertHistoryStateChange,
+		PrevState:   c.PrevAlertState,
+		NewState:    c.Rule.State,
+		EvalMatches: simplejson.NewFromAny(c.EvalMatches),
+		Epoch:       toEpochMillis(c.StartTime),
+	}
+
+	if !c.ShouldUpdateAlertState() {
+		cmd.Type = m.AlertHistoryError
+	}
+
+	if c.Error != nil {
+		cmd.Error = c.Error.Error()
+	}
+
+	return cmd
+}
+
+// newNotificationHistoryEntry records the outcome of sending a notification
+// for the evaluation.
+func newNotificationHistoryEntry(c *EvalContext, notifierId int64, status m.AlertNotificationDeliveryStatus, sendErr error) *m.SaveAlertHistoryEntryCommand {
+	cmd := &m.SaveAlertHistoryEntryCommand{
+		OrgId:              c.Rule.OrgId,
+		AlertId:            c.Rule.Id,
+		Type:               m.AlertHistoryNotification,
+		PrevState:          c.PrevAlertState,
+		NewState:           c.Rule.State,
+		EvalMatches:        simplejson.NewFromAny(c.EvalMatches),
+		NotifierId:         notifierId,
+		NotificationStatus: status,
+		Epoch:              toEpochMillis(time.Now()),
+	}
+
+	if sendErr != nil {
+		cmd.Error = sendErr.Error()
+	}
+
+	return cmd
+}
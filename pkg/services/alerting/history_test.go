@@ -0,0 +1,50 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertHistory(t *testing.T) {
+	Convey("Alert history entries", t, func() {
+		ctx := NewEvalContext(context.TODO(), &Rule{Id: 1, OrgId: 2, State: m.AlertStateAlerting})
+		ctx.PrevAlertState = m.AlertStateOK
+		ctx.StartTime = time.Unix(10, 0)
+		ctx.EvalMatches = []*EvalMatch{{Metric: "server1", Value: 95}}
+
+		Convey("state change", func() {
+			cmd := newEvaluationHistoryEntry(ctx)
+
+			So(cmd.Type, ShouldEqual, m.AlertHistoryStateChange)
+			So(cmd.PrevState, ShouldEqual, m.AlertStateOK)
+			So(cmd.NewState, ShouldEqual, m.AlertStateAlerting)
+			So(cmd.Epoch, ShouldEqual, 10000)
+
+			evalMatches, _ := cmd.EvalMatches.MarshalJSON()
+			So(string(evalMatches), ShouldContainSubstring, `"metric":"server1"`)
+		})
+
+		Convey("error without state change", func() {
+			ctx.PrevAlertState = m.AlertStateAlerting
+			ctx.Error = errors.New("query timeout")
+			cmd := newEvaluationHistoryEntry(ctx)
+
+			So(cmd.Type, ShouldEqual, m.AlertHistoryError)
+			So(cmd.Error, ShouldEqual, "query timeout")
+		})
+
+		Convey("notification", func() {
+			cmd := newNotificationHistoryEntry(ctx, 4, m.AlertNotificationDeliverySent, nil)
+
+			So(cmd.Type, ShouldEqual, m.AlertHistoryNotification)
+			So(cmd.NotifierId, ShouldEqual, 4)
+			So(cmd.NotificationStatus, ShouldEqual, m.AlertNotificationDeliverySent)
+			So(cmd.Error, ShouldEqual, "")
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package alerting
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+// resolved instances are kept around for a while so the api can show
+// when a series stopped firing
+var resolvedInstanceRetention = time.Hour * 24
+
+// getInstanceKey identifies a series by its metric name and tags.
+func getInstanceKey(match *EvalMatch) string {
+	if len(match.Tags) == 0 {
+		return match.Metric
+	}
+
+	tags := make([]string, 0, len(match.Tags))
+	for key, value := range match.Tags {
+		tags = append(tags, key+"="+value)
+	}
+	sort.Strings(tags)
+
+	return match.Metric + "{" + strings.Join(tags, ",") + "}"
+}
+
+// updateInstances moves the per series state forward from the eval matches
+// of the rule and records which series started and stopped firing. The
+// instances are left as they are when the evaluation did not produce
+// reliable matches, e.g. on errors, no data or while pending.
+func updateInstances(evalContext *EvalContext, prev []*m.AlertInstance, now time.Time) []*m.AlertInstance {
+	state := evalContext.Rule.State
+	if evalContext.Error != nil || evalContext.NoDataFound || (state != m.AlertStateAlerting && state != m.AlertStateOK) {
+		return prev
+	}
+
+	current := make(map[string]*EvalMatch)
+	if state == m.AlertStateAlerting {
+		for _, match := range evalContext.EvalMatches {
+			current[getInstanceKey(match)] = match
+		}
+	}
+
+	instances := make([]*m.AlertInstance, 0, len(current))
+	for _, instance := range prev {
+		match, firing := current[instance.InstanceKey]
+		delete(current, instance.InstanceKey)
+
+		if firing {
+			if instance.State != m.AlertStateAlerting {
+				instance.State = m.AlertStateAlerting
+				instance.FirstSeen = now
+				instance.ResolvedAt = time.Time{}
+				evalContext.NewInstances = append(evalContext.NewInstances, instance)
+			}
+			instance.Value = match.Value
+			instance.Updated = now
+		} else if instance.State == m.AlertStateAlerting {
+			instance.State = m.AlertStateOK
+			instance.ResolvedAt = now
+			instance.Updated = now
+			evalContext.ResolvedInstances = append(evalContext.ResolvedInstances, instance)
+		} else if now.Sub(instance.ResolvedAt) > resolvedInstanceRetention {
+			continue
+		}
+
+		instances = append(instances, instance)
+	}
+
+	for key, match := range current {
+		instance := &m.AlertInstance{
+			OrgId:       evalContext.Rule.OrgId,
+			AlertId:     evalContext.Rule.Id,
+			InstanceKey: key,
+			Metric:      match.Metric,
+			Tags:        match.Tags,
+			State:       m.AlertStateAlerting,
+			Value:       match.Value,
+			FirstSeen:   now,
+			Updated:     now,
+		}
+
+		instances = append(instances, instance)
+		evalContext.NewInstances = append(evalContext.NewInstances, instance)
+	}
+
+	sort.Sort(instancesByKey(instances))
+	sort.Sort(instancesByKey(evalContext.NewInstances))
+	return instances
+}
+
+type instancesByKey []*m.AlertInstance
+
+func (s instancesByKey) Len() int           { return len(s) }
+func (s instancesByKey) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s instancesByKey) Less(i, j int) bool { return s[i].InstanceKey < s[j].InstanceKey }
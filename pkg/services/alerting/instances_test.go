@@ -0,0 +1,102 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertInstances(t *testing.T) {
+	Convey("Alert instances", t, func() {
+		now := time.Now()
+		rule := &Rule{Id: 1, OrgId: 1, Name: "cpu", State: m.AlertStateAlerting}
+		ctx := NewEvalContext(context.TODO(), rule)
+		ctx.PrevAlertState = m.AlertStateAlerting
+
+		Convey("Should use metric and sorted tags as key", func() {
+			So(getInstanceKey(&EvalMatch{Metric: "cpu"}), ShouldEqual, "cpu")
+			So(getInstanceKey(&EvalMatch{Metric: "cpu", Tags: map[string]string{"host": "a", "dc": "eu"}}), ShouldEqual, "cpu{dc=eu,host=a}")
+		})
+
+		Convey("Should add new firing series", func() {
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server2", Value: 2}, {Metric: "server1", Value: 1}}
+
+			instances := updateInstances(ctx, nil, now)
+			So(len(instances), ShouldEqual, 2)
+			So(instances[0].InstanceKey, ShouldEqual, "server1")
+			So(instances[0].State, ShouldEqual, m.AlertStateAlerting)
+			So(instances[0].FirstSeen, ShouldResemble, now)
+			So(len(ctx.NewInstances), ShouldEqual, 2)
+			So(ctx.HasInstanceChanges(), ShouldBeTrue)
+			So(ctx.GetNotificationTitle(), ShouldEqual, "[Alerting] cpu (2 new, 0 resolved)")
+		})
+
+		Convey("Should keep first seen of series that keep firing and resolve the rest", func() {
+			firstSeen := now.Add(-time.Hour)
+			prev := []*m.AlertInstance{
+				{InstanceKey: "server1", State: m.AlertStateAlerting, FirstSeen: firstSeen, Value: 1},
+				{InstanceKey: "server2", State: m.AlertStateAlerting, FirstSeen: firstSeen},
+			}
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server1", Value: 5}}
+
+			instances := updateInstances(ctx, prev, now)
+			So(len(instances), ShouldEqual, 2)
+			So(instances[0].FirstSeen, ShouldResemble, firstSeen)
+			So(instances[0].Value, ShouldEqual, 5)
+			So(instances[1].State, ShouldEqual, m.AlertStateOK)
+			So(instances[1].ResolvedAt, ShouldResemble, now)
+			So(len(ctx.NewInstances), ShouldEqual, 0)
+			So(len(ctx.ResolvedInstances), ShouldEqual, 1)
+			So(ctx.HasInstanceChanges(), ShouldBeTrue)
+			So(ctx.ShouldSendReminder(), ShouldBeFalse)
+		})
+
+		Convey("Should not report changes when nothing changed", func() {
+			prev := []*m.AlertInstance{{InstanceKey: "server1", State: m.AlertStateAlerting}}
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server1"}}
+
+			updateInstances(ctx, prev, now)
+			So(ctx.HasInstanceChanges(), ShouldBeFalse)
+			So(ctx.ShouldSendReminder(), ShouldBeTrue)
+		})
+
+		Convey("Should refire resolved series", func() {
+			prev := []*m.AlertInstance{{InstanceKey: "server1", State: m.AlertStateOK, ResolvedAt: now.Add(-time.Minute)}}
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server1"}}
+
+			instances := updateInstances(ctx, prev, now)
+			So(instances[0].State, ShouldEqual, m.AlertStateAlerting)
+			So(instances[0].ResolvedAt.IsZero(), ShouldBeTrue)
+			So(len(ctx.NewInstances), ShouldEqual, 1)
+		})
+
+		Convey("Should resolve all series when rule is ok and drop old resolved ones", func() {
+			rule.State = m.AlertStateOK
+			prev := []*m.AlertInstance{
+				{InstanceKey: "server1", State: m.AlertStateAlerting},
+				{InstanceKey: "server2", State: m.AlertStateOK, ResolvedAt: now.Add(-resolvedInstanceRetention - time.Minute)},
+			}
+
+			instances := updateInstances(ctx, prev, now)
+			So(len(instances), ShouldEqual, 1)
+			So(instances[0].State, ShouldEqual, m.AlertStateOK)
+			So(len(ctx.ResolvedInstances), ShouldEqual, 1)
+
+			Convey("Rule state change is notified instead", func() {
+				So(ctx.HasInstanceChanges(), ShouldBeFalse)
+			})
+		})
+
+		Convey("Should keep instances on execution errors", func() {
+			ctx.Error = context.DeadlineExceeded
+			prev := []*m.AlertInstance{{InstanceKey: "server1", State: m.AlertStateAlerting}}
+
+			instances := updateInstances(ctx, prev, now)
+			So(instances, ShouldResemble, prev)
+			So(len(ctx.ResolvedInstances), ShouldEqual, 0)
+		})
+	})
+}
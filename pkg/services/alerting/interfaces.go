@@ -9,6 +9,9 @@ type EvalHandler interface {
 type Scheduler interface {
 	Tick(time time.Time, execQueue chan *Job)
 	Update(rules []*Rule)
+	UpdateJob(rule *Rule)
+	RemoveJob(ruleId int64)
+	JobDone(job *Job)
 }
 
 type Notifier interface {
@@ -21,9 +24,18 @@ type Notifier interface {
 	GetIsDefault() bool
 }
 
+// ResendingNotifier is implemented by notifiers that are sent on every
+// evaluation while the alert is alerting, whatever their reminder settings.
+// Resends only post the alert again, without image, outbox or history.
+type ResendingNotifier interface {
+	ResendsWhileAlerting() bool
+}
+
 type ConditionResult struct {
 	Firing      bool
 	NoDataFound bool
+	Operator    string
+	Excluded    bool
 	EvalMatches []*EvalMatch
 }
 
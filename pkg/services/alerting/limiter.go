@@ -0,0 +1,55 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+)
+
+// dataSourceLimiter bounds the number of alert queries running against
+// a single data source, so one slow data source cannot occupy every worker.
+type dataSourceLimiter struct {
+	mtx   sync.Mutex
+	limit int
+	slots map[int64]chan struct{}
+}
+
+func newDataSourceLimiter(limit int) *dataSourceLimiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &dataSourceLimiter{
+		limit: limit,
+		slots: make(map[int64]chan struct{}),
+	}
+}
+
+func (l *dataSourceLimiter) getSlots(datasourceId int64) chan struct{} {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	slots, exists := l.slots[datasourceId]
+	if !exists {
+		slots = make(chan struct{}, l.limit)
+		l.slots[datasourceId] = slots
+	}
+
+	return slots
+}
+
+// acquire waits for a free slot for the data source. The returned func
+// must be called to release the slot.
+func (l *dataSourceLimiter) acquire(ctx context.Context, datasourceId int64) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	slots := l.getSlots(datasourceId)
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
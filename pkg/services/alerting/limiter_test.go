@@ -0,0 +1,48 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDataSourceLimiter(t *testing.T) {
+	Convey("Data source limiter", t, func() {
+		Convey("No limit should always acquire", func() {
+			limiter := newDataSourceLimiter(0)
+			So(limiter, ShouldBeNil)
+
+			release, err := limiter.acquire(context.TODO(), 1)
+			So(err, ShouldBeNil)
+			release()
+		})
+
+		Convey("Should limit concurrent queries per data source", func() {
+			limiter := newDataSourceLimiter(1)
+
+			release, err := limiter.acquire(context.TODO(), 1)
+			So(err, ShouldBeNil)
+
+			Convey("Other data sources are not affected", func() {
+				releaseOther, err := limiter.acquire(context.TODO(), 2)
+				So(err, ShouldBeNil)
+				releaseOther()
+			})
+
+			Convey("Should wait until slot is released", func() {
+				ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*10)
+				defer cancel()
+
+				_, err := limiter.acquire(ctx, 1)
+				So(err, ShouldNotBeNil)
+
+				release()
+				release, err = limiter.acquire(context.TODO(), 1)
+				So(err, ShouldBeNil)
+				release()
+			})
+		})
+	})
+}
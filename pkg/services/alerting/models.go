@@ -1,10 +1,13 @@
 package alerting
 
+import "time"
+
 type Job struct {
 	Offset     int64
 	OffsetWait bool
 	Delay      bool
 	Running    bool
+	Queued     time.Time
 	Rule       *Rule
 }
 
@@ -3,6 +3,7 @@ package alerting
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -44,11 +45,17 @@ func (n *RootNotifier) GetIsDefault() bool {
 }
 
 func (n *RootNotifier) Notify(context *EvalContext) error {
-	notifiers, err := n.getNotifiers(context.Rule.OrgId, context.Rule.Notifications, context)
+	notifiers, resends, err := n.getNotifiers(context.Rule.OrgId, context.Rule.Notifications, context)
 	if err != nil {
 		return err
 	}
 
+	if len(resends) > 0 {
+		if err := n.resendNotifications(context, resends); err != nil {
+			n.log.Error("Failed to resend notifications", "ruleId", context.Rule.Id, "error", err)
+		}
+	}
+
 	n.log.Info("Sending notifications for", "ruleId", context.Rule.Id, "sent count", len(notifiers))
 
 	if len(notifiers) == 0 {
@@ -64,18 +71,59 @@ func (n *RootNotifier) Notify(context *EvalContext) error {
 	return n.sendNotifications(context, notifiers)
 }
 
+// sendNotifications sends each notification once. Notifications that fail
+// stay in the outbox and are sent again by the DeliveryRetrier.
 func (n *RootNotifier) sendNotifications(context *EvalContext, notifiers []Notifier) error {
 	g, _ := errgroup.WithContext(context.Ctx)
 
 	for _, notifier := range notifiers {
 		not := notifier //avoid updating scope variable in go routine
 		n.log.Info("Sending notification", "type", not.GetType(), "id", not.GetNotifierId(), "isDefault", not.GetIsDefault())
+
+		if context.IsTestRun {
+			g.Go(func() error { return not.Notify(context) })
+			continue
+		}
+
+		delivery := n.createDelivery(context, not)
+		g.Go(func() error { return n.deliver(context, not, delivery) })
+	}
+
+	return g.Wait()
+}
+
+// resendNotifications sends the alert again to notifiers that resend while
+// alerting. Resends are not rendered, stored in the outbox or history, and
+// do not count as a sent reminder.
+func (n *RootNotifier) resendNotifications(context *EvalContext, notifiers []Notifier) error {
+	g, _ := errgroup.WithContext(context.Ctx)
+
+	for _, notifier := range notifiers {
+		not := notifier //avoid updating scope variable in go routine
+		n.log.Debug("Resending notification", "type", not.GetType(), "id", not.GetNotifierId())
 		g.Go(func() error { return not.Notify(context) })
 	}
 
 	return g.Wait()
 }
 
+func (n *RootNotifier) setNotificationSent(context *EvalContext, notifier Notifier) {
+	if context.IsTestRun {
+		return
+	}
+
+	cmd := &m.SetAlertNotificationStateCommand{
+		OrgId:      context.Rule.OrgId,
+		AlertId:    context.Rule.Id,
+		NotifierId: notifier.GetNotifierId(),
+		SentAt:     time.Now(),
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		n.log.Error("Failed to save notification state", "notifierId", cmd.NotifierId, "error", err)
+	}
+}
+
 func (n *RootNotifier) uploadImage(context *EvalContext) (err error) {
 	uploader, err := imguploader.NewImageUploader()
 	if err != nil {
@@ -110,25 +158,54 @@ func (n *RootNotifier) uploadImage(context *EvalContext) (err error) {
 	return nil
 }
 
-func (n *RootNotifier) getNotifiers(orgId int64, notificationIds []int64, context *EvalContext) ([]Notifier, error) {
+// getNotifiers returns the notifiers to send the notification to, and on
+// reminder evaluations the notifiers that only resend the alert.
+func (n *RootNotifier) getNotifiers(orgId int64, notificationIds []int64, context *EvalContext) ([]Notifier, []Notifier, error) {
 	query := &m.GetAlertNotificationsToSendQuery{OrgId: orgId, Ids: notificationIds}
 
 	if err := bus.Dispatch(query); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	lastSent := make(map[int64]time.Time)
+	if context.ShouldSendReminder() {
+		statesQuery := &m.GetAlertNotificationStatesQuery{OrgId: orgId, AlertId: context.Rule.Id}
+		if err := bus.Dispatch(statesQuery); err != nil {
+			return nil, nil, err
+		}
+
+		for _, state := range statesQuery.Result {
+			lastSent[state.NotifierId] = state.SentAt
+		}
 	}
 
-	var result []Notifier
+	var result, resends []Notifier
 	for _, notification := range query.Result {
-		if not, err := n.createNotifierFor(notification); err != nil {
-			return nil, err
-		} else {
-			if shouldUseNotification(not, context) {
-				result = append(result, not)
+		not, err := n.createNotifierFor(notification)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !shouldUseNotification(not, context) {
+			continue
+		}
+
+		if context.ShouldSendReminder() && !shouldSendReminder(notification, lastSent[notification.Id], context.StartTime) {
+			if resendsWhileAlerting(not) {
+				resends = append(resends, not)
 			}
+			continue
 		}
+
+		result = append(result, not)
 	}
 
-	return result, nil
+	return result, resends, nil
+}
+
+func resendsWhileAlerting(not Notifier) bool {
+	resending, ok := not.(ResendingNotifier)
+	return ok && resending.ResendsWhileAlerting()
 }
 
 func (n *RootNotifier) createNotifierFor(model *m.AlertNotification) (Notifier, error) {
@@ -140,16 +217,19 @@ func (n *RootNotifier) createNotifierFor(model *m.AlertNotification) (Notifier,
 	return factory(model)
 }
 
+// shouldUseNotification applies the notifier filters to every notification
+// of a rule, so a channel that received an alert also gets its recovery and
+// channels filtering out a rule never hear from it.
 func shouldUseNotification(notifier Notifier, context *EvalContext) bool {
-	if !context.Firing {
-		return true
-	}
+	return notifier.PassesFilter(context.Rule)
+}
 
-	if context.Error != nil {
-		return true
+func shouldSendReminder(notification *m.AlertNotification, lastSent time.Time, now time.Time) bool {
+	if !notification.SendReminder || notification.Frequency <= 0 {
+		return false
 	}
 
-	return notifier.PassesFilter(context.Rule)
+	return now.Sub(lastSent) >= notification.Frequency
 }
 
 type NotifierFactory func(notification *m.AlertNotification) (Notifier, error)
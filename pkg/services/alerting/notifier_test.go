@@ -1,10 +1,13 @@
 package alerting
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"fmt"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	m "github.com/grafana/grafana/pkg/models"
 	. "github.com/smartystreets/goconvey/convey"
@@ -36,32 +39,58 @@ func (fn *FakeNotifier) PassesFilter(rule *Rule) bool {
 	return fn.FakeMatchResult
 }
 
+type resendingNotifierStub struct {
+	FakeNotifier
+	sent int
+}
+
+func (n *resendingNotifierStub) Notify(evalContext *EvalContext) error {
+	n.sent++
+	return nil
+}
+
+func (n *resendingNotifierStub) ResendsWhileAlerting() bool {
+	return true
+}
+
 func TestAlertNotificationExtraction(t *testing.T) {
 
 	Convey("Notifier tests", t, func() {
-		Convey("none firing alerts", func() {
+		Convey("none firing alerts that match", func() {
 			ctx := &EvalContext{
 				Firing: false,
 				Rule: &Rule{
-					State: m.AlertStateAlerting,
+					State: m.AlertStateOK,
 				},
 			}
-			notifier := &FakeNotifier{FakeMatchResult: false}
+			notifier := &FakeNotifier{FakeMatchResult: true}
 
 			So(shouldUseNotification(notifier, ctx), ShouldBeTrue)
 		})
 
-		Convey("execution error cannot be ignored", func() {
+		Convey("none firing alerts that dont match", func() {
+			ctx := &EvalContext{
+				Firing: false,
+				Rule: &Rule{
+					State: m.AlertStateOK,
+				},
+			}
+			notifier := &FakeNotifier{FakeMatchResult: false}
+
+			So(shouldUseNotification(notifier, ctx), ShouldBeFalse)
+		})
+
+		Convey("execution error that dont match", func() {
 			ctx := &EvalContext{
 				Firing: true,
 				Error:  fmt.Errorf("I used to be a programmer just like you"),
 				Rule: &Rule{
-					State: m.AlertStateOK,
+					State: m.AlertStateAlerting,
 				},
 			}
 			notifier := &FakeNotifier{FakeMatchResult: false}
 
-			So(shouldUseNotification(notifier, ctx), ShouldBeTrue)
+			So(shouldUseNotification(notifier, ctx), ShouldBeFalse)
 		})
 
 		Convey("firing alert that match", func() {
@@ -85,5 +114,74 @@ func TestAlertNotificationExtraction(t *testing.T) {
 
 			So(shouldUseNotification(notifier, ctx), ShouldBeFalse)
 		})
+
+		Convey("reminders", func() {
+			now := time.Now()
+			notification := &m.AlertNotification{SendReminder: true, Frequency: time.Minute * 10}
+
+			Convey("should not be sent when disabled", func() {
+				notification.SendReminder = false
+				So(shouldSendReminder(notification, time.Time{}, now), ShouldBeFalse)
+			})
+
+			Convey("should be sent when never sent before", func() {
+				So(shouldSendReminder(notification, time.Time{}, now), ShouldBeTrue)
+			})
+
+			Convey("should not be sent before frequency has passed", func() {
+				So(shouldSendReminder(notification, now.Add(-time.Minute*9), now), ShouldBeFalse)
+			})
+
+			Convey("should be sent when frequency has passed", func() {
+				So(shouldSendReminder(notification, now.Add(-time.Minute*10), now), ShouldBeTrue)
+			})
+		})
+
+		Convey("reminder evaluations", func() {
+			bus.ClearBusHandlers()
+
+			bus.AddHandler("test", func(query *m.GetAlertNotificationsToSendQuery) error {
+				query.Result = []*m.AlertNotification{
+					{Id: 1, OrgId: 1, Type: "fake_stub"},
+					{Id: 2, OrgId: 1, Type: "resending_stub"},
+				}
+				return nil
+			})
+
+			bus.AddHandler("test", func(query *m.GetAlertNotificationStatesQuery) error {
+				return nil
+			})
+
+			RegisterNotifier("fake_stub", func(model *m.AlertNotification) (Notifier, error) {
+				return &FakeNotifier{FakeMatchResult: true}, nil
+			})
+			resending := &resendingNotifierStub{FakeNotifier: FakeNotifier{FakeMatchResult: true}}
+			RegisterNotifier("resending_stub", func(model *m.AlertNotification) (Notifier, error) {
+				return resending, nil
+			})
+
+			deliveries := 0
+			bus.AddHandler("test", func(cmd *m.CreateAlertNotificationDeliveryCommand) error {
+				deliveries++
+				return nil
+			})
+
+			evalContext := NewEvalContext(context.TODO(), &Rule{Id: 1, OrgId: 1, State: m.AlertStateAlerting})
+
+			Convey("should only resend to notifiers resending while alerting when reminders are disabled", func() {
+				notifiers, resends, err := NewRootNotifier().getNotifiers(1, []int64{1, 2}, evalContext)
+				So(err, ShouldBeNil)
+				So(len(notifiers), ShouldEqual, 0)
+				So(len(resends), ShouldEqual, 1)
+				So(resendsWhileAlerting(resends[0]), ShouldBeTrue)
+			})
+
+			Convey("should resend without image or delivery", func() {
+				So(NewRootNotifier().Notify(evalContext), ShouldBeNil)
+				So(resending.sent, ShouldEqual, 1)
+				So(deliveries, ShouldEqual, 0)
+				So(evalContext.ImagePublicUrl, ShouldEqual, "")
+			})
+		})
 	})
 }
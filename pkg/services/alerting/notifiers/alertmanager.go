@@ -0,0 +1,142 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// alertmanagerMissedEvaluations is the number of evaluations the alert stays
+// firing in the Alertmanager without being sent again.
+const alertmanagerMissedEvaluations = 3
+
+func init() {
+	alerting.RegisterNotifier("prometheus-alertmanager", NewAlertmanagerNotifier)
+}
+
+func NewAlertmanagerNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
+	url := model.Settings.Get("url").MustString()
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+
+	return &AlertmanagerNotifier{
+		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
+		Url:          strings.TrimSuffix(url, "/"),
+		User:         model.Settings.Get("username").MustString(),
+		Password:     model.Settings.Get("password").MustString(),
+		log:          log.New("alerting.notifier.prometheus-alertmanager"),
+	}, nil
+}
+
+// AlertmanagerNotifier forwards the rule as a single alert to the api of a
+// Prometheus Alertmanager, which then takes care of grouping, silencing and
+// routing it. The alert is sent again on every evaluation while the rule is
+// alerting and is resolved when the rule goes back to ok.
+type AlertmanagerNotifier struct {
+	NotifierBase
+	Url      string
+	User     string
+	Password string
+	log      log.Logger
+}
+
+// ResendsWhileAlerting keeps the alert firing, the Alertmanager resolves
+// alerts that are not sent again before they end.
+func (this *AlertmanagerNotifier) ResendsWhileAlerting() bool {
+	return true
+}
+
+func (this *AlertmanagerNotifier) Notify(evalContext *alerting.EvalContext) error {
+	this.log.Info("Sending alertmanager alert", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+	metrics.M_Alerting_Notification_Sent_Alertmanager.Inc(1)
+
+	alert := this.createAlert(evalContext, time.Now())
+
+	data, _ := json.Marshal([]interface{}{alert})
+	cmd := &m.SendWebhookSync{
+		Url:        this.Url + "/api/v1/alerts",
+		User:       this.User,
+		Password:   this.Password,
+		Body:       string(data),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send alertmanager alert", "error", err, "alertmanager", this.Name)
+		return err
+	}
+
+	return nil
+}
+
+// createAlert identifies the alert by the rule id, name, severity and labels
+// so the alert that resolves it has the same labels as the one that fired.
+// A firing alert ends a few evaluations later unless it is sent again.
+func (this *AlertmanagerNotifier) createAlert(evalContext *alerting.EvalContext, now time.Time) map[string]interface{} {
+	labels := map[string]string{
+		"alertname": evalContext.Rule.Name,
+		"rule_id":   strconv.FormatInt(evalContext.Rule.Id, 10),
+	}
+	if evalContext.Rule.Severity != "" {
+		labels["severity"] = string(evalContext.Rule.Severity)
+	}
+	for name, value := range evalContext.Rule.Labels {
+		labels[name] = value
+	}
+
+	annotations := map[string]string{
+		"summary": this.GetTitle(evalContext),
+	}
+
+	if evalContext.Rule.State != m.AlertStateOK {
+		annotations["description"] = this.GetMessage(evalContext)
+
+		matches := make([]string, 0)
+		for _, evt := range evalContext.EvalMatches {
+			matches = append(matches, fmt.Sprintf("%s=%v", evt.Metric, evt.Value))
+		}
+		if len(matches) > 0 {
+			annotations["metrics"] = strings.Join(matches, ", ")
+		}
+	}
+
+	if evalContext.Error != nil {
+		annotations["error"] = evalContext.Error.Error()
+	}
+
+	if evalContext.ImagePublicUrl != "" {
+		annotations["image"] = evalContext.ImagePublicUrl
+	}
+
+	alert := map[string]interface{}{
+		"labels":      labels,
+		"annotations": annotations,
+		"startsAt":    now.Format(time.RFC3339),
+	}
+
+	if ruleUrl, err := evalContext.GetRuleUrl(); err == nil {
+		alert["generatorURL"] = ruleUrl
+	}
+
+	endsAt := now
+	if evalContext.Rule.State != m.AlertStateOK {
+		frequency := time.Duration(evalContext.Rule.Frequency) * time.Second
+		if frequency <= 0 {
+			frequency = time.Minute
+		}
+		endsAt = now.Add(alertmanagerMissedEvaluations * frequency)
+	}
+	alert["endsAt"] = endsAt.Format(time.RFC3339)
+
+	return alert
+}
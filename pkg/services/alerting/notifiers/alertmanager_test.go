@@ -0,0 +1,108 @@
+package notifiers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertmanagerNotifier(t *testing.T) {
+	Convey("Alertmanager notifier tests", t, func() {
+
+		Convey("Parsing alert notification from settings", func() {
+			Convey("empty settings should return error", func() {
+				settingsJSON, _ := simplejson.NewJson([]byte(`{ }`))
+				model := &m.AlertNotification{
+					Name:     "ops",
+					Type:     "prometheus-alertmanager",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewAlertmanagerNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("from settings", func() {
+				settingsJSON, _ := simplejson.NewJson([]byte(`{"url": "http://alertmanager:9093/", "username": "admin"}`))
+				model := &m.AlertNotification{
+					Name:     "ops",
+					Type:     "prometheus-alertmanager",
+					Settings: settingsJSON,
+				}
+
+				not, err := NewAlertmanagerNotifier(model)
+				So(err, ShouldBeNil)
+
+				alertmanagerNotifier := not.(*AlertmanagerNotifier)
+				So(alertmanagerNotifier.Name, ShouldEqual, "ops")
+				So(alertmanagerNotifier.Url, ShouldEqual, "http://alertmanager:9093")
+				So(alertmanagerNotifier.User, ShouldEqual, "admin")
+			})
+		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(200)
+			defer server.Close()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("url", server.URL)
+
+			not, err := NewAlertmanagerNotifier(&m.AlertNotification{Name: "ops", Type: "prometheus-alertmanager", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("posts firing alert with rule labels", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.Rule.Severity = m.AlertSeverityWarning
+				evalContext.Rule.Labels = map[string]string{"team": "backend"}
+				evalContext.Rule.Frequency = 60
+
+				So(not.Notify(evalContext), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 1)
+				So((*requests)[0].Url, ShouldEqual, "/api/v1/alerts")
+
+				alert := (*requests)[0].Json().GetIndex(0)
+				So(alert.GetPath("labels", "alertname").MustString(), ShouldEqual, "cpu usage")
+				So(alert.GetPath("labels", "rule_id").MustString(), ShouldEqual, "10")
+				So(alert.GetPath("labels", "severity").MustString(), ShouldEqual, "warning")
+				So(alert.GetPath("labels", "team").MustString(), ShouldEqual, "backend")
+				So(alert.GetPath("annotations", "description").MustString(), ShouldEqual, "cpu is high")
+				So(alert.GetPath("annotations", "metrics").MustString(), ShouldEqual, "server1=95")
+
+				startsAt, _ := time.Parse(time.RFC3339, alert.Get("startsAt").MustString())
+				endsAt, _ := time.Parse(time.RFC3339, alert.Get("endsAt").MustString())
+				So(endsAt.Sub(startsAt), ShouldEqual, 3*time.Minute)
+			})
+
+			Convey("is sent again on every evaluation while alerting", func() {
+				So(not.(alerting.ResendingNotifier).ResendsWhileAlerting(), ShouldBeTrue)
+			})
+
+			Convey("resolves alert when rule is ok", func() {
+				evalContext := newTestEvalContext(m.AlertStateOK)
+				evalContext.Error = errors.New("timeout")
+
+				So(not.Notify(evalContext), ShouldBeNil)
+
+				alert := (*requests)[0].Json().GetIndex(0)
+				So(alert.Get("endsAt").MustString(), ShouldEqual, alert.Get("startsAt").MustString())
+				So(alert.GetPath("annotations", "error").MustString(), ShouldEqual, "timeout")
+				_, hasDescription := alert.Get("annotations").CheckGet("description")
+				So(hasDescription, ShouldBeFalse)
+			})
+
+			Convey("returns error when alertmanager fails", func() {
+				failing, _ := newReceiverServer(400)
+				defer failing.Close()
+				settingsJSON.Set("url", failing.URL)
+				not, _ := NewAlertmanagerNotifier(&m.AlertNotification{Name: "ops", Type: "prometheus-alertmanager", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateAlerting)), ShouldNotBeNil)
+			})
+		})
+	})
+}
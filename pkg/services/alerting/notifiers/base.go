@@ -1,28 +1,95 @@
 package notifiers
 
 import (
+	"strings"
+
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	m "github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 )
 
 type NotifierBase struct {
-	Name     string
-	Type     string
-	Id       int64
-	IsDeault bool
+	Name           string
+	Type           string
+	Id             int64
+	IsDeault       bool
+	TitleTemplate  string
+	BodyTemplate   string
+	SeverityFilter []m.AlertSeverityType
+	LabelFilter    map[string]string
 }
 
 func NewNotifierBase(id int64, isDefault bool, name, notifierType string, model *simplejson.Json) NotifierBase {
+	severityFilter := make([]m.AlertSeverityType, 0)
+	for _, severity := range strings.Split(model.Get("severityFilter").MustString(), ",") {
+		if severity = strings.TrimSpace(severity); severity != "" {
+			severityFilter = append(severityFilter, m.AlertSeverityType(severity))
+		}
+	}
+
 	return NotifierBase{
-		Id:       id,
-		Name:     name,
-		IsDeault: isDefault,
-		Type:     notifierType,
+		Id:             id,
+		Name:           name,
+		IsDeault:       isDefault,
+		Type:           notifierType,
+		TitleTemplate:  model.Get("titleTemplate").MustString(),
+		BodyTemplate:   model.Get("bodyTemplate").MustString(),
+		SeverityFilter: severityFilter,
+		LabelFilter:    m.ParseAlertLabelFilters(strings.Split(model.Get("labelFilter").MustString(), ",")),
+	}
+}
+
+// GetTitle returns the notification title, rendered from the title
+// template when the notifier overrides it.
+func (n *NotifierBase) GetTitle(evalContext *alerting.EvalContext) string {
+	if n.TitleTemplate == "" {
+		return evalContext.GetNotificationTitle()
+	}
+
+	title, err := evalContext.RenderTemplate(n.TitleTemplate)
+	if err != nil {
+		log.Error2("Failed to render notification title", "notifier", n.Name, "error", err)
+		return evalContext.GetNotificationTitle()
 	}
+
+	return title
 }
 
+// GetMessage returns the notification body, rendered from the body
+// template when the notifier overrides it and the rule message otherwise.
+func (n *NotifierBase) GetMessage(evalContext *alerting.EvalContext) string {
+	if n.BodyTemplate == "" {
+		return evalContext.GetNotificationMessage()
+	}
+
+	message, err := evalContext.RenderTemplate(n.BodyTemplate)
+	if err != nil {
+		log.Error2("Failed to render notification body", "notifier", n.Name, "error", err)
+		return evalContext.GetNotificationMessage()
+	}
+
+	return message
+}
+
+// PassesFilter returns true when the rule has one of the severities and
+// all of the labels the notifier is filtered on.
 func (n *NotifierBase) PassesFilter(rule *alerting.Rule) bool {
-	return true
+	if len(n.SeverityFilter) > 0 {
+		found := false
+		for _, severity := range n.SeverityFilter {
+			if severity == rule.Severity {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return m.MatchAlertLabels(n.LabelFilter, rule.Labels)
 }
 
 func (n *NotifierBase) GetType() string {
@@ -0,0 +1,118 @@
+package notifiers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type receivedRequest struct {
+	Method string
+	Url    string
+	Header http.Header
+	Body   []byte
+}
+
+func (r *receivedRequest) Json() *simplejson.Json {
+	json, _ := simplejson.NewJson(r.Body)
+	return json
+}
+
+// newReceiverServer starts a server that records the requests notifiers
+// send and answers with the given status code. Webhooks are sent through
+// the real notification service.
+func newReceiverServer(status int) (*httptest.Server, *[]*receivedRequest) {
+	requests := make([]*receivedRequest, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, &receivedRequest{
+			Method: r.Method,
+			Url:    r.URL.String(),
+			Header: r.Header,
+			Body:   body,
+		})
+		w.WriteHeader(status)
+	}))
+
+	bus.ClearBusHandlers()
+	bus.AddCtxHandler("test", notifications.SendWebhookSync)
+
+	return server, &requests
+}
+
+func newTestEvalContext(state m.AlertStateType) *alerting.EvalContext {
+	evalContext := alerting.NewEvalContext(context.TODO(), &alerting.Rule{
+		Id:      10,
+		Name:    "cpu usage",
+		Message: "cpu is high",
+		State:   state,
+	})
+	evalContext.IsTestRun = true
+	evalContext.EvalMatches = []*alerting.EvalMatch{{Metric: "server1", Value: 95}}
+
+	return evalContext
+}
+
+func TestNotifierBase(t *testing.T) {
+	Convey("Notifier base", t, func() {
+		settings := simplejson.New()
+		evalContext := newTestEvalContext(m.AlertStateAlerting)
+
+		Convey("uses default title and rule message", func() {
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.GetTitle(evalContext), ShouldEqual, "[Alerting] cpu usage")
+			So(base.GetMessage(evalContext), ShouldEqual, "cpu is high")
+		})
+
+		Convey("renders title and body templates", func() {
+			settings.Set("titleTemplate", "{{.Rule.Name}} is {{.State}}")
+			settings.Set("bodyTemplate", "{{.Message}}:{{range .EvalMatches}} {{.Metric}}={{.Value}}{{end}}")
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.GetTitle(evalContext), ShouldEqual, "cpu usage is alerting")
+			So(base.GetMessage(evalContext), ShouldEqual, "cpu is high: server1=95")
+		})
+
+		Convey("falls back to defaults when templates fail to render", func() {
+			settings.Set("titleTemplate", "{{.Unknown}}")
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.GetTitle(evalContext), ShouldEqual, "[Alerting] cpu usage")
+		})
+
+		Convey("passes every rule without filters", func() {
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.PassesFilter(&alerting.Rule{Severity: m.AlertSeverityInfo}), ShouldBeTrue)
+		})
+
+		Convey("filters on severity", func() {
+			settings.Set("severityFilter", "critical, error")
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.PassesFilter(&alerting.Rule{Severity: m.AlertSeverityCritical}), ShouldBeTrue)
+			So(base.PassesFilter(&alerting.Rule{Severity: m.AlertSeverityError}), ShouldBeTrue)
+			So(base.PassesFilter(&alerting.Rule{Severity: m.AlertSeverityWarning}), ShouldBeFalse)
+		})
+
+		Convey("filters on labels", func() {
+			settings.Set("labelFilter", "team=payments,env")
+			base := NewNotifierBase(1, false, "ops", "webhook", settings)
+
+			So(base.PassesFilter(&alerting.Rule{Labels: map[string]string{"team": "payments", "env": "prod"}}), ShouldBeTrue)
+			So(base.PassesFilter(&alerting.Rule{Labels: map[string]string{"team": "payments"}}), ShouldBeFalse)
+			So(base.PassesFilter(&alerting.Rule{Labels: map[string]string{"team": "ops", "env": "prod"}}), ShouldBeFalse)
+		})
+	})
+}
@@ -58,11 +58,11 @@ func (this *EmailNotifier) Notify(evalContext *alerting.EvalContext) error {
 	cmd := &m.SendEmailCommandSync{
 		SendEmailCommand: m.SendEmailCommand{
 			Data: map[string]interface{}{
-				"Title":        evalContext.GetNotificationTitle(),
+				"Title":        this.GetTitle(evalContext),
 				"State":        evalContext.Rule.State,
 				"Name":         evalContext.Rule.Name,
 				"StateModel":   evalContext.GetStateModel(),
-				"Message":      evalContext.Rule.Message,
+				"Message":      this.GetMessage(evalContext),
 				"RuleUrl":      ruleUrl,
 				"ImageLink":    "",
 				"EmbededImage": "",
@@ -89,6 +89,7 @@ func (this *EmailNotifier) Notify(evalContext *alerting.EvalContext) error {
 
 	if err != nil {
 		this.log.Error("Failed to send alert notification email", "error", err)
+		return err
 	}
 	return nil
 
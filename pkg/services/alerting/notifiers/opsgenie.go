@@ -0,0 +1,142 @@
+package notifiers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier("opsgenie", NewOpsGenieNotifier)
+}
+
+var (
+	opsgenieAlertApiUrl string = "https://api.opsgenie.com/v2/alerts"
+)
+
+func NewOpsGenieNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
+	autoClose := model.Settings.Get("autoClose").MustBool(true)
+	apiKey := model.Settings.Get("apiKey").MustString()
+	if apiKey == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find api key property in settings"}
+	}
+
+	return &OpsGenieNotifier{
+		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
+		ApiKey:       apiKey,
+		ApiUrl:       strings.TrimSuffix(model.Settings.Get("apiUrl").MustString(opsgenieAlertApiUrl), "/"),
+		AutoClose:    autoClose,
+		log:          log.New("alerting.notifier.opsgenie"),
+	}, nil
+}
+
+type OpsGenieNotifier struct {
+	NotifierBase
+	ApiKey    string
+	ApiUrl    string
+	AutoClose bool
+	log       log.Logger
+}
+
+func (this *OpsGenieNotifier) Notify(evalContext *alerting.EvalContext) error {
+	metrics.M_Alerting_Notification_Sent_OpsGenie.Inc(1)
+
+	if evalContext.Rule.State == m.AlertStateOK {
+		if !this.AutoClose {
+			this.log.Info("Not closing OpsGenie alert", "state", evalContext.Rule.State, "auto close", this.AutoClose)
+			return nil
+		}
+
+		return this.closeAlert(evalContext)
+	}
+
+	return this.createAlert(evalContext)
+}
+
+// getOpsGenieAlias returns the alias OpsGenie uses to deduplicate alerts, so
+// repeated notifications and the close request refer to the same alert.
+func getOpsGenieAlias(evalContext *alerting.EvalContext) string {
+	return "alertId-" + strconv.FormatInt(evalContext.Rule.Id, 10)
+}
+
+func (this *OpsGenieNotifier) createAlert(evalContext *alerting.EvalContext) error {
+	this.log.Info("Creating OpsGenie alert", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+
+	ruleUrl, err := evalContext.GetRuleUrl()
+	if err != nil {
+		this.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	details := simplejson.New()
+	details.Set("url", ruleUrl)
+	if evalContext.ImagePublicUrl != "" {
+		details.Set("image", evalContext.ImagePublicUrl)
+	}
+
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("message", this.GetTitle(evalContext))
+	bodyJSON.Set("alias", getOpsGenieAlias(evalContext))
+	bodyJSON.Set("description", fmt.Sprintf("%s - %s\n%s", evalContext.Rule.Name, ruleUrl, this.GetMessage(evalContext)))
+	bodyJSON.Set("details", details)
+	bodyJSON.Set("source", "Grafana")
+	bodyJSON.Set("tags", getOpsGenieTags(evalContext))
+
+	return this.send(evalContext, this.ApiUrl, bodyJSON)
+}
+
+func (this *OpsGenieNotifier) closeAlert(evalContext *alerting.EvalContext) error {
+	this.log.Info("Closing OpsGenie alert", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("source", "Grafana")
+
+	url := fmt.Sprintf("%s/%s/close?identifierType=alias", this.ApiUrl, getOpsGenieAlias(evalContext))
+	return this.send(evalContext, url, bodyJSON)
+}
+
+func (this *OpsGenieNotifier) send(evalContext *alerting.EvalContext, url string, bodyJSON *simplejson.Json) error {
+	body, _ := bodyJSON.MarshalJSON()
+
+	cmd := &m.SendWebhookSync{
+		Url:        url,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "GenieKey " + this.ApiKey,
+		},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send notification to OpsGenie", "error", err, "body", string(body))
+		return err
+	}
+
+	return nil
+}
+
+// getOpsGenieTags returns the rule severity and labels as "key:value"
+// tags, sorted so the same rule always gets the same tags.
+func getOpsGenieTags(evalContext *alerting.EvalContext) []string {
+	tags := make([]string, 0)
+	if evalContext.Rule.Severity != "" {
+		tags = append(tags, "severity:"+string(evalContext.Rule.Severity))
+	}
+
+	labels := make([]string, 0)
+	for key, value := range evalContext.Rule.Labels {
+		labels = append(labels, key+":"+value)
+	}
+	sort.Strings(labels)
+
+	return append(tags, labels...)
+}
@@ -0,0 +1,109 @@
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOpsGenieNotifier(t *testing.T) {
+	Convey("OpsGenie notifier tests", t, func() {
+
+		Convey("Parsing alert notification from settings", func() {
+			Convey("empty settings should return error", func() {
+				json := `{ }`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "opsgenie_testing",
+					Type:     "opsgenie",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewOpsGenieNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("settings should trigger incident", func() {
+				json := `
+				{
+          "apiKey": "abcdefgh0123456789"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "opsgenie_testing",
+					Type:     "opsgenie",
+					Settings: settingsJSON,
+				}
+
+				not, err := NewOpsGenieNotifier(model)
+				opsgenieNotifier := not.(*OpsGenieNotifier)
+
+				So(err, ShouldBeNil)
+				So(opsgenieNotifier.Name, ShouldEqual, "opsgenie_testing")
+				So(opsgenieNotifier.Type, ShouldEqual, "opsgenie")
+				So(opsgenieNotifier.ApiKey, ShouldEqual, "abcdefgh0123456789")
+				So(opsgenieNotifier.ApiUrl, ShouldEqual, "https://api.opsgenie.com/v2/alerts")
+				So(opsgenieNotifier.AutoClose, ShouldBeTrue)
+			})
+		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(202)
+			defer server.Close()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("apiKey", "abcdefgh0123456789")
+			settingsJSON.Set("apiUrl", server.URL+"/v2/alerts/")
+
+			not, err := NewOpsGenieNotifier(&m.AlertNotification{Name: "ops", Type: "opsgenie", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("creates alert with alias", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.Rule.Severity = m.AlertSeverityCritical
+				evalContext.Rule.Labels = map[string]string{"team": "payments", "env": "prod"}
+
+				err := not.Notify(evalContext)
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				req := (*requests)[0]
+				So(req.Method, ShouldEqual, "POST")
+				So(req.Url, ShouldEqual, "/v2/alerts")
+				So(req.Header.Get("Authorization"), ShouldEqual, "GenieKey abcdefgh0123456789")
+				So(req.Json().Get("alias").MustString(), ShouldEqual, "alertId-10")
+				So(req.Json().Get("message").MustString(), ShouldEqual, "[Alerting] cpu usage")
+				So(req.Json().Get("tags").MustStringArray(), ShouldResemble, []string{"severity:critical", "env:prod", "team:payments"})
+			})
+
+			Convey("closes alert by alias when ok", func() {
+				err := not.Notify(newTestEvalContext(m.AlertStateOK))
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				So((*requests)[0].Url, ShouldEqual, "/v2/alerts/alertId-10/close?identifierType=alias")
+			})
+
+			Convey("does not close alert when auto close is disabled", func() {
+				settingsJSON.Set("autoClose", false)
+				not, _ := NewOpsGenieNotifier(&m.AlertNotification{Name: "ops", Type: "opsgenie", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateOK)), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 0)
+			})
+
+			Convey("returns error when OpsGenie fails", func() {
+				failing, _ := newReceiverServer(500)
+				defer failing.Close()
+				settingsJSON.Set("apiUrl", failing.URL)
+				not, _ := NewOpsGenieNotifier(&m.AlertNotification{Name: "ops", Type: "opsgenie", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateAlerting)), ShouldNotBeNil)
+			})
+		})
+	})
+}
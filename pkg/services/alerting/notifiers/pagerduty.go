@@ -2,6 +2,8 @@ package notifiers
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -16,7 +18,11 @@ func init() {
 }
 
 var (
-	pagerdutyEventApiUrl string = "https://events.pagerduty.com/generic/2010-04-15/create_event.json"
+	pagerdutyEventApiUrl   string = "https://events.pagerduty.com/generic/2010-04-15/create_event.json"
+	pagerdutyEventV2ApiUrl string = "https://events.pagerduty.com/v2/enqueue"
+
+	// pagerduty rejects summaries longer than this
+	pagerdutySummaryLimit int = 1024
 )
 
 func NewPagerdutyNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
@@ -26,10 +32,16 @@ func NewPagerdutyNotifier(model *m.AlertNotification) (alerting.Notifier, error)
 		return nil, alerting.ValidationError{Reason: "Could not find integration key property in settings"}
 	}
 
+	apiVersion := model.Settings.Get("apiVersion").MustString("v1")
+	if apiVersion != "v1" && apiVersion != "v2" {
+		return nil, alerting.ValidationError{Reason: "Unsupported Events API version " + apiVersion}
+	}
+
 	return &PagerdutyNotifier{
 		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
 		Key:          key,
 		AutoResolve:  autoResolve,
+		ApiVersion:   apiVersion,
 		log:          log.New("alerting.notifier.pagerduty"),
 	}, nil
 }
@@ -38,36 +50,87 @@ type PagerdutyNotifier struct {
 	NotifierBase
 	Key         string
 	AutoResolve bool
+	ApiVersion  string
 	log         log.Logger
 }
 
+// getPagerdutyEventType returns trigger while the rule is alerting and
+// resolve on every transition out of alerting. Other notifications, like
+// no data for a rule that was ok, trigger an incident as well.
+func getPagerdutyEventType(evalContext *alerting.EvalContext) string {
+	state := evalContext.Rule.State
+	if state == m.AlertStateOK {
+		return "resolve"
+	}
+
+	if state != m.AlertStateAlerting && evalContext.PrevAlertState == m.AlertStateAlerting {
+		return "resolve"
+	}
+
+	return "trigger"
+}
+
+func getPagerdutyDedupKey(evalContext *alerting.EvalContext) string {
+	return "alertId-" + strconv.FormatInt(evalContext.Rule.Id, 10)
+}
+
 func (this *PagerdutyNotifier) Notify(evalContext *alerting.EvalContext) error {
 	metrics.M_Alerting_Notification_Sent_PagerDuty.Inc(1)
 
-	if evalContext.Rule.State == m.AlertStateOK && !this.AutoResolve {
+	eventType := getPagerdutyEventType(evalContext)
+	if eventType == "resolve" && !this.AutoResolve {
 		this.log.Info("Not sending a trigger to Pagerduty", "state", evalContext.Rule.State, "auto resolve", this.AutoResolve)
 		return nil
 	}
 
-	eventType := "trigger"
-	if evalContext.Rule.State == m.AlertStateOK {
-		eventType = "resolve"
+	this.log.Info("Notifying Pagerduty", "event_type", eventType, "api_version", this.ApiVersion)
+
+	ruleUrl, err := evalContext.GetRuleUrl()
+	if err != nil {
+		this.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	url := pagerdutyEventApiUrl
+	bodyJSON := this.buildEventV1(evalContext, eventType, ruleUrl)
+	if this.ApiVersion == "v2" {
+		url = pagerdutyEventV2ApiUrl
+		bodyJSON = this.buildEventV2(evalContext, eventType, ruleUrl)
+	}
+
+	body, _ := bodyJSON.MarshalJSON()
+
+	cmd := &m.SendWebhookSync{
+		Url:        url,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send notification to Pagerduty", "error", err, "body", string(body))
+		return err
+	}
+
+	return nil
+}
+
+func (this *PagerdutyNotifier) getSummary(evalContext *alerting.EvalContext) string {
+	title := evalContext.Rule.Name
+	if this.TitleTemplate != "" {
+		title = this.GetTitle(evalContext)
 	}
 
-	this.log.Info("Notifying Pagerduty", "event_type", eventType)
+	return truncate(title+" - "+this.GetMessage(evalContext), pagerdutySummaryLimit)
+}
 
+func (this *PagerdutyNotifier) buildEventV1(evalContext *alerting.EvalContext, eventType string, ruleUrl string) *simplejson.Json {
 	bodyJSON := simplejson.New()
 	bodyJSON.Set("service_key", this.Key)
-	bodyJSON.Set("description", evalContext.Rule.Name+" - "+evalContext.Rule.Message)
+	bodyJSON.Set("description", this.getSummary(evalContext))
 	bodyJSON.Set("client", "Grafana")
 	bodyJSON.Set("event_type", eventType)
-	bodyJSON.Set("incident_key", "alertId-"+strconv.FormatInt(evalContext.Rule.Id, 10))
-
-	ruleUrl, err := evalContext.GetRuleUrl()
-	if err != nil {
-		this.log.Error("Failed get rule link", "error", err)
-		return err
-	}
+	bodyJSON.Set("incident_key", getPagerdutyDedupKey(evalContext))
 	bodyJSON.Set("client_url", ruleUrl)
 
 	if evalContext.ImagePublicUrl != "" {
@@ -79,17 +142,81 @@ func (this *PagerdutyNotifier) Notify(evalContext *alerting.EvalContext) error {
 		bodyJSON.Set("contexts", contexts)
 	}
 
-	body, _ := bodyJSON.MarshalJSON()
+	return bodyJSON
+}
 
-	cmd := &m.SendWebhookSync{
-		Url:        pagerdutyEventApiUrl,
-		Body:       string(body),
-		HttpMethod: "POST",
+// buildEventV2 builds an Events API v2 event. The component is the
+// dashboard, the group its tags and the class the rule name.
+func (this *PagerdutyNotifier) buildEventV2(evalContext *alerting.EvalContext, eventType string, ruleUrl string) *simplejson.Json {
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("routing_key", this.Key)
+	bodyJSON.Set("event_action", eventType)
+	bodyJSON.Set("dedup_key", getPagerdutyDedupKey(evalContext))
+	bodyJSON.Set("client", "Grafana")
+	bodyJSON.Set("client_url", ruleUrl)
+
+	if eventType == "resolve" {
+		return bodyJSON
 	}
 
-	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
-		this.log.Error("Failed to send notification to Pagerduty", "error", err, "body", string(body))
+	payload := simplejson.New()
+	payload.Set("summary", this.getSummary(evalContext))
+	payload.Set("source", "Grafana")
+	payload.Set("severity", getPagerdutySeverity(evalContext.Rule.Severity))
+	payload.Set("timestamp", evalContext.StartTime.Format(time.RFC3339))
+	payload.Set("class", evalContext.Rule.Name)
+
+	if slug, err := evalContext.GetDashboardSlug(); err == nil {
+		payload.Set("component", slug)
+	} else {
+		this.log.Warn("Failed to get dashboard for pagerduty component", "error", err)
 	}
 
-	return nil
+	if tags, err := evalContext.GetDashboardTags(); err == nil {
+		if len(tags) > 0 {
+			payload.Set("group", strings.Join(tags, ","))
+		}
+	} else {
+		this.log.Warn("Failed to get dashboard tags for pagerduty group", "error", err)
+	}
+
+	details := simplejson.New()
+	details.Set("state", evalContext.Rule.State)
+	details.Set("evalMatches", evalContext.EvalMatches)
+	if len(evalContext.Rule.Labels) > 0 {
+		details.Set("labels", evalContext.Rule.Labels)
+	}
+	if evalContext.Error != nil {
+		details.Set("error", evalContext.Error.Error())
+	}
+	payload.Set("custom_details", details)
+
+	bodyJSON.Set("payload", payload)
+
+	links := make([]interface{}, 1)
+	linkJSON := simplejson.New()
+	linkJSON.Set("href", ruleUrl)
+	linkJSON.Set("text", "View Rule")
+	links[0] = linkJSON
+	bodyJSON.Set("links", links)
+
+	if evalContext.ImagePublicUrl != "" {
+		images := make([]interface{}, 1)
+		imageJSON := simplejson.New()
+		imageJSON.Set("src", evalContext.ImagePublicUrl)
+		images[0] = imageJSON
+		bodyJSON.Set("images", images)
+	}
+
+	return bodyJSON
+}
+
+// getPagerdutySeverity maps the rule severity to one of the severities
+// PagerDuty accepts, which use the same names.
+func getPagerdutySeverity(severity m.AlertSeverityType) string {
+	if !severity.IsValid() {
+		return string(m.AlertSeverityCritical)
+	}
+
+	return string(severity)
 }
@@ -3,6 +3,7 @@ package notifiers
 import (
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	m "github.com/grafana/grafana/pkg/models"
 	. "github.com/smartystreets/goconvey/convey"
@@ -46,8 +47,139 @@ func TestPagerdutyNotifier(t *testing.T) {
 				So(pagerdutyNotifier.Name, ShouldEqual, "pagerduty_testing")
 				So(pagerdutyNotifier.Type, ShouldEqual, "pagerduty")
 				So(pagerdutyNotifier.Key, ShouldEqual, "abcdefgh0123456789")
+				So(pagerdutyNotifier.ApiVersion, ShouldEqual, "v1")
 			})
 
+			Convey("unknown api version should return error", func() {
+				json := `
+				{
+          "integrationKey": "abcdefgh0123456789",
+          "apiVersion": "v3"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "pagerduty_testing",
+					Type:     "pagerduty",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewPagerdutyNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("Event type", func() {
+			evalContext := newTestEvalContext(m.AlertStateAlerting)
+
+			Convey("should trigger when alerting", func() {
+				evalContext.PrevAlertState = m.AlertStateOK
+				So(getPagerdutyEventType(evalContext), ShouldEqual, "trigger")
+			})
+
+			Convey("should resolve when ok", func() {
+				evalContext.Rule.State = m.AlertStateOK
+				evalContext.PrevAlertState = m.AlertStateAlerting
+				So(getPagerdutyEventType(evalContext), ShouldEqual, "resolve")
+			})
+
+			Convey("should resolve when going from alerting to no data", func() {
+				evalContext.Rule.State = m.AlertStateNoData
+				evalContext.PrevAlertState = m.AlertStateAlerting
+				So(getPagerdutyEventType(evalContext), ShouldEqual, "resolve")
+			})
+
+			Convey("should trigger when going from ok to no data", func() {
+				evalContext.Rule.State = m.AlertStateNoData
+				evalContext.PrevAlertState = m.AlertStateOK
+				So(getPagerdutyEventType(evalContext), ShouldEqual, "trigger")
+			})
+		})
+
+		Convey("Sending events", func() {
+			server, requests := newReceiverServer(202)
+			defer server.Close()
+
+			bus.AddHandler("test", func(query *m.GetDashboardSlugByIdQuery) error {
+				query.Result = "servers"
+				return nil
+			})
+
+			bus.AddHandler("test", func(query *m.GetDashboardTagsByIdQuery) error {
+				query.Result = []string{"prod", "web"}
+				return nil
+			})
+
+			oldV1Url, oldV2Url := pagerdutyEventApiUrl, pagerdutyEventV2ApiUrl
+			pagerdutyEventApiUrl = server.URL + "/v1"
+			pagerdutyEventV2ApiUrl = server.URL + "/v2/enqueue"
+			defer func() { pagerdutyEventApiUrl, pagerdutyEventV2ApiUrl = oldV1Url, oldV2Url }()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("integrationKey", "abcdefgh0123456789")
+			settingsJSON.Set("apiVersion", "v2")
+
+			not, err := NewPagerdutyNotifier(&m.AlertNotification{Name: "pd", Type: "pagerduty", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("v2 trigger contains payload", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.Rule.Severity = m.AlertSeverityWarning
+				evalContext.Rule.Labels = map[string]string{"team": "payments"}
+
+				So(not.Notify(evalContext), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 1)
+
+				req := (*requests)[0]
+				So(req.Url, ShouldEqual, "/v2/enqueue")
+
+				body := req.Json()
+				So(body.Get("routing_key").MustString(), ShouldEqual, "abcdefgh0123456789")
+				So(body.Get("event_action").MustString(), ShouldEqual, "trigger")
+				So(body.Get("dedup_key").MustString(), ShouldEqual, "alertId-10")
+
+				payload := body.Get("payload")
+				So(payload.Get("summary").MustString(), ShouldEqual, "cpu usage - cpu is high")
+				So(payload.Get("severity").MustString(), ShouldEqual, "warning")
+				So(payload.Get("component").MustString(), ShouldEqual, "servers")
+				So(payload.Get("group").MustString(), ShouldEqual, "prod,web")
+				So(payload.Get("class").MustString(), ShouldEqual, "cpu usage")
+				So(payload.GetPath("custom_details", "labels", "team").MustString(), ShouldEqual, "payments")
+				So(payload.GetPath("custom_details", "evalMatches").GetIndex(0).Get("metric").MustString(), ShouldEqual, "server1")
+			})
+
+			Convey("v2 resolve uses dedup key", func() {
+				evalContext := newTestEvalContext(m.AlertStateNoData)
+				evalContext.PrevAlertState = m.AlertStateAlerting
+
+				So(not.Notify(evalContext), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 1)
+
+				body := (*requests)[0].Json()
+				So(body.Get("event_action").MustString(), ShouldEqual, "resolve")
+				So(body.Get("dedup_key").MustString(), ShouldEqual, "alertId-10")
+			})
+
+			Convey("does not resolve when auto resolve is disabled", func() {
+				settingsJSON.Set("autoResolve", false)
+				not, _ := NewPagerdutyNotifier(&m.AlertNotification{Name: "pd", Type: "pagerduty", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateOK)), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 0)
+			})
+
+			Convey("v1 uses incident key", func() {
+				settingsJSON.Set("apiVersion", "v1")
+				not, _ := NewPagerdutyNotifier(&m.AlertNotification{Name: "pd", Type: "pagerduty", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateOK)), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 1)
+
+				body := (*requests)[0].Json()
+				So((*requests)[0].Url, ShouldEqual, "/v1")
+				So(body.Get("event_type").MustString(), ShouldEqual, "resolve")
+				So(body.Get("incident_key").MustString(), ShouldEqual, "alertId-10")
+			})
 		})
 	})
 }
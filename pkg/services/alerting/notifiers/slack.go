@@ -75,14 +75,14 @@ func (this *SlackNotifier) Notify(evalContext *alerting.EvalContext) error {
 
 	message := this.Mention
 	if evalContext.Rule.State != m.AlertStateOK { //dont add message when going back to alert state ok.
-		message += " " + evalContext.Rule.Message
+		message += " " + this.GetMessage(evalContext)
 	}
 
 	body := map[string]interface{}{
 		"attachments": []map[string]interface{}{
 			{
 				"color":       evalContext.GetStateModel().Color,
-				"title":       evalContext.GetNotificationTitle(),
+				"title":       this.GetTitle(evalContext),
 				"title_link":  ruleUrl,
 				"text":        message,
 				"fields":      fields,
@@ -105,6 +105,7 @@ func (this *SlackNotifier) Notify(evalContext *alerting.EvalContext) error {
 
 	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
 		this.log.Error("Failed to send slack notification", "error", err, "webhook", this.Name)
+		return err
 	}
 
 	return nil
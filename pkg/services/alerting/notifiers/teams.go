@@ -0,0 +1,117 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier("teams", NewTeamsNotifier)
+}
+
+func NewTeamsNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
+	url := model.Settings.Get("url").MustString()
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+
+	return &TeamsNotifier{
+		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
+		Url:          url,
+		log:          log.New("alerting.notifier.teams"),
+	}, nil
+}
+
+// TeamsNotifier posts a MessageCard to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	NotifierBase
+	Url string
+	log log.Logger
+}
+
+func (this *TeamsNotifier) Notify(evalContext *alerting.EvalContext) error {
+	this.log.Info("Executing teams notification", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+	metrics.M_Alerting_Notification_Sent_Teams.Inc(1)
+
+	ruleUrl, err := evalContext.GetRuleUrl()
+	if err != nil {
+		this.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	facts := make([]map[string]interface{}, 0)
+	for _, evt := range evalContext.EvalMatches {
+		facts = append(facts, map[string]interface{}{
+			"name":  evt.Metric,
+			"value": fmt.Sprintf("%v", evt.Value),
+		})
+	}
+
+	if evalContext.Error != nil {
+		facts = append(facts, map[string]interface{}{
+			"name":  "Error message",
+			"value": evalContext.Error.Error(),
+		})
+	}
+
+	message := ""
+	if evalContext.Rule.State != m.AlertStateOK { //dont add message when going back to alert state ok.
+		message = this.GetMessage(evalContext)
+	}
+
+	images := make([]map[string]interface{}, 0)
+	if evalContext.ImagePublicUrl != "" {
+		images = append(images, map[string]interface{}{
+			"image": evalContext.ImagePublicUrl,
+		})
+	}
+
+	title := this.GetTitle(evalContext)
+	body := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "http://schema.org/extensions",
+		"summary":    title,
+		"title":      title,
+		"themeColor": strings.TrimPrefix(evalContext.GetStateModel().Color, "#"),
+		"sections": []map[string]interface{}{
+			{
+				"activityTitle": evalContext.Rule.Name,
+				"text":          message,
+				"facts":         facts,
+				"images":        images,
+			},
+		},
+		"potentialAction": []map[string]interface{}{
+			{
+				"@context": "http://schema.org",
+				"@type":    "OpenUri",
+				"name":     "View Rule",
+				"targets": []map[string]interface{}{
+					{"os": "default", "uri": ruleUrl},
+				},
+			},
+		},
+	}
+
+	data, _ := json.Marshal(&body)
+	cmd := &m.SendWebhookSync{
+		Url:        this.Url,
+		Body:       string(data),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send teams notification", "error", err, "webhook", this.Name)
+		return err
+	}
+
+	return nil
+}
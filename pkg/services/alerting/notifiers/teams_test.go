@@ -0,0 +1,93 @@
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTeamsNotifier(t *testing.T) {
+	Convey("Teams notifier tests", t, func() {
+
+		Convey("Parsing alert notification from settings", func() {
+			Convey("empty settings should return error", func() {
+				json := `{ }`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "ops",
+					Type:     "teams",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewTeamsNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("from settings", func() {
+				json := `
+				{
+          "url": "http://google.com"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "ops",
+					Type:     "teams",
+					Settings: settingsJSON,
+				}
+
+				not, err := NewTeamsNotifier(model)
+				teamsNotifier := not.(*TeamsNotifier)
+
+				So(err, ShouldBeNil)
+				So(teamsNotifier.Name, ShouldEqual, "ops")
+				So(teamsNotifier.Type, ShouldEqual, "teams")
+				So(teamsNotifier.Url, ShouldEqual, "http://google.com")
+			})
+		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(200)
+			defer server.Close()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("url", server.URL+"/webhook")
+
+			not, err := NewTeamsNotifier(&m.AlertNotification{Name: "ops", Type: "teams", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("posts message card with image", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.ImagePublicUrl = "http://images/panel.png"
+
+				err := not.Notify(evalContext)
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				card := (*requests)[0].Json()
+				So((*requests)[0].Header.Get("Content-Type"), ShouldEqual, "application/json")
+				So(card.Get("@type").MustString(), ShouldEqual, "MessageCard")
+				So(card.Get("title").MustString(), ShouldEqual, "[Alerting] cpu usage")
+				So(card.Get("themeColor").MustString(), ShouldEqual, "D63232")
+
+				section := card.Get("sections").GetIndex(0)
+				So(section.Get("text").MustString(), ShouldEqual, "cpu is high")
+				So(section.Get("facts").GetIndex(0).Get("name").MustString(), ShouldEqual, "server1")
+				So(section.Get("facts").GetIndex(0).Get("value").MustString(), ShouldEqual, "95")
+				So(section.Get("images").GetIndex(0).Get("image").MustString(), ShouldEqual, "http://images/panel.png")
+			})
+
+			Convey("returns error when teams fails", func() {
+				failing, _ := newReceiverServer(400)
+				defer failing.Close()
+				settingsJSON.Set("url", failing.URL)
+				not, _ := NewTeamsNotifier(&m.AlertNotification{Name: "ops", Type: "teams", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateAlerting)), ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,178 @@
+package notifiers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier("telegram", NewTelegramNotifier)
+}
+
+var (
+	telegramApiUrl string = "https://api.telegram.org/bot%s/%s"
+
+	// telegram rejects photo captions and messages longer than this
+	telegramCaptionLimit int = 1024
+	telegramMessageLimit int = 4096
+)
+
+func NewTelegramNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
+	botToken := model.Settings.Get("bottoken").MustString()
+	if botToken == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Bot Token in settings"}
+	}
+
+	chatId := model.Settings.Get("chatid").MustString()
+	if chatId == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Chat Id in settings"}
+	}
+
+	return &TelegramNotifier{
+		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
+		BotToken:     botToken,
+		ChatId:       chatId,
+		UploadImage:  model.Settings.Get("uploadImage").MustBool(true),
+		log:          log.New("alerting.notifier.telegram"),
+	}, nil
+}
+
+// TelegramNotifier sends alerts through a Telegram bot. When the rendered
+// panel is available on disk it is uploaded as a photo, otherwise a text
+// message with a link to the image is sent.
+type TelegramNotifier struct {
+	NotifierBase
+	BotToken    string
+	ChatId      string
+	UploadImage bool
+	log         log.Logger
+}
+
+func (this *TelegramNotifier) Notify(evalContext *alerting.EvalContext) error {
+	this.log.Info("Executing telegram notification", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+	metrics.M_Alerting_Notification_Sent_Telegram.Inc(1)
+
+	ruleUrl, err := evalContext.GetRuleUrl()
+	if err != nil {
+		this.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	var cmd *m.SendWebhookSync
+	if this.UploadImage && evalContext.ImageOnDiskPath != "" {
+		cmd, err = this.buildPhotoMessage(evalContext, ruleUrl)
+		if err != nil {
+			this.log.Error("Failed to build telegram photo message", "error", err)
+			return err
+		}
+	} else {
+		cmd = this.buildTextMessage(evalContext, ruleUrl)
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send telegram notification", "error", err, "webhook", this.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (this *TelegramNotifier) buildMessage(evalContext *alerting.EvalContext, ruleUrl string, includeImageUrl bool) string {
+	message := this.GetTitle(evalContext) + "\n"
+
+	if evalContext.Rule.State != m.AlertStateOK { //dont add message when going back to alert state ok.
+		if text := this.GetMessage(evalContext); text != "" {
+			message += text + "\n"
+		}
+	}
+
+	for _, evt := range evalContext.EvalMatches {
+		message += fmt.Sprintf("%s: %v\n", evt.Metric, evt.Value)
+	}
+
+	if evalContext.Error != nil {
+		message += "Error: " + evalContext.Error.Error() + "\n"
+	}
+
+	message += "URL: " + ruleUrl + "\n"
+
+	if includeImageUrl && evalContext.ImagePublicUrl != "" {
+		message += "Image: " + evalContext.ImagePublicUrl + "\n"
+	}
+
+	return message
+}
+
+func (this *TelegramNotifier) buildTextMessage(evalContext *alerting.EvalContext, ruleUrl string) *m.SendWebhookSync {
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("chat_id", this.ChatId)
+	bodyJSON.Set("text", truncate(this.buildMessage(evalContext, ruleUrl, true), telegramMessageLimit))
+
+	body, _ := bodyJSON.MarshalJSON()
+
+	return &m.SendWebhookSync{
+		Url:        fmt.Sprintf(telegramApiUrl, this.BotToken, "sendMessage"),
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+	}
+}
+
+func (this *TelegramNotifier) buildPhotoMessage(evalContext *alerting.EvalContext, ruleUrl string) (*m.SendWebhookSync, error) {
+	image, err := os.Open(evalContext.ImageOnDiskPath)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	if err := w.WriteField("chat_id", this.ChatId); err != nil {
+		return nil, err
+	}
+
+	if err := w.WriteField("caption", truncate(this.buildMessage(evalContext, ruleUrl, false), telegramCaptionLimit)); err != nil {
+		return nil, err
+	}
+
+	part, err := w.CreateFormFile("photo", filepath.Base(evalContext.ImageOnDiskPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(part, image); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &m.SendWebhookSync{
+		Url:        fmt.Sprintf(telegramApiUrl, this.BotToken, "sendPhoto"),
+		Body:       body.String(),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": w.FormDataContentType()},
+	}, nil
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,144 @@
+package notifiers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	Convey("Telegram notifier tests", t, func() {
+
+		Convey("Parsing alert notification from settings", func() {
+			Convey("empty settings should return error", func() {
+				json := `{ }`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "telegram_testing",
+					Type:     "telegram",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewTelegramNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("missing chat id should return error", func() {
+				json := `
+				{
+          "bottoken": "abcdefgh0123456789"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "telegram_testing",
+					Type:     "telegram",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewTelegramNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("settings should trigger incident", func() {
+				json := `
+				{
+          "bottoken": "abcdefgh0123456789",
+          "chatid": "-1234567890"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "telegram_testing",
+					Type:     "telegram",
+					Settings: settingsJSON,
+				}
+
+				not, err := NewTelegramNotifier(model)
+				telegramNotifier := not.(*TelegramNotifier)
+
+				So(err, ShouldBeNil)
+				So(telegramNotifier.Name, ShouldEqual, "telegram_testing")
+				So(telegramNotifier.Type, ShouldEqual, "telegram")
+				So(telegramNotifier.BotToken, ShouldEqual, "abcdefgh0123456789")
+				So(telegramNotifier.ChatId, ShouldEqual, "-1234567890")
+				So(telegramNotifier.UploadImage, ShouldBeTrue)
+			})
+		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(200)
+			defer server.Close()
+
+			oldApiUrl := telegramApiUrl
+			telegramApiUrl = server.URL + "/bot%s/%s"
+			defer func() { telegramApiUrl = oldApiUrl }()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("bottoken", "abcdefgh0123456789")
+			settingsJSON.Set("chatid", "-1234567890")
+
+			not, err := NewTelegramNotifier(&m.AlertNotification{Name: "ops", Type: "telegram", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("sends text message without image on disk", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.ImagePublicUrl = "http://images/panel.png"
+
+				err := not.Notify(evalContext)
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				req := (*requests)[0]
+				So(req.Url, ShouldEqual, "/botabcdefgh0123456789/sendMessage")
+				So(req.Json().Get("chat_id").MustString(), ShouldEqual, "-1234567890")
+
+				text := req.Json().Get("text").MustString()
+				So(text, ShouldStartWith, "[Alerting] cpu usage\ncpu is high\nserver1: 95\n")
+				So(text, ShouldContainSubstring, "Image: http://images/panel.png")
+			})
+
+			Convey("uploads photo when image is on disk", func() {
+				image, err := ioutil.TempFile("", "telegram_test")
+				So(err, ShouldBeNil)
+				image.Write([]byte("png data"))
+				image.Close()
+				defer os.Remove(image.Name())
+
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.ImageOnDiskPath = image.Name()
+
+				err = not.Notify(evalContext)
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				req := (*requests)[0]
+				So(req.Url, ShouldEqual, "/botabcdefgh0123456789/sendPhoto")
+
+				mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+				So(err, ShouldBeNil)
+				So(mediaType, ShouldEqual, "multipart/form-data")
+
+				form, err := multipart.NewReader(bytes.NewReader(req.Body), params["boundary"]).ReadForm(1024 * 1024)
+				So(err, ShouldBeNil)
+				So(form.Value["chat_id"][0], ShouldEqual, "-1234567890")
+				So(form.Value["caption"][0], ShouldStartWith, "[Alerting] cpu usage")
+				So(len(form.File["photo"]), ShouldEqual, 1)
+			})
+
+			Convey("truncates long messages", func() {
+				So(truncate(strings.Repeat("a", 10), 10), ShouldEqual, strings.Repeat("a", 10))
+				So(truncate(strings.Repeat("a", 11), 10), ShouldEqual, strings.Repeat("a", 7)+"...")
+			})
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package notifiers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier("victorops", NewVictoropsNotifier)
+}
+
+func NewVictoropsNotifier(model *m.AlertNotification) (alerting.Notifier, error) {
+	url := model.Settings.Get("url").MustString()
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find victorops url property in settings"}
+	}
+
+	routingKey := model.Settings.Get("routingKey").MustString()
+	if routingKey != "" {
+		url = strings.TrimSuffix(url, "/") + "/" + routingKey
+	}
+
+	return &VictoropsNotifier{
+		NotifierBase: NewNotifierBase(model.Id, model.IsDefault, model.Name, model.Type, model.Settings),
+		Url:          url,
+		AutoResolve:  model.Settings.Get("autoResolve").MustBool(true),
+		log:          log.New("alerting.notifier.victorops"),
+	}, nil
+}
+
+// VictoropsNotifier sends alerts to the VictorOps REST endpoint. The
+// routing key is appended to the integration url.
+type VictoropsNotifier struct {
+	NotifierBase
+	Url         string
+	AutoResolve bool
+	log         log.Logger
+}
+
+func (this *VictoropsNotifier) Notify(evalContext *alerting.EvalContext) error {
+	this.log.Info("Executing victorops notification", "ruleId", evalContext.Rule.Id, "notification", this.Name)
+	metrics.M_Alerting_Notification_Sent_VictorOps.Inc(1)
+
+	if evalContext.Rule.State == m.AlertStateOK && !this.AutoResolve {
+		this.log.Info("Not sending a recovery to VictorOps", "state", evalContext.Rule.State, "auto resolve", this.AutoResolve)
+		return nil
+	}
+
+	ruleUrl, err := evalContext.GetRuleUrl()
+	if err != nil {
+		this.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("message_type", getVictoropsMessageType(evalContext.Rule.State))
+	bodyJSON.Set("entity_id", "alertId-"+strconv.FormatInt(evalContext.Rule.Id, 10))
+	bodyJSON.Set("entity_display_name", this.GetTitle(evalContext))
+	bodyJSON.Set("timestamp", time.Now().Unix())
+	bodyJSON.Set("state_message", this.GetMessage(evalContext))
+	bodyJSON.Set("monitoring_tool", "Grafana")
+	bodyJSON.Set("alert_url", ruleUrl)
+
+	if evalContext.ImagePublicUrl != "" {
+		bodyJSON.Set("image_url", evalContext.ImagePublicUrl)
+	}
+
+	if evalContext.Error != nil {
+		bodyJSON.Set("error_message", evalContext.Error.Error())
+	}
+
+	body, _ := bodyJSON.MarshalJSON()
+
+	cmd := &m.SendWebhookSync{
+		Url:        this.Url,
+		Body:       string(body),
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{"Content-Type": "application/json"},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		this.log.Error("Failed to send victorops notification", "error", err, "webhook", this.Name)
+		return err
+	}
+
+	return nil
+}
+
+func getVictoropsMessageType(state m.AlertStateType) string {
+	switch state {
+	case m.AlertStateOK:
+		return "RECOVERY"
+	case m.AlertStateAlerting:
+		return "CRITICAL"
+	default:
+		return "WARNING"
+	}
+}
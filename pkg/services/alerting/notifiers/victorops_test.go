@@ -0,0 +1,95 @@
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVictoropsNotifier(t *testing.T) {
+	Convey("Victorops notifier tests", t, func() {
+
+		Convey("Parsing alert notification from settings", func() {
+			Convey("empty settings should return error", func() {
+				json := `{ }`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "victorops_testing",
+					Type:     "victorops",
+					Settings: settingsJSON,
+				}
+
+				_, err := NewVictoropsNotifier(model)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("from settings with routing key", func() {
+				json := `
+				{
+          "url": "http://alert.victorops.com/integrations/generic/20131114/alert/key/",
+          "routingKey": "ops"
+				}`
+
+				settingsJSON, _ := simplejson.NewJson([]byte(json))
+				model := &m.AlertNotification{
+					Name:     "victorops_testing",
+					Type:     "victorops",
+					Settings: settingsJSON,
+				}
+
+				not, err := NewVictoropsNotifier(model)
+				victoropsNotifier := not.(*VictoropsNotifier)
+
+				So(err, ShouldBeNil)
+				So(victoropsNotifier.Name, ShouldEqual, "victorops_testing")
+				So(victoropsNotifier.Type, ShouldEqual, "victorops")
+				So(victoropsNotifier.Url, ShouldEqual, "http://alert.victorops.com/integrations/generic/20131114/alert/key/ops")
+				So(victoropsNotifier.AutoResolve, ShouldBeTrue)
+			})
+		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(200)
+			defer server.Close()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("url", server.URL+"/alert/key")
+			settingsJSON.Set("routingKey", "ops")
+
+			not, err := NewVictoropsNotifier(&m.AlertNotification{Name: "ops", Type: "victorops", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("sends critical message when alerting", func() {
+				err := not.Notify(newTestEvalContext(m.AlertStateAlerting))
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				req := (*requests)[0]
+				So(req.Url, ShouldEqual, "/alert/key/ops")
+				So(req.Json().Get("message_type").MustString(), ShouldEqual, "CRITICAL")
+				So(req.Json().Get("entity_id").MustString(), ShouldEqual, "alertId-10")
+				So(req.Json().Get("state_message").MustString(), ShouldEqual, "cpu is high")
+			})
+
+			Convey("sends recovery message when ok", func() {
+				err := not.Notify(newTestEvalContext(m.AlertStateOK))
+				So(err, ShouldBeNil)
+
+				So(len(*requests), ShouldEqual, 1)
+				So((*requests)[0].Json().Get("message_type").MustString(), ShouldEqual, "RECOVERY")
+				So((*requests)[0].Json().Get("entity_id").MustString(), ShouldEqual, "alertId-10")
+			})
+
+			Convey("does not send recovery when auto resolve is disabled", func() {
+				settingsJSON.Set("autoResolve", false)
+				not, _ := NewVictoropsNotifier(&m.AlertNotification{Name: "ops", Type: "victorops", Settings: settingsJSON})
+
+				So(not.Notify(newTestEvalContext(m.AlertStateOK)), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 0)
+			})
+		})
+	})
+}
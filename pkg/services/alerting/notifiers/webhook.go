@@ -43,10 +43,13 @@ func (this *WebhookNotifier) Notify(evalContext *alerting.EvalContext) error {
 	metrics.M_Alerting_Notification_Sent_Webhook.Inc(1)
 
 	bodyJSON := simplejson.New()
-	bodyJSON.Set("title", evalContext.GetNotificationTitle())
+	bodyJSON.Set("title", this.GetTitle(evalContext))
 	bodyJSON.Set("ruleId", evalContext.Rule.Id)
 	bodyJSON.Set("ruleName", evalContext.Rule.Name)
 	bodyJSON.Set("state", evalContext.Rule.State)
+	bodyJSON.Set("severity", evalContext.Rule.Severity)
+	bodyJSON.Set("labels", evalContext.Rule.Labels)
+	bodyJSON.Set("message", this.GetMessage(evalContext))
 	bodyJSON.Set("evalMatches", evalContext.EvalMatches)
 
 	ruleUrl, err := evalContext.GetRuleUrl()
@@ -70,6 +73,7 @@ func (this *WebhookNotifier) Notify(evalContext *alerting.EvalContext) error {
 
 	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
 		this.log.Error("Failed to send webhook", "error", err, "webhook", this.Name)
+		return err
 	}
 
 	return nil
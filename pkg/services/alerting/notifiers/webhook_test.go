@@ -48,5 +48,30 @@ func TestWebhookNotifier(t *testing.T) {
 				So(webhookNotifier.Url, ShouldEqual, "http://google.com")
 			})
 		})
+
+		Convey("Sending notifications", func() {
+			server, requests := newReceiverServer(200)
+			defer server.Close()
+
+			settingsJSON := simplejson.New()
+			settingsJSON.Set("url", server.URL+"/hook")
+
+			not, err := NewWebHookNotifier(&m.AlertNotification{Name: "ops", Type: "webhook", Settings: settingsJSON})
+			So(err, ShouldBeNil)
+
+			Convey("body contains severity and labels", func() {
+				evalContext := newTestEvalContext(m.AlertStateAlerting)
+				evalContext.Rule.Severity = m.AlertSeverityWarning
+				evalContext.Rule.Labels = map[string]string{"team": "payments"}
+
+				So(not.Notify(evalContext), ShouldBeNil)
+				So(len(*requests), ShouldEqual, 1)
+
+				body := (*requests)[0].Json()
+				So(body.Get("ruleName").MustString(), ShouldEqual, "cpu usage")
+				So(body.Get("severity").MustString(), ShouldEqual, "warning")
+				So(body.GetPath("labels", "team").MustString(), ShouldEqual, "payments")
+			})
+		})
 	})
 }
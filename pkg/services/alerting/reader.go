@@ -8,10 +8,14 @@ import (
 	"github.com/grafana/grafana/pkg/log"
 	"github.com/grafana/grafana/pkg/metrics"
 	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 type RuleReader interface {
 	Fetch() []*Rule
+	FetchOne(alertId int64) *Rule
+	IsAssigned(alertId int64) bool
 }
 
 type DefaultRuleReader struct {
@@ -22,17 +26,35 @@ type DefaultRuleReader struct {
 	log            log.Logger
 }
 
+var (
+	heartbeatInterval time.Duration = time.Second * 10
+	heartbeatTimeout  time.Duration = time.Second * 30
+)
+
 func NewRuleReader() *DefaultRuleReader {
 	ruleReader := &DefaultRuleReader{
-		log: log.New("alerting.ruleReader"),
+		serverID:       newServerId(),
+		serverPosition: 1,
+		clusterSize:    1,
+		log:            log.New("alerting.ruleReader"),
 	}
 
+	ruleReader.heartbeat()
 	go ruleReader.initReader()
 	return ruleReader
 }
 
+func newServerId() string {
+	name := setting.InstanceName
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	return name + "-" + util.GetRandomString(8)
+}
+
 func (arr *DefaultRuleReader) initReader() {
-	heartbeat := time.NewTicker(time.Second * 10)
+	heartbeat := time.NewTicker(heartbeatInterval)
 
 	for {
 		select {
@@ -50,8 +72,16 @@ func (arr *DefaultRuleReader) Fetch() []*Rule {
 		return []*Rule{}
 	}
 
+	arr.RLock()
+	clusterSize, serverPosition := arr.clusterSize, arr.serverPosition
+	arr.RUnlock()
+
 	res := make([]*Rule, 0)
 	for _, ruleDef := range cmd.Result {
+		if !isAssignedToServer(ruleDef.Id, clusterSize, serverPosition) {
+			continue
+		}
+
 		if model, err := NewRuleFromDBAlert(ruleDef); err != nil {
 			arr.log.Error("Could not build alert model for rule", "ruleId", ruleDef.Id, "error", err)
 		} else {
@@ -63,22 +93,62 @@ func (arr *DefaultRuleReader) Fetch() []*Rule {
 	return res
 }
 
+// FetchOne returns the rule for a single alert, or nil if the alert no longer
+// exists, cannot be parsed or is evaluated by another server in the cluster.
+func (arr *DefaultRuleReader) FetchOne(alertId int64) *Rule {
+	query := &m.GetAlertByIdQuery{Id: alertId}
+
+	if err := bus.Dispatch(query); err != nil {
+		arr.log.Debug("Could not load alert", "alertId", alertId, "error", err)
+		return nil
+	}
+
+	if !arr.IsAssigned(alertId) {
+		return nil
+	}
+
+	model, err := NewRuleFromDBAlert(query.Result)
+	if err != nil {
+		arr.log.Error("Could not build alert model for rule", "ruleId", alertId, "error", err)
+		return nil
+	}
+
+	return model
+}
+
+// IsAssigned returns true when the alert is evaluated by this server.
+func (arr *DefaultRuleReader) IsAssigned(alertId int64) bool {
+	arr.RLock()
+	defer arr.RUnlock()
+
+	return isAssignedToServer(alertId, arr.clusterSize, arr.serverPosition)
+}
+
+// isAssignedToServer shards the rules over all live servers so that
+// each rule is evaluated by exactly one server in the cluster.
+func isAssignedToServer(ruleId int64, clusterSize int, serverPosition int) bool {
+	if clusterSize <= 1 {
+		return true
+	}
+
+	return (ruleId-1)%int64(clusterSize) == int64(serverPosition-1)
+}
+
 func (arr *DefaultRuleReader) heartbeat() {
+	cmd := &m.HeartBeatCommand{ServerId: arr.serverID, Timeout: heartbeatTimeout}
 
-	//Lets cheat on this until we focus on clustering
-	//log.Info("Heartbeat: Sending heartbeat from " + this.serverId)
-	arr.clusterSize = 1
-	arr.serverPosition = 1
+	if err := bus.Dispatch(cmd); err != nil {
+		arr.log.Error("Failed to send heartbeat", "error", err)
+		return
+	}
 
-	/*
-		cmd := &m.HeartBeatCommand{ServerId: this.serverId}
-		err := bus.Dispatch(cmd)
+	arr.Lock()
+	defer arr.Unlock()
 
-		if err != nil {
-			log.Error(1, "Failed to send heartbeat.")
-		} else {
-			this.clusterSize = cmd.Result.ClusterSize
-			this.serverPosition = cmd.Result.UptimePosition
-		}
-	*/
+	if arr.clusterSize != cmd.Result.ClusterSize || arr.serverPosition != cmd.Result.UptimePosition {
+		arr.log.Info("Alerting cluster changed", "serverId", arr.serverID, "clusterSize", cmd.Result.ClusterSize, "position", cmd.Result.UptimePosition)
+	}
+
+	arr.clusterSize = cmd.Result.ClusterSize
+	arr.serverPosition = cmd.Result.UptimePosition
 }
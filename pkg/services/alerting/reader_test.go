@@ -1,84 +1,55 @@
 package alerting
 
 import (
-	//m "github.com/grafana/grafana/pkg/models"
-	. "github.com/smartystreets/goconvey/convey"
 	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestAlertingScheduler(t *testing.T) {
 	Convey("Testing alert job selection", t, func() {
-		/*
-			mockFn := func() []m.AlertRule {
-				return []m.AlertRule{
-					{Id: 1, Title: "test 1"},
-					{Id: 2, Title: "test 2"},
-					{Id: 3, Title: "test 3"},
-					{Id: 4, Title: "test 4"},
-					{Id: 5, Title: "test 5"},
-					{Id: 6, Title: "test 6"},
-				}
-			}
+		ruleIds := []int64{1, 2, 3, 4, 5, 6}
 
-			Convey("single server", func() {
-				scheduler := &Scheduler{
-					jobs:           make(map[int64]*AlertJob, 0),
-					runQueue:       make(chan *AlertJob, 1000),
-					serverId:       "",
-					serverPosition: 1,
-					clusterSize:    1,
+		assignedRules := func(clusterSize, serverPosition int) []int64 {
+			res := make([]int64, 0)
+			for _, id := range ruleIds {
+				if isAssignedToServer(id, clusterSize, serverPosition) {
+					res = append(res, id)
 				}
+			}
+			return res
+		}
 
-				scheduler.updateJobs(mockFn)
-				So(len(scheduler.jobs), ShouldEqual, 6)
-			})
+		Convey("single server", func() {
+			So(len(assignedRules(1, 1)), ShouldEqual, 6)
+		})
 
-			Convey("two servers", func() {
-				scheduler := &Scheduler{
-					jobs:           make(map[int64]*AlertJob, 0),
-					runQueue:       make(chan *AlertJob, 1000),
-					serverId:       "",
-					serverPosition: 1,
-					clusterSize:    2,
-				}
+		Convey("two servers", func() {
+			first := assignedRules(2, 1)
+			second := assignedRules(2, 2)
 
-				scheduler.updateJobs(mockFn)
-				So(len(scheduler.jobs), ShouldEqual, 3)
-				So(scheduler.jobs[1].rule.Id, ShouldEqual, 1)
-			})
+			So(len(first), ShouldEqual, 3)
+			So(len(second), ShouldEqual, 3)
+			So(first[0], ShouldEqual, 1)
+			So(second[0], ShouldEqual, 2)
+		})
 
-			Convey("six servers", func() {
-				scheduler := &Scheduler{
-					jobs:           make(map[int64]*AlertJob, 0),
-					runQueue:       make(chan *AlertJob, 1000),
-					serverId:       "",
-					serverPosition: 6,
-					clusterSize:    6,
-				}
+		Convey("six servers", func() {
+			rules := assignedRules(6, 6)
 
-				scheduler.updateJobs(mockFn)
-				So(len(scheduler.jobs), ShouldEqual, 1)
-				So(scheduler.jobs[6].rule.Id, ShouldEqual, 6)
-			})
+			So(len(rules), ShouldEqual, 1)
+			So(rules[0], ShouldEqual, 6)
+		})
 
-			Convey("more servers then alerts", func() {
-				mockFn := func() []m.AlertRule {
-					return []m.AlertRule{
-						{Id: 1, Title: "test 1"},
-					}
-				}
+		Convey("more servers then alerts", func() {
+			ruleIds = []int64{1}
 
-				scheduler := &Scheduler{
-					jobs:           make(map[int64]*AlertJob, 0),
-					runQueue:       make(chan *AlertJob, 1000),
-					serverId:       "",
-					serverPosition: 3,
-					clusterSize:    3,
-				}
+			So(len(assignedRules(3, 3)), ShouldEqual, 0)
+			So(len(assignedRules(3, 1)), ShouldEqual, 1)
+		})
 
-				scheduler.updateJobs(mockFn)
-				So(len(scheduler.jobs), ShouldEqual, 0)
-			})
-		*/
+		Convey("unknown cluster position should get all rules", func() {
+			So(len(assignedRules(0, 0)), ShouldEqual, 6)
+		})
 	})
 }
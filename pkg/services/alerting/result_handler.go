@@ -41,7 +41,7 @@ func (handler *DefaultResultHandler) GetStateFromEvaluation(evalContext *EvalCon
 			return evalContext.Rule.ExecutionErrorState.ToAlertState()
 		}
 	} else if evalContext.Firing {
-		return m.AlertStateAlerting
+		return handler.getFiringState(evalContext)
 	} else if evalContext.NoDataFound {
 		handler.log.Info("Alert Rule returned no data",
 			"ruleId", evalContext.Rule.Id,
@@ -58,11 +58,35 @@ func (handler *DefaultResultHandler) GetStateFromEvaluation(evalContext *EvalCon
 	return m.AlertStateOK
 }
 
+// getFiringState keeps a firing rule pending until its conditions
+// have been true for the duration configured in the rule.
+func (handler *DefaultResultHandler) getFiringState(evalContext *EvalContext) m.AlertStateType {
+	rule := evalContext.Rule
+
+	if rule.For == 0 || evalContext.PrevAlertState == m.AlertStateAlerting {
+		return m.AlertStateAlerting
+	}
+
+	if evalContext.PrevAlertState != m.AlertStatePending || rule.PendingSince.IsZero() {
+		rule.PendingSince = evalContext.StartTime
+	}
+
+	if evalContext.StartTime.Sub(rule.PendingSince) >= rule.For {
+		return m.AlertStateAlerting
+	}
+
+	return m.AlertStatePending
+}
+
 func (handler *DefaultResultHandler) Handle(evalContext *EvalContext) error {
 	executionError := ""
 	annotationData := simplejson.New()
+	prevPendingSince := evalContext.Rule.PendingSince
 
 	evalContext.Rule.State = handler.GetStateFromEvaluation(evalContext)
+	if evalContext.Rule.State != m.AlertStatePending {
+		evalContext.Rule.PendingSince = time.Time{}
+	}
 
 	if evalContext.Error != nil {
 		executionError = evalContext.Error.Error()
@@ -74,19 +98,25 @@ func (handler *DefaultResultHandler) Handle(evalContext *EvalContext) error {
 	}
 
 	countStateResult(evalContext.Rule.State)
+	handler.saveInstances(evalContext)
+
+	if evalContext.ShouldUpdateAlertState() || evalContext.Error != nil {
+		if err := bus.Dispatch(newEvaluationHistoryEntry(evalContext)); err != nil {
+			handler.log.Error("Failed to save alert history", "alertId", evalContext.Rule.Id, "error", err)
+		}
+	}
+
 	if evalContext.ShouldUpdateAlertState() {
 		handler.log.Info("New state change", "alertId", evalContext.Rule.Id, "newState", evalContext.Rule.State, "prev state", evalContext.PrevAlertState)
 
-		cmd := &m.SetAlertStateCommand{
-			AlertId:  evalContext.Rule.Id,
-			OrgId:    evalContext.Rule.OrgId,
-			State:    evalContext.Rule.State,
-			Error:    executionError,
-			EvalData: annotationData,
-		}
+		handler.saveState(evalContext, executionError, annotationData)
 
-		if err := bus.Dispatch(cmd); err != nil {
-			handler.log.Error("Failed to save state", "error", err)
+		annotationText := evalContext.GetStateModel().Text
+		if evalContext.ShouldSendNotification() {
+			handler.checkSilences(evalContext)
+			if evalContext.SilencedBy != nil {
+				annotationText += " (notifications silenced)"
+			}
 		}
 
 		// save annotation
@@ -97,7 +127,7 @@ func (handler *DefaultResultHandler) Handle(evalContext *EvalContext) error {
 			Type:        annotations.AlertType,
 			AlertId:     evalContext.Rule.Id,
 			Title:       evalContext.Rule.Name,
-			Text:        evalContext.GetStateModel().Text,
+			Text:        annotationText,
 			NewState:    string(evalContext.Rule.State),
 			PrevState:   string(evalContext.PrevAlertState),
 			Epoch:       time.Now().Unix(),
@@ -112,11 +142,98 @@ func (handler *DefaultResultHandler) Handle(evalContext *EvalContext) error {
 		if evalContext.ShouldSendNotification() {
 			handler.notifier.Notify(evalContext)
 		}
+	} else if evalContext.HasInstanceChanges() {
+		handler.log.Info("Alert instances changed", "alertId", evalContext.Rule.Id, "new", len(evalContext.NewInstances), "resolved", len(evalContext.ResolvedInstances))
+
+		handler.checkSilences(evalContext)
+		if evalContext.SilencedBy == nil {
+			handler.notifier.Notify(evalContext)
+		}
+	} else if evalContext.ShouldSendReminder() {
+		handler.checkSilences(evalContext)
+		if evalContext.SilencedBy == nil {
+			handler.notifier.Notify(evalContext)
+		}
+	} else if !evalContext.Rule.PendingSince.Equal(prevPendingSince) {
+		handler.saveState(evalContext, executionError, annotationData)
 	}
 
 	return nil
 }
 
+func (handler *DefaultResultHandler) saveState(evalContext *EvalContext, executionError string, evalData *simplejson.Json) {
+	cmd := &m.SetAlertStateCommand{
+		AlertId:      evalContext.Rule.Id,
+		OrgId:        evalContext.Rule.OrgId,
+		State:        evalContext.Rule.State,
+		Error:        executionError,
+		EvalData:     evalData,
+		PendingSince: evalContext.Rule.PendingSince,
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		handler.log.Error("Failed to save state", "error", err)
+	}
+}
+
+// saveInstances stores the series of the rule that started or stopped
+// firing and removes resolved series that are kept long enough.
+func (handler *DefaultResultHandler) saveInstances(evalContext *EvalContext) {
+	query := &m.GetAlertInstancesQuery{OrgId: evalContext.Rule.OrgId, AlertId: evalContext.Rule.Id}
+	if err := bus.Dispatch(query); err != nil {
+		handler.log.Error("Failed to get alert instances", "alertId", evalContext.Rule.Id, "error", err)
+		return
+	}
+
+	instances := updateInstances(evalContext, query.Result, time.Now())
+
+	kept := make(map[int64]bool)
+	for _, instance := range instances {
+		kept[instance.Id] = true
+	}
+
+	removedIds := make([]int64, 0)
+	for _, instance := range query.Result {
+		if !kept[instance.Id] {
+			removedIds = append(removedIds, instance.Id)
+		}
+	}
+
+	changed := make([]*m.AlertInstance, 0, len(evalContext.NewInstances)+len(evalContext.ResolvedInstances))
+	changed = append(changed, evalContext.NewInstances...)
+	changed = append(changed, evalContext.ResolvedInstances...)
+
+	if len(changed) == 0 && len(removedIds) == 0 {
+		return
+	}
+
+	cmd := &m.SaveAlertInstancesCommand{
+		OrgId:      evalContext.Rule.OrgId,
+		AlertId:    evalContext.Rule.Id,
+		Instances:  changed,
+		RemovedIds: removedIds,
+	}
+
+	if err := bus.Dispatch(cmd); err != nil {
+		handler.log.Error("Failed to save alert instances", "alertId", evalContext.Rule.Id, "error", err)
+	}
+}
+
+// checkSilences marks the context as silenced when an active silence
+// matches the rule. Failing to look up silences never blocks notifications.
+func (handler *DefaultResultHandler) checkSilences(evalContext *EvalContext) {
+	silence, err := findActiveSilence(evalContext)
+	if err != nil {
+		handler.log.Error("Failed to look up alert silences", "alertId", evalContext.Rule.Id, "error", err)
+		return
+	}
+
+	if silence != nil {
+		handler.log.Info("Notification suppressed by silence", "alertId", evalContext.Rule.Id, "silenceId", silence.Id)
+		evalContext.SilencedBy = silence
+	}
+}
+
 func countStateResult(state m.AlertStateType) {
 	switch state {
 	case m.AlertStatePending:
@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"fmt"
+	"time"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -86,5 +88,96 @@ func TestAlertingResultHandler(t *testing.T) {
 				So(ctx.ShouldUpdateAlertState(), ShouldBeFalse)
 			})
 		})
+
+		Convey("Should wait for the for duration", func() {
+			ctx.Firing = true
+			ctx.Rule.For = time.Minute * 5
+
+			Convey("ok -> pending", func() {
+				ctx.PrevAlertState = models.AlertStateOK
+
+				ctx.Rule.State = handler.GetStateFromEvaluation(ctx)
+				So(ctx.Rule.State, ShouldEqual, models.AlertStatePending)
+				So(ctx.Rule.PendingSince, ShouldResemble, ctx.StartTime)
+				So(ctx.ShouldUpdateAlertState(), ShouldBeTrue)
+				So(ctx.ShouldSendNotification(), ShouldBeFalse)
+			})
+
+			Convey("pending -> pending while for has not passed", func() {
+				ctx.PrevAlertState = models.AlertStatePending
+				ctx.Rule.PendingSince = ctx.StartTime.Add(-time.Minute * 4)
+
+				ctx.Rule.State = handler.GetStateFromEvaluation(ctx)
+				So(ctx.Rule.State, ShouldEqual, models.AlertStatePending)
+				So(ctx.ShouldUpdateAlertState(), ShouldBeFalse)
+			})
+
+			Convey("pending -> alerting when for has passed", func() {
+				ctx.PrevAlertState = models.AlertStatePending
+				ctx.Rule.PendingSince = ctx.StartTime.Add(-time.Minute * 5)
+
+				ctx.Rule.State = handler.GetStateFromEvaluation(ctx)
+				So(ctx.Rule.State, ShouldEqual, models.AlertStateAlerting)
+				So(ctx.ShouldUpdateAlertState(), ShouldBeTrue)
+				So(ctx.ShouldSendNotification(), ShouldBeTrue)
+			})
+
+			Convey("pending without pending since should start waiting", func() {
+				ctx.PrevAlertState = models.AlertStatePending
+
+				ctx.Rule.State = handler.GetStateFromEvaluation(ctx)
+				So(ctx.Rule.State, ShouldEqual, models.AlertStatePending)
+				So(ctx.Rule.PendingSince, ShouldResemble, ctx.StartTime)
+			})
+
+			Convey("alerting -> alerting", func() {
+				ctx.PrevAlertState = models.AlertStateAlerting
+
+				ctx.Rule.State = handler.GetStateFromEvaluation(ctx)
+				So(ctx.Rule.State, ShouldEqual, models.AlertStateAlerting)
+			})
+		})
+
+		Convey("Should only save instances that changed", func() {
+			bus.ClearBusHandlers()
+
+			now := time.Now()
+			stored := []*models.AlertInstance{
+				{Id: 1, InstanceKey: "server1", State: models.AlertStateAlerting, FirstSeen: now},
+				{Id: 2, InstanceKey: "server2", State: models.AlertStateAlerting, FirstSeen: now},
+				{Id: 3, InstanceKey: "server3", State: models.AlertStateOK, ResolvedAt: now.Add(-resolvedInstanceRetention * 2)},
+			}
+			bus.AddHandler("test", func(query *models.GetAlertInstancesQuery) error {
+				query.Result = stored
+				return nil
+			})
+
+			var saved *models.SaveAlertInstancesCommand
+			bus.AddHandler("test", func(cmd *models.SaveAlertInstancesCommand) error {
+				saved = cmd
+				return nil
+			})
+
+			ctx.Rule.State = models.AlertStateAlerting
+			ctx.PrevAlertState = models.AlertStateAlerting
+			ctx.EvalMatches = []*EvalMatch{{Metric: "server1", Value: 10}}
+
+			handler.saveInstances(ctx)
+
+			So(saved, ShouldNotBeNil)
+			So(len(saved.Instances), ShouldEqual, 1)
+			So(saved.Instances[0].Id, ShouldEqual, 2)
+			So(saved.Instances[0].State, ShouldEqual, models.AlertStateOK)
+			So(saved.RemovedIds, ShouldResemble, []int64{3})
+
+			Convey("And nothing when no instance changed", func() {
+				saved = nil
+				stored = stored[:1]
+				ctx.ResolvedInstances = nil
+
+				handler.saveInstances(ctx)
+				So(saved, ShouldBeNil)
+			})
+		})
 	})
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 
@@ -16,8 +18,12 @@ type Rule struct {
 	DashboardId         int64
 	PanelId             int64
 	Frequency           int64
+	For                 time.Duration
+	PendingSince        time.Time
 	Name                string
 	Message             string
+	Severity            m.AlertSeverityType
+	Labels              map[string]string
 	NoDataState         m.NoDataOption
 	ExecutionErrorState m.ExecutionErrorOption
 	State               m.AlertStateType
@@ -98,9 +104,28 @@ func NewRuleFromDBAlert(ruleDef *m.Alert) (*Rule, error) {
 	model.Message = ruleDef.Message
 	model.Frequency = ruleDef.Frequency
 	model.State = ruleDef.State
+	model.PendingSince = ruleDef.PendingSince
+	model.Severity = m.AlertSeverityType(ruleDef.Settings.Get("severity").MustString(string(m.AlertSeverityCritical)))
+	if !model.Severity.IsValid() {
+		return nil, ValidationError{Reason: "Invalid severity " + string(model.Severity), DashboardId: model.DashboardId, Alertid: model.Id, PanelId: model.PanelId}
+	}
+
+	if err := validateLabels(ruleDef.Settings.Get("labels")); err != nil {
+		return nil, ValidationError{Reason: err.Error(), DashboardId: model.DashboardId, Alertid: model.Id, PanelId: model.PanelId}
+	}
+	model.Labels = ruleDef.GetLabels()
+
 	model.NoDataState = m.NoDataOption(ruleDef.Settings.Get("noDataState").MustString("no_data"))
 	model.ExecutionErrorState = m.ExecutionErrorOption(ruleDef.Settings.Get("executionErrorState").MustString("alerting"))
 
+	if forValue := ruleDef.Settings.Get("for").MustString(""); forValue != "" {
+		forSeconds, err := getTimeDurationStringToSeconds(forValue)
+		if err != nil {
+			return nil, ValidationError{Reason: "Could not parse for", DashboardId: model.DashboardId, Alertid: model.Id, PanelId: model.PanelId}
+		}
+		model.For = time.Duration(forSeconds) * time.Second
+	}
+
 	for _, v := range ruleDef.Settings.Get("notifications").MustArray() {
 		jsonModel := simplejson.NewFromAny(v)
 		if id, err := jsonModel.Get("id").Int64(); err != nil {
@@ -131,6 +156,32 @@ func NewRuleFromDBAlert(ruleDef *m.Alert) (*Rule, error) {
 	return model, nil
 }
 
+// validateLabels checks that labels are string values with keys and values
+// that can be used in label filters like "team=payments,env=prod".
+func validateLabels(labels *simplejson.Json) error {
+	if labels.Interface() == nil {
+		return nil
+	}
+
+	labelMap, err := labels.Map()
+	if err != nil {
+		return fmt.Errorf("Labels must be an object")
+	}
+
+	for key, value := range labelMap {
+		if key == "" || strings.ContainsAny(key, "=, ") {
+			return fmt.Errorf("Invalid label name %q", key)
+		}
+
+		str, ok := value.(string)
+		if !ok || str == "" || strings.Contains(str, ",") {
+			return fmt.Errorf("Invalid value for label %q", key)
+		}
+	}
+
+	return nil
+}
+
 type ConditionFactory func(model *simplejson.Json, index int) (Condition, error)
 
 var conditionFactories map[string]ConditionFactory = make(map[string]ConditionFactory)
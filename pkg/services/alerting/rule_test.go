@@ -2,6 +2,7 @@ package alerting
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	m "github.com/grafana/grafana/pkg/models"
@@ -55,6 +56,7 @@ func TestAlertRuleModel(t *testing.T) {
 				"noDataMode": "critical",
 				"enabled": true,
 				"frequency": "60s",
+				"for": "5m",
         "conditions": [
           {
             "type": "test",
@@ -88,6 +90,51 @@ func TestAlertRuleModel(t *testing.T) {
 			Convey("Can read notifications", func() {
 				So(len(alertRule.Notifications), ShouldEqual, 2)
 			})
+
+			Convey("Can read for", func() {
+				So(alertRule.For, ShouldEqual, time.Minute*5)
+			})
+
+			Convey("Defaults severity to critical", func() {
+				So(alertRule.Severity, ShouldEqual, m.AlertSeverityCritical)
+			})
+
+			Convey("Can read severity", func() {
+				alertJSON.Set("severity", "warning")
+				alertRule, err := NewRuleFromDBAlert(alert)
+
+				So(err, ShouldBeNil)
+				So(alertRule.Severity, ShouldEqual, m.AlertSeverityWarning)
+			})
+
+			Convey("Returns error for unknown severity", func() {
+				alertJSON.Set("severity", "sev1")
+				_, err := NewRuleFromDBAlert(alert)
+
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Can read labels", func() {
+				alertJSON.Set("labels", map[string]interface{}{"team": "payments", "env": "prod"})
+				alertRule, err := NewRuleFromDBAlert(alert)
+
+				So(err, ShouldBeNil)
+				So(alertRule.Labels, ShouldResemble, map[string]string{"team": "payments", "env": "prod"})
+			})
+
+			Convey("Returns error for invalid labels", func() {
+				alertJSON.Set("labels", map[string]interface{}{"team=a": "payments"})
+				_, err := NewRuleFromDBAlert(alert)
+				So(err, ShouldNotBeNil)
+
+				alertJSON.Set("labels", map[string]interface{}{"team": 1})
+				_, err = NewRuleFromDBAlert(alert)
+				So(err, ShouldNotBeNil)
+
+				alertJSON.Set("labels", []interface{}{"team"})
+				_, err = NewRuleFromDBAlert(alert)
+				So(err, ShouldNotBeNil)
+			})
 			/*
 				Convey("Can read noDataMode", func() {
 					So(len(alertRule.NoDataMode), ShouldEqual, m.AlertStateCritical)
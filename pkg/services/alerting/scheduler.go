@@ -2,13 +2,16 @@ package alerting
 
 import (
 	"math"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
 	"github.com/grafana/grafana/pkg/models"
 )
 
 type SchedulerImpl struct {
+	mtx  sync.Mutex
 	jobs map[int64]*Job
 	log  log.Logger
 }
@@ -23,6 +26,9 @@ func NewScheduler() Scheduler {
 func (s *SchedulerImpl) Update(rules []*Rule) {
 	s.log.Debug("Scheduling update", "ruleCount", len(rules))
 
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	jobs := make(map[int64]*Job, 0)
 
 	for i, rule := range rules {
@@ -36,40 +42,117 @@ func (s *SchedulerImpl) Update(rules []*Rule) {
 		}
 
 		job.Rule = rule
-
-		offset := ((rule.Frequency * 1000) / int64(len(rules))) * int64(i)
-		job.Offset = int64(math.Floor(float64(offset) / 1000))
+		job.Offset = getJobOffset(rule, i, len(rules))
 		jobs[rule.Id] = job
 	}
 
 	s.jobs = jobs
 }
 
+func (s *SchedulerImpl) UpdateJob(rule *Rule) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if job, exists := s.jobs[rule.Id]; exists {
+		s.log.Debug("Scheduling update for job", "id", rule.Id)
+		job.Rule = rule
+		return
+	}
+
+	s.log.Debug("Scheduling new job", "id", rule.Id)
+	s.jobs[rule.Id] = &Job{
+		Rule:   rule,
+		Offset: getJobOffset(rule, len(s.jobs), len(s.jobs)+1),
+	}
+}
+
+func (s *SchedulerImpl) RemoveJob(ruleId int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, exists := s.jobs[ruleId]; exists {
+		s.log.Debug("Removing job", "id", ruleId)
+		delete(s.jobs, ruleId)
+	}
+}
+
+func getJobOffset(rule *Rule, index int, jobCount int) int64 {
+	offset := ((rule.Frequency * 1000) / int64(jobCount)) * int64(index)
+	return int64(math.Floor(float64(offset) / 1000))
+}
+
 func (s *SchedulerImpl) Tick(tickTime time.Time, execQueue chan *Job) {
+	for _, job := range s.getJobsToRun(tickTime) {
+		s.enque(job, execQueue)
+	}
+
+	metrics.M_Alerting_Queue_Depth.Update(int64(len(execQueue)))
+}
+
+func (s *SchedulerImpl) getJobsToRun(tickTime time.Time) []*Job {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	now := tickTime.Unix()
+	jobsToRun := make([]*Job, 0)
 
 	for _, job := range s.jobs {
-		if job.Running || job.Rule.State == models.AlertStatePaused {
+		if job.Rule.State == models.AlertStatePaused {
 			continue
 		}
 
+		due := false
 		if job.OffsetWait && now%job.Offset == 0 {
 			job.OffsetWait = false
-			s.enque(job, execQueue)
-			continue
-		}
-
-		if now%job.Rule.Frequency == 0 {
+			due = true
+		} else if now%job.Rule.Frequency == 0 {
 			if job.Offset > 0 {
 				job.OffsetWait = true
 			} else {
-				s.enque(job, execQueue)
+				due = true
 			}
 		}
+
+		if !due {
+			continue
+		}
+
+		// a job stays running from the moment it is queued until its
+		// evaluation is done
+		if job.Running {
+			s.log.Warn("Skipping alert rule evaluation, previous evaluation is still running", "alertId", job.Rule.Id, "name", job.Rule.Name)
+			metrics.M_Alerting_Evaluations_Skipped.Inc(1)
+			continue
+		}
+
+		job.Running = true
+		jobsToRun = append(jobsToRun, job)
 	}
+
+	return jobsToRun
 }
 
+// enque never blocks the ticker. When the queue is full the evaluation
+// is dropped and the job is scheduled again on its next interval.
 func (s *SchedulerImpl) enque(job *Job, execQueue chan *Job) {
 	s.log.Debug("Scheduler: Putting job on to exec queue", "name", job.Rule.Name, "id", job.Rule.Id)
-	execQueue <- job
+
+	job.Queued = time.Now()
+
+	select {
+	case execQueue <- job:
+	default:
+		s.log.Warn("Alert job queue is full, dropping evaluation", "alertId", job.Rule.Id, "name", job.Rule.Name)
+		metrics.M_Alerting_Jobs_Dropped.Inc(1)
+		s.JobDone(job)
+	}
+}
+
+// JobDone marks the evaluation of the job as finished, so the job is
+// scheduled again on its next interval.
+func (s *SchedulerImpl) JobDone(job *Job) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	job.Running = false
 }
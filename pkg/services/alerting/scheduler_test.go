@@ -0,0 +1,88 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSchedulerJobUpdates(t *testing.T) {
+	Convey("Testing scheduler job updates", t, func() {
+		scheduler := NewScheduler()
+		scheduler.Update([]*Rule{
+			{Id: 1, Frequency: 10, State: m.AlertStateOK},
+			{Id: 2, Frequency: 10, State: m.AlertStateOK},
+		})
+
+		jobs := func() map[int64]*Job {
+			return scheduler.(*SchedulerImpl).jobs
+		}
+
+		Convey("Can add a new job", func() {
+			scheduler.UpdateJob(&Rule{Id: 3, Frequency: 10, State: m.AlertStateOK})
+
+			So(len(jobs()), ShouldEqual, 3)
+			So(jobs()[3].Rule.Id, ShouldEqual, 3)
+		})
+
+		Convey("Can replace the rule of an existing job", func() {
+			existing := jobs()[2]
+			scheduler.UpdateJob(&Rule{Id: 2, Name: "updated", Frequency: 60, State: m.AlertStateOK})
+
+			So(len(jobs()), ShouldEqual, 2)
+			So(jobs()[2], ShouldEqual, existing)
+			So(jobs()[2].Rule.Name, ShouldEqual, "updated")
+			So(jobs()[2].Rule.Frequency, ShouldEqual, 60)
+		})
+
+		Convey("Can remove a job", func() {
+			scheduler.RemoveJob(1)
+
+			So(len(jobs()), ShouldEqual, 1)
+			So(jobs()[1], ShouldBeNil)
+		})
+
+		Convey("Should not enqueue paused rules", func() {
+			scheduler.UpdateJob(&Rule{Id: 1, Frequency: 10, State: m.AlertStatePaused})
+
+			execQueue := make(chan *Job, 10)
+			scheduler.Tick(time.Unix(100, 0), execQueue)
+			scheduler.Tick(time.Unix(105, 0), execQueue)
+
+			So(len(execQueue), ShouldEqual, 1)
+			So((<-execQueue).Rule.Id, ShouldEqual, 2)
+		})
+
+		Convey("Should skip jobs that are still running", func() {
+			execQueue := make(chan *Job, 10)
+			scheduler.Tick(time.Unix(100, 0), execQueue)
+			scheduler.Tick(time.Unix(105, 0), execQueue)
+			So(len(execQueue), ShouldEqual, 2)
+			So(jobs()[1].Running, ShouldBeTrue)
+
+			scheduler.JobDone(jobs()[2])
+			scheduler.Tick(time.Unix(110, 0), execQueue)
+			scheduler.Tick(time.Unix(115, 0), execQueue)
+
+			So(len(execQueue), ShouldEqual, 3)
+		})
+
+		Convey("Should drop jobs instead of blocking when queue is full", func() {
+			execQueue := make(chan *Job, 1)
+			scheduler.Tick(time.Unix(100, 0), execQueue)
+			scheduler.Tick(time.Unix(105, 0), execQueue)
+
+			So(len(execQueue), ShouldEqual, 1)
+
+			queued := <-execQueue
+			So(queued.Running, ShouldBeTrue)
+			So(queued.Queued.IsZero(), ShouldBeFalse)
+
+			Convey("Dropped job is scheduled again on its next interval", func() {
+				So(jobs()[2].Running, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package alerting
+
+import (
+	"regexp"
+
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+// findActiveSilence returns the first silence active at the start of the
+// evaluation that matches the rule, or nil if notifications may be sent.
+func findActiveSilence(evalContext *EvalContext) (*m.AlertSilence, error) {
+	query := &m.GetAlertSilencesQuery{
+		OrgId:    evalContext.Rule.OrgId,
+		ActiveAt: evalContext.StartTime,
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+
+	for _, silence := range query.Result {
+		match, err := silenceMatches(silence, evalContext)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			return silence, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func silenceMatches(silence *m.AlertSilence, evalContext *EvalContext) (bool, error) {
+	rule := evalContext.Rule
+
+	if silence.DashboardId != 0 && silence.DashboardId != rule.DashboardId {
+		return false, nil
+	}
+
+	if silence.AlertId != 0 && silence.AlertId != rule.Id {
+		return false, nil
+	}
+
+	if silence.NameRegex != "" {
+		re, err := regexp.Compile(silence.NameRegex)
+		if err != nil {
+			return false, err
+		}
+
+		if !re.MatchString(rule.Name) {
+			return false, nil
+		}
+	}
+
+	if silence.Tag != "" {
+		tags, err := evalContext.GetDashboardTags()
+		if err != nil {
+			return false, err
+		}
+
+		for _, tag := range tags {
+			if tag == silence.Tag {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertSilences(t *testing.T) {
+	Convey("Alert silences", t, func() {
+		rule := &Rule{Id: 5, OrgId: 1, DashboardId: 2, Name: "cpu usage", State: m.AlertStateAlerting}
+		ctx := NewEvalContext(context.TODO(), rule)
+
+		Convey("Empty matchers match any alert", func() {
+			match, err := silenceMatches(&m.AlertSilence{}, ctx)
+			So(err, ShouldBeNil)
+			So(match, ShouldBeTrue)
+		})
+
+		Convey("Matches on dashboard and alert id", func() {
+			match, _ := silenceMatches(&m.AlertSilence{DashboardId: 2, AlertId: 5}, ctx)
+			So(match, ShouldBeTrue)
+
+			match, _ = silenceMatches(&m.AlertSilence{AlertId: 6}, ctx)
+			So(match, ShouldBeFalse)
+
+			match, _ = silenceMatches(&m.AlertSilence{DashboardId: 3}, ctx)
+			So(match, ShouldBeFalse)
+		})
+
+		Convey("Matches on name regexp", func() {
+			match, _ := silenceMatches(&m.AlertSilence{NameRegex: "^cpu"}, ctx)
+			So(match, ShouldBeTrue)
+
+			match, _ = silenceMatches(&m.AlertSilence{NameRegex: "^memory"}, ctx)
+			So(match, ShouldBeFalse)
+
+			_, err := silenceMatches(&m.AlertSilence{NameRegex: "("}, ctx)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Matches on dashboard tag", func() {
+			bus.AddHandler("test", func(query *m.GetDashboardTagsByIdQuery) error {
+				query.Result = []string{"prod", "db"}
+				return nil
+			})
+
+			match, _ := silenceMatches(&m.AlertSilence{Tag: "db"}, ctx)
+			So(match, ShouldBeTrue)
+
+			match, _ = silenceMatches(&m.AlertSilence{Tag: "staging"}, ctx)
+			So(match, ShouldBeFalse)
+		})
+
+		Convey("Silenced context does not send notifications", func() {
+			bus.AddHandler("test", func(query *m.GetAlertSilencesQuery) error {
+				query.Result = []*m.AlertSilence{
+					{Id: 1, AlertId: 9, StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)},
+					{Id: 2, NameRegex: "cpu", StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)},
+				}
+				return nil
+			})
+
+			silence, err := findActiveSilence(ctx)
+			So(err, ShouldBeNil)
+			So(silence.Id, ShouldEqual, 2)
+
+			ctx.PrevAlertState = m.AlertStateOK
+			So(ctx.ShouldSendNotification(), ShouldBeTrue)
+
+			ctx.SilencedBy = silence
+			So(ctx.ShouldSendNotification(), ShouldBeFalse)
+
+			ctx.PrevAlertState = m.AlertStateAlerting
+			So(ctx.ShouldSendReminder(), ShouldBeFalse)
+		})
+	})
+}
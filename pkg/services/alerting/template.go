@@ -0,0 +1,142 @@
+package alerting
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+// TemplateData is the data model alert messages and notifier title/body
+// templates are rendered against.
+type TemplateData struct {
+	Rule          TemplateRule
+	State         m.AlertStateType
+	PrevState     m.AlertStateType
+	Title         string
+	Message       string
+	EvalMatches   []*EvalMatch
+	RuleUrl       string
+	ImageUrl      string
+	DashboardTags []string
+	Error         string
+}
+
+type TemplateRule struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	PanelId     int64
+	Name        string
+	Severity    m.AlertSeverityType
+	Labels      map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("notification").Funcs(templateFuncs).Parse(text)
+}
+
+func renderTemplate(text string, data *TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ValidateTemplate parses the template and renders it against the
+// test notification data so unknown fields are reported up front.
+func ValidateTemplate(text string) error {
+	ctx := createTestEvalContext()
+	if _, err := renderTemplate(text, ctx.GetTemplateData()); err != nil {
+		return ValidationError{Reason: "Invalid template: " + err.Error()}
+	}
+
+	return nil
+}
+
+// GetTemplateData collects the values templates can refer to. Message
+// holds the rule message already rendered against the same data. The data
+// is built once per evaluation and shared by all notifiers, so it should
+// not be called before the panel image is uploaded.
+func (c *EvalContext) GetTemplateData() *TemplateData {
+	c.templateDataOnce.Do(func() {
+		c.templateData = c.newTemplateData()
+	})
+
+	return c.templateData
+}
+
+func (c *EvalContext) newTemplateData() *TemplateData {
+	data := &TemplateData{
+		Rule: TemplateRule{
+			Id:          c.Rule.Id,
+			OrgId:       c.Rule.OrgId,
+			DashboardId: c.Rule.DashboardId,
+			PanelId:     c.Rule.PanelId,
+			Name:        c.Rule.Name,
+			Severity:    c.Rule.Severity,
+			Labels:      c.Rule.Labels,
+		},
+		State:       c.Rule.State,
+		PrevState:   c.PrevAlertState,
+		Title:       c.GetNotificationTitle(),
+		Message:     c.Rule.Message,
+		EvalMatches: c.EvalMatches,
+		ImageUrl:    c.ImagePublicUrl,
+	}
+
+	if ruleUrl, err := c.GetRuleUrl(); err == nil {
+		data.RuleUrl = ruleUrl
+	} else {
+		c.log.Error("Failed to get rule url for template", "error", err)
+	}
+
+	if tags, err := c.GetDashboardTags(); err == nil {
+		data.DashboardTags = tags
+	} else {
+		c.log.Error("Failed to get dashboard tags for template", "error", err)
+	}
+
+	if c.Error != nil {
+		data.Error = c.Error.Error()
+	}
+
+	if message, err := renderTemplate(c.Rule.Message, data); err == nil {
+		data.Message = message
+	} else {
+		c.log.Error("Failed to render alert message", "ruleId", c.Rule.Id, "error", err)
+	}
+
+	return data
+}
+
+// RenderTemplate renders text against the template data of the evaluation.
+func (c *EvalContext) RenderTemplate(text string) (string, error) {
+	return renderTemplate(text, c.GetTemplateData())
+}
+
+// GetNotificationMessage returns the rule message rendered as a template.
+// If rendering fails the message is returned as written.
+func (c *EvalContext) GetNotificationMessage() string {
+	if !strings.Contains(c.Rule.Message, "{{") {
+		return c.Rule.Message
+	}
+
+	return c.GetTemplateData().Message
+}
@@ -0,0 +1,103 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNotificationTemplates(t *testing.T) {
+	Convey("Notification templates", t, func() {
+		ctx := NewEvalContext(context.TODO(), &Rule{Id: 3, Name: "cpu", State: m.AlertStateAlerting})
+		ctx.IsTestRun = true
+		ctx.dashboardTags = []string{"prod", "web"}
+		ctx.PrevAlertState = m.AlertStateOK
+		ctx.EvalMatches = []*EvalMatch{
+			{Metric: "server1", Value: 95, Tags: map[string]string{"host": "server1"}},
+			{Metric: "server2", Value: 90.5},
+		}
+
+		Convey("Should render rule message against eval matches", func() {
+			ctx.Rule.Message = "{{.Rule.Name}} went from {{.PrevState}} to {{.State}}:{{range .EvalMatches}} {{.Metric}}={{.Value}}{{end}}"
+
+			So(ctx.GetNotificationMessage(), ShouldEqual, "cpu went from ok to alerting: server1=95 server2=90.5")
+		})
+
+		Convey("Should render dashboard tags and title", func() {
+			text, err := ctx.RenderTemplate(`{{join .DashboardTags ","}} {{.Title}}`)
+
+			So(err, ShouldBeNil)
+			So(text, ShouldEqual, "prod,web [Alerting] cpu")
+		})
+
+		Convey("Should expose rendered rule message", func() {
+			ctx.Rule.Message = "{{.Rule.Id}} is firing"
+			text, err := ctx.RenderTemplate("body: {{.Message}}")
+
+			So(err, ShouldBeNil)
+			So(text, ShouldEqual, "body: 3 is firing")
+		})
+
+		Convey("Should keep message as written when it fails to render", func() {
+			ctx.Rule.Message = "{{.Unknown}}"
+
+			So(ctx.GetNotificationMessage(), ShouldEqual, "{{.Unknown}}")
+		})
+
+		Convey("Should keep plain messages", func() {
+			ctx.Rule.Message = "disk is full"
+
+			So(ctx.GetNotificationMessage(), ShouldEqual, "disk is full")
+		})
+
+		Convey("Should build template data once for all notifiers", func() {
+			ctx.dashboardTags = nil
+			queries := 0
+			bus.ClearBusHandlers()
+			bus.AddHandler("test", func(query *m.GetDashboardTagsByIdQuery) error {
+				queries++
+				query.Result = []string{"prod"}
+				return nil
+			})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx.RenderTemplate("{{.Title}}")
+					ctx.GetDashboardTags()
+				}()
+			}
+			wg.Wait()
+
+			So(queries, ShouldEqual, 1)
+			So(ctx.GetTemplateData(), ShouldEqual, ctx.GetTemplateData())
+		})
+
+		Convey("Validation", func() {
+			So(ValidateTemplate(""), ShouldBeNil)
+			So(ValidateTemplate("{{.Rule.Name}} {{.RuleUrl}} {{.ImageUrl}}"), ShouldBeNil)
+
+			Convey("Should return validation error for syntax errors", func() {
+				err := ValidateTemplate("{{.Rule.Name")
+
+				So(err, ShouldNotBeNil)
+				_, ok := err.(ValidationError)
+				So(ok, ShouldBeTrue)
+			})
+
+			Convey("Should return validation error for unknown fields", func() {
+				err := ValidateTemplate("{{.Rule.Unknown}}")
+
+				So(err, ShouldNotBeNil)
+				_, ok := err.(ValidationError)
+				So(ok, ShouldBeTrue)
+			})
+		})
+	})
+}
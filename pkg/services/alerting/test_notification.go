@@ -30,6 +30,12 @@ func handleNotificationTestCommand(cmd *NotificationTestCommand) error {
 		Settings: cmd.Settings,
 	}
 
+	for _, key := range []string{"titleTemplate", "bodyTemplate"} {
+		if err := ValidateTemplate(cmd.Settings.Get(key).MustString()); err != nil {
+			return err
+		}
+	}
+
 	notifiers, err := notifier.createNotifierFor(model)
 
 	if err != nil {
@@ -55,6 +61,7 @@ func createTestEvalContext() *EvalContext {
 	ctx.Firing = true
 	ctx.Error = nil
 	ctx.EvalMatches = evalMatchesBasedOnState()
+	ctx.dashboardTags = []string{"test"}
 
 	return ctx
 }
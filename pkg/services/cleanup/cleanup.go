@@ -45,6 +45,8 @@ func (service *CleanUpService) start(ctx context.Context) error {
 		case <-ticker.C:
 			service.cleanUpTmpFiles()
 			service.deleteExpiredSnapshots()
+			service.deleteExpiredAlertNotificationDeliveries()
+			service.deleteExpiredAlertHistory()
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -83,3 +85,21 @@ func (service *CleanUpService) cleanUpTmpFiles() {
 func (service *CleanUpService) deleteExpiredSnapshots() {
 	bus.Dispatch(&m.DeleteExpiredSnapshotsCommand{})
 }
+
+func (service *CleanUpService) deleteExpiredAlertNotificationDeliveries() {
+	cmd := &m.DeleteExpiredAlertNotificationDeliveriesCommand{OlderThan: time.Now().AddDate(0, 0, -7)}
+	if err := bus.Dispatch(cmd); err != nil {
+		service.log.Error("Failed to delete expired alert notification deliveries", "error", err)
+	}
+}
+
+func (service *CleanUpService) deleteExpiredAlertHistory() {
+	if setting.AlertingHistoryRetentionDays <= 0 {
+		return
+	}
+
+	cmd := &m.DeleteExpiredAlertHistoryCommand{OlderThan: time.Now().AddDate(0, 0, -setting.AlertingHistoryRetentionDays)}
+	if err := bus.Dispatch(cmd); err != nil {
+		service.log.Error("Failed to delete expired alert history", "error", err)
+	}
+}
@@ -20,13 +20,13 @@ type Webhook struct {
 	Password   string
 	Body       string
 	HttpMethod string
+	HttpHeader map[string]string
 }
 
 var webhookQueue chan *Webhook
-var webhookLog log.Logger
+var webhookLog log.Logger = log.New("notifications.webhook")
 
 func initWebhookQueue() {
-	webhookLog = log.New("notifications.webhook")
 	webhookQueue = make(chan *Webhook, 10)
 	go processWebhookQueue()
 }
@@ -56,12 +56,16 @@ func sendWebRequestSync(ctx context.Context, webhook *Webhook) error {
 	}
 
 	request, err := http.NewRequest(webhook.HttpMethod, webhook.Url, bytes.NewReader([]byte(webhook.Body)))
+	if err != nil {
+		return err
+	}
+
 	if webhook.User != "" && webhook.Password != "" {
 		request.Header.Add("Authorization", util.GetBasicAuthHeader(webhook.User, webhook.Password))
 	}
 
-	if err != nil {
-		return err
+	for k, v := range webhook.HttpHeader {
+		request.Header.Set(k, v)
 	}
 
 	resp, err := ctxhttp.Do(ctx, client, request)
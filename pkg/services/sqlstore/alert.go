@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-xorm/xorm"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	m "github.com/grafana/grafana/pkg/models"
 )
 
@@ -46,9 +47,15 @@ func GetAllAlertQueryHandler(query *m.GetAllAlertsQuery) error {
 	return nil
 }
 
-func deleteAlertByIdInternal(alertId int64, reason string, sess *xorm.Session) error {
+func deleteAlertByIdInternal(alertId int64, reason string, sess *session) error {
 	sqlog.Debug("Deleting alert", "id", alertId, "reason", reason)
 
+	alert := m.Alert{}
+	has, err := sess.Id(alertId).Get(&alert)
+	if err != nil {
+		return err
+	}
+
 	if _, err := sess.Exec("DELETE FROM alert WHERE id = ?", alertId); err != nil {
 		return err
 	}
@@ -57,11 +64,40 @@ func deleteAlertByIdInternal(alertId int64, reason string, sess *xorm.Session) e
 		return err
 	}
 
+	if _, err := sess.Exec("DELETE FROM alert_notification_state WHERE alert_id = ?", alertId); err != nil {
+		return err
+	}
+
+	if _, err := sess.Exec("DELETE FROM alert_instance WHERE alert_id = ?", alertId); err != nil {
+		return err
+	}
+
+	if _, err := sess.Exec("DELETE FROM alert_notification_delivery WHERE alert_id = ?", alertId); err != nil {
+		return err
+	}
+
+	if _, err := sess.Exec("DELETE FROM alert_label WHERE alert_id = ?", alertId); err != nil {
+		return err
+	}
+
+	if _, err := sess.Exec("DELETE FROM alert_history_entry WHERE alert_id = ?", alertId); err != nil {
+		return err
+	}
+
+	if has {
+		sess.publishAfterCommit(&events.AlertDeleted{
+			Timestamp:   time.Now(),
+			Id:          alert.Id,
+			OrgId:       alert.OrgId,
+			DashboardId: alert.DashboardId,
+		})
+	}
+
 	return nil
 }
 
 func DeleteAlertById(cmd *m.DeleteAlertCommand) error {
-	return inTransaction(func(sess *xorm.Session) error {
+	return inTransaction2(func(sess *session) error {
 		return deleteAlertByIdInternal(cmd.AlertId, "DeleteAlertCommand", sess)
 	})
 }
@@ -99,13 +135,40 @@ func HandleAlertsQuery(query *m.GetAlertsQuery) error {
 		sql.WriteString(")")
 	}
 
+	if len(query.Severity) > 0 {
+		sql.WriteString(` AND (`)
+		for i, v := range query.Severity {
+			if i > 0 {
+				sql.WriteString(" OR ")
+			}
+			// alerts saved before severities were added are critical
+			if v == string(m.AlertSeverityCritical) {
+				sql.WriteString("severity = ? OR severity = '' ")
+			} else {
+				sql.WriteString("severity = ? ")
+			}
+			params = append(params, v)
+		}
+		sql.WriteString(")")
+	}
+
+	for key, value := range query.Labels {
+		sql.WriteString(` AND EXISTS (SELECT 1 FROM alert_label WHERE alert_label.alert_id = alert.id AND alert_label.name = ?`)
+		params = append(params, key)
+		if value != "" {
+			sql.WriteString(` AND alert_label.value = ?`)
+			params = append(params, value)
+		}
+		sql.WriteString(`)`)
+	}
+
+	sql.WriteString(" ORDER BY name ASC")
+
 	if query.Limit != 0 {
 		sql.WriteString(" LIMIT ?")
 		params = append(params, query.Limit)
 	}
 
-	sql.WriteString(" ORDER BY name ASC")
-
 	alerts := make([]*m.Alert, 0)
 	if err := x.Sql(sql.String(), params...).Find(&alerts); err != nil {
 		return err
@@ -115,7 +178,7 @@ func HandleAlertsQuery(query *m.GetAlertsQuery) error {
 	return nil
 }
 
-func DeleteAlertDefinition(dashboardId int64, sess *xorm.Session) error {
+func DeleteAlertDefinition(dashboardId int64, sess *session) error {
 	alerts := make([]*m.Alert, 0)
 	sess.Where("dashboard_id = ?", dashboardId).Find(&alerts)
 
@@ -127,8 +190,8 @@ func DeleteAlertDefinition(dashboardId int64, sess *xorm.Session) error {
 }
 
 func SaveAlerts(cmd *m.SaveAlertsCommand) error {
-	return inTransaction(func(sess *xorm.Session) error {
-		existingAlerts, err := GetAlertsByDashboardId2(cmd.DashboardId, sess)
+	return inTransaction2(func(sess *session) error {
+		existingAlerts, err := GetAlertsByDashboardId2(cmd.DashboardId, sess.Session)
 		if err != nil {
 			return err
 		}
@@ -145,7 +208,7 @@ func SaveAlerts(cmd *m.SaveAlertsCommand) error {
 	})
 }
 
-func upsertAlerts(existingAlerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *xorm.Session) error {
+func upsertAlerts(existingAlerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *session) error {
 	for _, alert := range cmd.Alerts {
 		update := false
 		var alertToUpdate *m.Alert
@@ -168,7 +231,12 @@ func upsertAlerts(existingAlerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *xor
 					return err
 				}
 
+				if err := saveAlertLabels(alert, sess); err != nil {
+					return err
+				}
+
 				sqlog.Debug("Alert updated", "name", alert.Name, "id", alert.Id)
+				publishAlertUpdated(alert, sess)
 			}
 		} else {
 			alert.Updated = time.Now()
@@ -181,14 +249,44 @@ func upsertAlerts(existingAlerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *xor
 				return err
 			}
 
+			if err := saveAlertLabels(alert, sess); err != nil {
+				return err
+			}
+
 			sqlog.Debug("Alert inserted", "name", alert.Name, "id", alert.Id)
+			publishAlertUpdated(alert, sess)
 		}
 	}
 
 	return nil
 }
 
-func deleteMissingAlerts(alerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *xorm.Session) error {
+// saveAlertLabels replaces the labels of the alert with the ones in its
+// settings so alerts can be queried by label.
+func saveAlertLabels(alert *m.Alert, sess *session) error {
+	if _, err := sess.Exec("DELETE FROM alert_label WHERE alert_id = ?", alert.Id); err != nil {
+		return err
+	}
+
+	for name, value := range alert.GetLabels() {
+		if _, err := sess.Insert(&AlertLabel{AlertId: alert.Id, Name: name, Value: value}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func publishAlertUpdated(alert *m.Alert, sess *session) {
+	sess.publishAfterCommit(&events.AlertUpdated{
+		Timestamp:   time.Now(),
+		Id:          alert.Id,
+		OrgId:       alert.OrgId,
+		DashboardId: alert.DashboardId,
+	})
+}
+
+func deleteMissingAlerts(alerts []*m.Alert, cmd *m.SaveAlertsCommand, sess *session) error {
 	for _, missingAlert := range alerts {
 		missing := true
 
@@ -232,6 +330,7 @@ func SetAlertState(cmd *m.SetAlertStateCommand) error {
 		alert.StateChanges += 1
 		alert.NewStateDate = time.Now()
 		alert.EvalData = cmd.EvalData
+		alert.PendingSince = cmd.PendingSince
 
 		if cmd.Error == "" {
 			alert.ExecutionError = " " //without this space, xorm skips updating this field
@@ -239,13 +338,13 @@ func SetAlertState(cmd *m.SetAlertStateCommand) error {
 			alert.ExecutionError = cmd.Error
 		}
 
-		sess.Id(alert.Id).Update(&alert)
+		sess.Id(alert.Id).MustCols("pending_since").Update(&alert)
 		return nil
 	})
 }
 
 func PauseAlertRule(cmd *m.PauseAlertCommand) error {
-	return inTransaction(func(sess *xorm.Session) error {
+	return inTransaction2(func(sess *session) error {
 		alert := m.Alert{}
 
 		has, err := x.Where("id = ? AND org_id=?", cmd.AlertId, cmd.OrgId).Get(&alert)
@@ -265,6 +364,7 @@ func PauseAlertRule(cmd *m.PauseAlertCommand) error {
 		alert.State = newState
 
 		sess.Id(alert.Id).Update(&alert)
+		publishAlertUpdated(&alert, sess)
 		return nil
 	})
 }
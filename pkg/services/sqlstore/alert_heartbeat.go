@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/go-xorm/xorm"
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", HandleAlertHeartbeat)
+}
+
+func HandleAlertHeartbeat(cmd *m.HeartBeatCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		now := time.Now()
+		heartbeat := m.HeartBeat{}
+
+		has, err := sess.Table("alert_heartbeat").Where("server_id = ?", cmd.ServerId).Get(&heartbeat)
+		if err != nil {
+			return err
+		}
+
+		if has {
+			heartbeat.Updated = now
+			if _, err := sess.Table("alert_heartbeat").Id(heartbeat.Id).Cols("updated").Update(&heartbeat); err != nil {
+				return err
+			}
+		} else {
+			heartbeat = m.HeartBeat{
+				ServerId: cmd.ServerId,
+				Created:  now,
+				Updated:  now,
+			}
+
+			if _, err := sess.Table("alert_heartbeat").Insert(&heartbeat); err != nil {
+				return err
+			}
+		}
+
+		// servers that stopped sending heartbeats are no longer part of the cluster
+		expired := now.Add(-cmd.Timeout)
+		if _, err := sess.Exec("DELETE FROM alert_heartbeat WHERE updated < ?", expired); err != nil {
+			return err
+		}
+
+		servers := make([]*m.HeartBeat, 0)
+		if err := sess.Table("alert_heartbeat").Asc("created", "id").Find(&servers); err != nil {
+			return err
+		}
+
+		cmd.Result = m.AlertingClusterInfo{
+			ServerId:    cmd.ServerId,
+			ClusterSize: len(servers),
+		}
+
+		for i, server := range servers {
+			if server.ServerId == cmd.ServerId {
+				cmd.Result.UptimePosition = i + 1
+				break
+			}
+		}
+
+		return nil
+	})
+}
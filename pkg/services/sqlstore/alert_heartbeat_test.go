@@ -2,8 +2,9 @@ package sqlstore
 
 import (
 	"testing"
+	"time"
 
-	//	m "github.com/grafana/grafana/pkg/models"
+	m "github.com/grafana/grafana/pkg/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -11,8 +12,42 @@ func TestAlertingHeartbeatDataAccess(t *testing.T) {
 
 	Convey("Testing Alerting data access", t, func() {
 		InitTestDB(t)
-		//send heartbeat from server 1
-		//send heartbeat from server 2
 
+		server1 := &m.HeartBeatCommand{ServerId: "server1", Timeout: time.Minute}
+		err := HandleAlertHeartbeat(server1)
+		So(err, ShouldBeNil)
+
+		Convey("first server should be alone in the cluster", func() {
+			So(server1.Result.ClusterSize, ShouldEqual, 1)
+			So(server1.Result.UptimePosition, ShouldEqual, 1)
+		})
+
+		Convey("when second server sends heartbeat", func() {
+			server2 := &m.HeartBeatCommand{ServerId: "server2", Timeout: time.Minute}
+			err := HandleAlertHeartbeat(server2)
+			So(err, ShouldBeNil)
+
+			So(server2.Result.ClusterSize, ShouldEqual, 2)
+			So(server2.Result.UptimePosition, ShouldEqual, 2)
+
+			Convey("first server should keep its position", func() {
+				err := HandleAlertHeartbeat(server1)
+				So(err, ShouldBeNil)
+
+				So(server1.Result.ClusterSize, ShouldEqual, 2)
+				So(server1.Result.UptimePosition, ShouldEqual, 1)
+			})
+
+			Convey("servers that stopped sending heartbeats should be removed", func() {
+				_, err := x.Exec("UPDATE alert_heartbeat SET updated = ? WHERE server_id = ?", time.Now().Add(-time.Hour), "server1")
+				So(err, ShouldBeNil)
+
+				err = HandleAlertHeartbeat(server2)
+				So(err, ShouldBeNil)
+
+				So(server2.Result.ClusterSize, ShouldEqual, 1)
+				So(server2.Result.UptimePosition, ShouldEqual, 1)
+			})
+		})
 	})
 }
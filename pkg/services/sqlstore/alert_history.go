@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/go-xorm/xorm"
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveAlertHistoryEntry)
+	bus.AddHandler("sql", GetAlertHistory)
+	bus.AddHandler("sql", DeleteExpiredAlertHistory)
+}
+
+func SaveAlertHistoryEntry(cmd *m.SaveAlertHistoryEntryCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		entry := &m.AlertHistoryEntry{
+			OrgId:              cmd.OrgId,
+			AlertId:            cmd.AlertId,
+			Type:               cmd.Type,
+			PrevState:          cmd.PrevState,
+			NewState:           cmd.NewState,
+			EvalMatches:        cmd.EvalMatches,
+			Error:              cmd.Error,
+			NotifierId:         cmd.NotifierId,
+			NotificationStatus: cmd.NotificationStatus,
+			Epoch:              cmd.Epoch,
+		}
+
+		_, err := sess.Insert(entry)
+		return err
+	})
+}
+
+func DeleteExpiredAlertHistory(cmd *m.DeleteExpiredAlertHistoryCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		_, err := sess.Exec("DELETE FROM alert_history_entry WHERE epoch < ?", cmd.OlderThan.UnixNano()/int64(time.Millisecond))
+		return err
+	})
+}
+
+func GetAlertHistory(query *m.GetAlertHistoryQuery) error {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	if query.PerPage < 1 {
+		query.PerPage = 100
+	}
+
+	total, err := filterAlertHistory(query).Count(&m.AlertHistoryEntry{})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*m.AlertHistoryEntry, 0)
+	sess := filterAlertHistory(query).Desc("epoch", "id").Limit(query.PerPage, (query.Page-1)*query.PerPage)
+	if err := sess.Find(&entries); err != nil {
+		return err
+	}
+
+	query.Result = &m.AlertHistoryResult{
+		TotalCount: total,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+		Entries:    entries,
+	}
+
+	return nil
+}
+
+func filterAlertHistory(query *m.GetAlertHistoryQuery) *xorm.Session {
+	sess := x.Where("org_id = ? AND alert_id = ?", query.OrgId, query.AlertId)
+
+	if query.From > 0 {
+		sess.And("epoch >= ?", query.From)
+	}
+
+	if query.To > 0 {
+		sess.And("epoch <= ?", query.To)
+	}
+
+	if len(query.State) > 0 {
+		states := make([]interface{}, 0)
+		for _, state := range query.State {
+			states = append(states, state)
+		}
+		sess.In("new_state", states...)
+	}
+
+	if query.Type != "" {
+		sess.And("type = ?", string(query.Type))
+	}
+
+	return sess
+}
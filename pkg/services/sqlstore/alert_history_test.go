@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertHistoryDataAccess(t *testing.T) {
+	Convey("Testing alert history data access", t, func() {
+		InitTestDB(t)
+
+		save := func(alertId int64, entryType m.AlertHistoryEntryType, newState m.AlertStateType, epoch int64) {
+			err := SaveAlertHistoryEntry(&m.SaveAlertHistoryEntryCommand{
+				OrgId:       1,
+				AlertId:     alertId,
+				Type:        entryType,
+				NewState:    newState,
+				EvalMatches: simplejson.NewFromAny([]interface{}{map[string]interface{}{"metric": "server1", "value": 95}}),
+				Epoch:       epoch,
+			})
+			So(err, ShouldBeNil)
+		}
+
+		save(1, m.AlertHistoryStateChange, m.AlertStateAlerting, 1000)
+		save(1, m.AlertHistoryNotification, m.AlertStateAlerting, 2000)
+		save(1, m.AlertHistoryStateChange, m.AlertStateOK, 3000)
+		save(2, m.AlertHistoryStateChange, m.AlertStateAlerting, 1000)
+
+		Convey("Returns entries of the alert newest first", func() {
+			query := &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1}
+			So(GetAlertHistory(query), ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 3)
+			So(query.Result.Page, ShouldEqual, 1)
+			So(len(query.Result.Entries), ShouldEqual, 3)
+			So(query.Result.Entries[0].NewState, ShouldEqual, m.AlertStateOK)
+			So(query.Result.Entries[2].EvalMatches.GetIndex(0).Get("metric").MustString(), ShouldEqual, "server1")
+		})
+
+		Convey("Can page through entries", func() {
+			query := &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1, Page: 2, PerPage: 2}
+			So(GetAlertHistory(query), ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 3)
+			So(len(query.Result.Entries), ShouldEqual, 1)
+			So(query.Result.Entries[0].Epoch, ShouldEqual, 1000)
+		})
+
+		Convey("Can filter by time range, state and type", func() {
+			query := &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1, From: 1500, To: 3000}
+			So(GetAlertHistory(query), ShouldBeNil)
+			So(query.Result.TotalCount, ShouldEqual, 2)
+
+			query = &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1, State: []string{"alerting"}}
+			So(GetAlertHistory(query), ShouldBeNil)
+			So(query.Result.TotalCount, ShouldEqual, 2)
+
+			query = &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1, Type: m.AlertHistoryStateChange}
+			So(GetAlertHistory(query), ShouldBeNil)
+			So(query.Result.TotalCount, ShouldEqual, 2)
+		})
+
+		Convey("Can delete expired entries", func() {
+			err := DeleteExpiredAlertHistory(&m.DeleteExpiredAlertHistoryCommand{OlderThan: time.Unix(2, 0)})
+			So(err, ShouldBeNil)
+
+			query := &m.GetAlertHistoryQuery{OrgId: 1, AlertId: 1}
+			So(GetAlertHistory(query), ShouldBeNil)
+			So(query.Result.TotalCount, ShouldEqual, 2)
+		})
+	})
+}
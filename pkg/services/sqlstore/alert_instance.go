@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"crypto/sha1"
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveAlertInstances)
+	bus.AddHandler("sql", GetAlertInstances)
+}
+
+// SaveAlertInstances inserts new instances, updates the state of changed
+// ones and deletes removed ones, leaving other instances of the alert as
+// they are.
+func SaveAlertInstances(cmd *m.SaveAlertInstancesCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		for _, id := range cmd.RemovedIds {
+			if _, err := sess.Exec("DELETE FROM alert_instance WHERE id = ? AND org_id = ? AND alert_id = ?", id, cmd.OrgId, cmd.AlertId); err != nil {
+				return err
+			}
+		}
+
+		for _, instance := range cmd.Instances {
+			instance.OrgId = cmd.OrgId
+			instance.AlertId = cmd.AlertId
+			instance.InstanceHash = getInstanceHash(instance.InstanceKey)
+
+			if instance.Id == 0 {
+				if _, err := sess.Insert(instance); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := sess.Id(instance.Id).Cols("state", "value", "first_seen", "resolved_at", "updated").Update(instance); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// getInstanceHash returns the indexed hash of an instance key, as keys of
+// series with many tags can be longer than an index allows.
+func getInstanceHash(key string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(key)))
+}
+
+func GetAlertInstances(query *m.GetAlertInstancesQuery) error {
+	instances := make([]*m.AlertInstance, 0)
+	if err := x.Where("org_id = ? AND alert_id = ?", query.OrgId, query.AlertId).Asc("instance_key").Find(&instances); err != nil {
+		return err
+	}
+
+	query.Result = instances
+	return nil
+}
@@ -0,0 +1,90 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertInstanceDataAccess(t *testing.T) {
+	Convey("Testing alert instance data access", t, func() {
+		InitTestDB(t)
+
+		now := time.Now()
+		cmd := &m.SaveAlertInstancesCommand{
+			OrgId:   1,
+			AlertId: 2,
+			Instances: []*m.AlertInstance{
+				{InstanceKey: "server2", Metric: "server2", State: m.AlertStateOK, FirstSeen: now, ResolvedAt: now, Updated: now},
+				{InstanceKey: "server1{host=a}", Metric: "server1", Tags: map[string]string{"host": "a"}, State: m.AlertStateAlerting, Value: 12.5, FirstSeen: now, Updated: now},
+			},
+		}
+
+		err := SaveAlertInstances(cmd)
+		So(err, ShouldBeNil)
+
+		Convey("Can read instances for alert", func() {
+			query := &m.GetAlertInstancesQuery{OrgId: 1, AlertId: 2}
+			err := GetAlertInstances(query)
+
+			So(err, ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 2)
+			So(query.Result[0].Metric, ShouldEqual, "server1")
+			So(query.Result[0].Tags["host"], ShouldEqual, "a")
+			So(query.Result[0].State, ShouldEqual, m.AlertStateAlerting)
+			So(query.Result[0].Value, ShouldEqual, 12.5)
+			So(query.Result[0].ResolvedAt.IsZero(), ShouldBeTrue)
+			So(query.Result[1].ResolvedAt.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("Saving again updates changed instances and removes others", func() {
+			resolved, firing := cmd.Instances[0], cmd.Instances[1]
+			firing.State = m.AlertStateOK
+			firing.ResolvedAt = now
+
+			cmd.Instances = []*m.AlertInstance{firing}
+			cmd.RemovedIds = []int64{resolved.Id}
+			So(SaveAlertInstances(cmd), ShouldBeNil)
+
+			query := &m.GetAlertInstancesQuery{OrgId: 1, AlertId: 2}
+			So(GetAlertInstances(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 1)
+			So(query.Result[0].Id, ShouldEqual, firing.Id)
+			So(query.Result[0].State, ShouldEqual, m.AlertStateOK)
+			So(query.Result[0].ResolvedAt.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("Can save instances with long keys", func() {
+			tags := make(map[string]string)
+			for i := 0; i < 20; i++ {
+				tags[fmt.Sprintf("label_%d", i)] = strings.Repeat("x", 20)
+			}
+
+			longKey := "http_requests_total{" + strings.Repeat("x", 300)
+			cmd.AlertId = 3
+			cmd.Instances = []*m.AlertInstance{
+				{InstanceKey: longKey + "a}", Metric: "http_requests_total", Tags: tags, State: m.AlertStateAlerting, FirstSeen: now, Updated: now},
+				{InstanceKey: longKey + "b}", Metric: "http_requests_total", Tags: tags, State: m.AlertStateAlerting, FirstSeen: now, Updated: now},
+			}
+			So(SaveAlertInstances(cmd), ShouldBeNil)
+
+			query := &m.GetAlertInstancesQuery{OrgId: 1, AlertId: 3}
+			So(GetAlertInstances(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 2)
+			So(query.Result[0].InstanceKey, ShouldEqual, longKey+"a}")
+			So(query.Result[0].InstanceHash, ShouldEqual, getInstanceHash(longKey+"a}"))
+			So(len(query.Result[0].InstanceHash), ShouldEqual, 40)
+			So(query.Result[1].InstanceKey, ShouldEqual, longKey+"b}")
+		})
+
+		Convey("Does not return instances from other orgs", func() {
+			query := &m.GetAlertInstancesQuery{OrgId: 2, AlertId: 2}
+			So(GetAlertInstances(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 0)
+		})
+	})
+}
@@ -18,6 +18,8 @@ func init() {
 	bus.AddHandler("sql", DeleteAlertNotification)
 	bus.AddHandler("sql", GetAlertNotificationsToSend)
 	bus.AddHandler("sql", GetAllAlertNotifications)
+	bus.AddHandler("sql", SetAlertNotificationState)
+	bus.AddHandler("sql", GetAlertNotificationStates)
 }
 
 func DeleteAlertNotification(cmd *m.DeleteAlertNotificationCommand) error {
@@ -29,6 +31,10 @@ func DeleteAlertNotification(cmd *m.DeleteAlertNotificationCommand) error {
 			return err
 		}
 
+		if _, err := sess.Exec("DELETE FROM alert_notification_delivery WHERE org_id = ? AND notifier_id = ?", cmd.OrgId, cmd.Id); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
@@ -59,7 +65,9 @@ func GetAlertNotificationsToSend(query *m.GetAlertNotificationsToSendQuery) erro
 										alert_notification.created,
 										alert_notification.updated,
 										alert_notification.settings,
-										alert_notification.is_default
+										alert_notification.is_default,
+										alert_notification.send_reminder,
+										alert_notification.frequency
 										FROM alert_notification
 	  							`)
 
@@ -97,7 +105,9 @@ func getAlertNotificationInternal(query *m.GetAlertNotificationsQuery, sess *xor
 										alert_notification.created,
 										alert_notification.updated,
 										alert_notification.settings,
-										alert_notification.is_default
+										alert_notification.is_default,
+										alert_notification.send_reminder,
+										alert_notification.frequency
 										FROM alert_notification
 	  							`)
 
@@ -143,14 +153,21 @@ func CreateAlertNotificationCommand(cmd *m.CreateAlertNotificationCommand) error
 			return fmt.Errorf("Alert notification name %s already exists", cmd.Name)
 		}
 
+		frequency, err := parseNotificationFrequency(cmd.SendReminder, cmd.Frequency)
+		if err != nil {
+			return err
+		}
+
 		alertNotification := &m.AlertNotification{
-			OrgId:     cmd.OrgId,
-			Name:      cmd.Name,
-			Type:      cmd.Type,
-			Settings:  cmd.Settings,
-			Created:   time.Now(),
-			Updated:   time.Now(),
-			IsDefault: cmd.IsDefault,
+			OrgId:        cmd.OrgId,
+			Name:         cmd.Name,
+			Type:         cmd.Type,
+			Settings:     cmd.Settings,
+			Created:      time.Now(),
+			Updated:      time.Now(),
+			IsDefault:    cmd.IsDefault,
+			SendReminder: cmd.SendReminder,
+			Frequency:    frequency,
 		}
 
 		if _, err = sess.Insert(alertNotification); err != nil {
@@ -180,13 +197,21 @@ func UpdateAlertNotification(cmd *m.UpdateAlertNotificationCommand) error {
 			return fmt.Errorf("Alert notification name %s already exists", cmd.Name)
 		}
 
+		frequency, err := parseNotificationFrequency(cmd.SendReminder, cmd.Frequency)
+		if err != nil {
+			return err
+		}
+
 		current.Updated = time.Now()
 		current.Settings = cmd.Settings
 		current.Name = cmd.Name
 		current.Type = cmd.Type
 		current.IsDefault = cmd.IsDefault
+		current.SendReminder = cmd.SendReminder
+		current.Frequency = frequency
 
-		sess.UseBool("is_default")
+		sess.UseBool("is_default", "send_reminder")
+		sess.MustCols("frequency")
 
 		if affected, err := sess.Id(cmd.Id).Update(current); err != nil {
 			return err
@@ -198,3 +223,55 @@ func UpdateAlertNotification(cmd *m.UpdateAlertNotificationCommand) error {
 		return nil
 	})
 }
+
+func parseNotificationFrequency(sendReminder bool, frequency string) (time.Duration, error) {
+	if !sendReminder {
+		return 0, nil
+	}
+
+	if frequency == "" {
+		return 0, m.ErrNotificationFrequencyNotFound
+	}
+
+	duration, err := time.ParseDuration(frequency)
+	if err != nil || duration <= 0 {
+		return 0, m.ErrNotificationFrequencyInvalid
+	}
+
+	return duration, nil
+}
+
+func SetAlertNotificationState(cmd *m.SetAlertNotificationStateCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		state := m.AlertNotificationState{}
+
+		has, err := sess.Where("org_id = ? AND alert_id = ? AND notifier_id = ?", cmd.OrgId, cmd.AlertId, cmd.NotifierId).Get(&state)
+		if err != nil {
+			return err
+		}
+
+		state.SentAt = cmd.SentAt
+
+		if has {
+			_, err = sess.Id(state.Id).Cols("sent_at").Update(&state)
+			return err
+		}
+
+		state.OrgId = cmd.OrgId
+		state.AlertId = cmd.AlertId
+		state.NotifierId = cmd.NotifierId
+
+		_, err = sess.Insert(&state)
+		return err
+	})
+}
+
+func GetAlertNotificationStates(query *m.GetAlertNotificationStatesQuery) error {
+	states := make([]*m.AlertNotificationState, 0)
+	if err := x.Where("org_id = ? AND alert_id = ?", query.OrgId, query.AlertId).Find(&states); err != nil {
+		return err
+	}
+
+	query.Result = states
+	return nil
+}
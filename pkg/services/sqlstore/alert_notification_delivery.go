@@ -0,0 +1,135 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/go-xorm/xorm"
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateAlertNotificationDelivery)
+	bus.AddHandler("sql", UpdateAlertNotificationDelivery)
+	bus.AddHandler("sql", ClaimAlertNotificationDelivery)
+	bus.AddHandler("sql", DeleteExpiredAlertNotificationDeliveries)
+	bus.AddHandler("sql", GetPendingAlertNotificationDeliveries)
+	bus.AddHandler("sql", GetAlertNotificationDeliveries)
+}
+
+// CreateAlertNotificationDelivery adds a delivery to the outbox and
+// supersedes the pending deliveries of the same alert and channel, so an
+// old notification is not sent after a newer one.
+func CreateAlertNotificationDelivery(cmd *m.CreateAlertNotificationDeliveryCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		sql := "UPDATE alert_notification_delivery SET status = ?, updated = ? WHERE org_id = ? AND alert_id = ? AND notifier_id = ? AND status = ?"
+		if _, err := sess.Exec(sql, string(m.AlertNotificationDeliverySuperseded), time.Now(), cmd.OrgId, cmd.AlertId, cmd.NotifierId, string(m.AlertNotificationDeliveryPending)); err != nil {
+			return err
+		}
+
+		delivery := &m.AlertNotificationDelivery{
+			OrgId:       cmd.OrgId,
+			AlertId:     cmd.AlertId,
+			NotifierId:  cmd.NotifierId,
+			Status:      m.AlertNotificationDeliveryPending,
+			Payload:     cmd.Payload,
+			NextAttempt: cmd.NextAttempt,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+
+		if _, err := sess.Insert(delivery); err != nil {
+			return err
+		}
+
+		cmd.Result = delivery
+		return nil
+	})
+}
+
+// UpdateAlertNotificationDelivery records the outcome of an attempt. Only
+// pending deliveries are updated, so a superseded delivery stays superseded.
+func UpdateAlertNotificationDelivery(cmd *m.UpdateAlertNotificationDeliveryCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		delivery := &m.AlertNotificationDelivery{
+			Status:      cmd.Status,
+			Attempts:    cmd.Attempts,
+			LastError:   cmd.LastError,
+			NextAttempt: cmd.NextAttempt,
+			Updated:     time.Now(),
+		}
+
+		affected, err := sess.Id(cmd.Id).Where("status = ?", string(m.AlertNotificationDeliveryPending)).Cols("status", "attempts", "last_error", "next_attempt", "updated").Update(delivery)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return m.ErrAlertNotificationDeliveryNotFound
+		}
+
+		return nil
+	})
+}
+
+func ClaimAlertNotificationDelivery(cmd *m.ClaimAlertNotificationDeliveryCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		sql := "UPDATE alert_notification_delivery SET next_attempt = ?, updated = ? WHERE id = ? AND status = ? AND next_attempt <= ?"
+		res, err := sess.Exec(sql, cmd.Until, cmd.Now, cmd.Id, string(m.AlertNotificationDeliveryPending), cmd.Now)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		cmd.Claimed = affected == 1
+		return nil
+	})
+}
+
+// DeleteExpiredAlertNotificationDeliveries removes finished deliveries,
+// pending ones are kept until they are sent or given up on.
+func DeleteExpiredAlertNotificationDeliveries(cmd *m.DeleteExpiredAlertNotificationDeliveriesCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		sql := "DELETE FROM alert_notification_delivery WHERE status != ? AND updated < ?"
+		_, err := sess.Exec(sql, string(m.AlertNotificationDeliveryPending), cmd.OlderThan)
+		return err
+	})
+}
+
+func GetPendingAlertNotificationDeliveries(query *m.GetPendingAlertNotificationDeliveriesQuery) error {
+	sess := x.Where("status = ? AND next_attempt <= ?", string(m.AlertNotificationDeliveryPending), query.DueBefore).Asc("next_attempt", "id")
+	if query.Limit > 0 {
+		sess.Limit(query.Limit)
+	}
+
+	deliveries := make([]*m.AlertNotificationDelivery, 0)
+	if err := sess.Find(&deliveries); err != nil {
+		return err
+	}
+
+	query.Result = deliveries
+	return nil
+}
+
+func GetAlertNotificationDeliveries(query *m.GetAlertNotificationDeliveriesQuery) error {
+	sess := x.Where("org_id = ? AND notifier_id = ?", query.OrgId, query.NotifierId)
+	if query.Status != "" {
+		sess.And("status = ?", string(query.Status))
+	}
+
+	if query.Limit > 0 {
+		sess.Limit(query.Limit)
+	}
+
+	deliveries := make([]*m.AlertNotificationDelivery, 0)
+	if err := sess.Desc("id").Find(&deliveries); err != nil {
+		return err
+	}
+
+	query.Result = deliveries
+	return nil
+}
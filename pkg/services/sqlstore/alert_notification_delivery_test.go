@@ -0,0 +1,146 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertNotificationDeliveryDataAccess(t *testing.T) {
+	Convey("Testing alert notification delivery data access", t, func() {
+		InitTestDB(t)
+
+		now := time.Now()
+		payload := simplejson.New()
+		payload.Set("state", "alerting")
+
+		cmd := &m.CreateAlertNotificationDeliveryCommand{
+			OrgId:       1,
+			AlertId:     2,
+			NotifierId:  3,
+			Payload:     payload,
+			NextAttempt: now.Add(time.Minute),
+		}
+
+		err := CreateAlertNotificationDelivery(cmd)
+		So(err, ShouldBeNil)
+		So(cmd.Result.Id, ShouldNotEqual, 0)
+		So(cmd.Result.Status, ShouldEqual, m.AlertNotificationDeliveryPending)
+
+		Convey("Is not returned as pending before next attempt", func() {
+			query := &m.GetPendingAlertNotificationDeliveriesQuery{DueBefore: now}
+			So(GetPendingAlertNotificationDeliveries(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 0)
+		})
+
+		Convey("Is returned as pending after next attempt", func() {
+			query := &m.GetPendingAlertNotificationDeliveriesQuery{DueBefore: now.Add(2 * time.Minute)}
+			So(GetPendingAlertNotificationDeliveries(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 1)
+			So(query.Result[0].Payload.Get("state").MustString(), ShouldEqual, "alerting")
+		})
+
+		Convey("Can update delivery status", func() {
+			err := UpdateAlertNotificationDelivery(&m.UpdateAlertNotificationDeliveryCommand{
+				Id:          cmd.Result.Id,
+				Status:      m.AlertNotificationDeliveryFailed,
+				Attempts:    2,
+				LastError:   "Webhook response status 502 Bad Gateway",
+				NextAttempt: now,
+			})
+			So(err, ShouldBeNil)
+
+			query := &m.GetAlertNotificationDeliveriesQuery{OrgId: 1, NotifierId: 3}
+			So(GetAlertNotificationDeliveries(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 1)
+			So(query.Result[0].Status, ShouldEqual, m.AlertNotificationDeliveryFailed)
+			So(query.Result[0].Attempts, ShouldEqual, 2)
+			So(query.Result[0].LastError, ShouldEqual, "Webhook response status 502 Bad Gateway")
+
+			Convey("Failed deliveries are not pending", func() {
+				query := &m.GetPendingAlertNotificationDeliveriesQuery{DueBefore: now.Add(time.Hour)}
+				So(GetPendingAlertNotificationDeliveries(query), ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 0)
+			})
+
+			Convey("Can filter by status", func() {
+				query := &m.GetAlertNotificationDeliveriesQuery{OrgId: 1, NotifierId: 3, Status: m.AlertNotificationDeliverySent}
+				So(GetAlertNotificationDeliveries(query), ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 0)
+			})
+
+			Convey("Can delete finished deliveries", func() {
+				err := DeleteExpiredAlertNotificationDeliveries(&m.DeleteExpiredAlertNotificationDeliveriesCommand{OlderThan: time.Now().Add(time.Hour)})
+				So(err, ShouldBeNil)
+
+				query := &m.GetAlertNotificationDeliveriesQuery{OrgId: 1, NotifierId: 3}
+				So(GetAlertNotificationDeliveries(query), ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 0)
+			})
+		})
+
+		Convey("Newer delivery supersedes pending delivery of same alert and channel", func() {
+			payload := simplejson.New()
+			payload.Set("state", "ok")
+
+			newer := &m.CreateAlertNotificationDeliveryCommand{OrgId: 1, AlertId: 2, NotifierId: 3, Payload: payload, NextAttempt: now.Add(time.Minute)}
+			So(CreateAlertNotificationDelivery(newer), ShouldBeNil)
+
+			other := &m.CreateAlertNotificationDeliveryCommand{OrgId: 1, AlertId: 2, NotifierId: 4, Payload: payload, NextAttempt: now.Add(time.Minute)}
+			So(CreateAlertNotificationDelivery(other), ShouldBeNil)
+
+			query := &m.GetPendingAlertNotificationDeliveriesQuery{DueBefore: now.Add(2 * time.Minute)}
+			So(GetPendingAlertNotificationDeliveries(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 2)
+			So(query.Result[0].Id, ShouldEqual, newer.Result.Id)
+			So(query.Result[0].Payload.Get("state").MustString(), ShouldEqual, "ok")
+			So(query.Result[1].Id, ShouldEqual, other.Result.Id)
+
+			Convey("Superseded delivery is not made pending again by its update", func() {
+				err := UpdateAlertNotificationDelivery(&m.UpdateAlertNotificationDeliveryCommand{
+					Id:          cmd.Result.Id,
+					Status:      m.AlertNotificationDeliveryPending,
+					Attempts:    2,
+					NextAttempt: now,
+				})
+				So(err, ShouldEqual, m.ErrAlertNotificationDeliveryNotFound)
+
+				query := &m.GetAlertNotificationDeliveriesQuery{OrgId: 1, NotifierId: 3, Status: m.AlertNotificationDeliverySuperseded}
+				So(GetAlertNotificationDeliveries(query), ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 1)
+				So(query.Result[0].Id, ShouldEqual, cmd.Result.Id)
+			})
+		})
+
+		Convey("Due delivery can only be claimed once", func() {
+			claimAt := now.Add(2 * time.Minute)
+			first := &m.ClaimAlertNotificationDeliveryCommand{Id: cmd.Result.Id, Now: claimAt, Until: claimAt.Add(time.Minute)}
+			So(ClaimAlertNotificationDelivery(first), ShouldBeNil)
+			So(first.Claimed, ShouldBeTrue)
+
+			second := &m.ClaimAlertNotificationDeliveryCommand{Id: cmd.Result.Id, Now: claimAt, Until: claimAt.Add(time.Minute)}
+			So(ClaimAlertNotificationDelivery(second), ShouldBeNil)
+			So(second.Claimed, ShouldBeFalse)
+
+			Convey("And not before it is due", func() {
+				early := &m.ClaimAlertNotificationDeliveryCommand{Id: cmd.Result.Id, Now: now, Until: now.Add(time.Minute)}
+				So(ClaimAlertNotificationDelivery(early), ShouldBeNil)
+				So(early.Claimed, ShouldBeFalse)
+			})
+		})
+
+		Convey("Updating missing delivery returns not found", func() {
+			err := UpdateAlertNotificationDelivery(&m.UpdateAlertNotificationDeliveryCommand{Id: 999, Status: m.AlertNotificationDeliverySent})
+			So(err, ShouldEqual, m.ErrAlertNotificationDeliveryNotFound)
+		})
+
+		Convey("Does not return deliveries from other orgs", func() {
+			query := &m.GetAlertNotificationDeliveriesQuery{OrgId: 2, NotifierId: 3}
+			So(GetAlertNotificationDeliveries(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 0)
+		})
+	})
+}
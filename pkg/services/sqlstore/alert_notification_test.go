@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	m "github.com/grafana/grafana/pkg/models"
@@ -59,6 +60,92 @@ func TestAlertNotificationSQLAccess(t *testing.T) {
 			})
 		})
 
+		Convey("Cannot save Alert Notification with reminder but without frequency", func() {
+			cmd := &m.CreateAlertNotificationCommand{
+				Name:         "ops",
+				Type:         "email",
+				OrgId:        1,
+				SendReminder: true,
+				Settings:     simplejson.New(),
+			}
+
+			err = CreateAlertNotificationCommand(cmd)
+			So(err, ShouldEqual, m.ErrNotificationFrequencyNotFound)
+		})
+
+		Convey("Cannot save Alert Notification with invalid or negative frequency", func() {
+			for _, frequency := range []string{"abc", "-10m"} {
+				cmd := &m.CreateAlertNotificationCommand{
+					Name:         "ops",
+					Type:         "email",
+					OrgId:        1,
+					SendReminder: true,
+					Frequency:    frequency,
+					Settings:     simplejson.New(),
+				}
+
+				err = CreateAlertNotificationCommand(cmd)
+				So(err, ShouldEqual, m.ErrNotificationFrequencyInvalid)
+			}
+		})
+
+		Convey("Can save Alert Notification with reminder", func() {
+			cmd := &m.CreateAlertNotificationCommand{
+				Name:         "ops",
+				Type:         "email",
+				OrgId:        1,
+				SendReminder: true,
+				Frequency:    "10m",
+				Settings:     simplejson.New(),
+			}
+
+			err = CreateAlertNotificationCommand(cmd)
+			So(err, ShouldBeNil)
+			So(cmd.Result.SendReminder, ShouldBeTrue)
+			So(cmd.Result.Frequency, ShouldEqual, 10*time.Minute)
+
+			Convey("Can disable reminder", func() {
+				newCmd := &m.UpdateAlertNotificationCommand{
+					Name:         "ops",
+					Type:         "email",
+					OrgId:        1,
+					SendReminder: false,
+					Settings:     simplejson.New(),
+					Id:           cmd.Result.Id,
+				}
+
+				err := UpdateAlertNotification(newCmd)
+				So(err, ShouldBeNil)
+
+				query := &m.GetAlertNotificationsQuery{OrgId: 1, Id: cmd.Result.Id}
+				err = GetAlertNotifications(query)
+				So(err, ShouldBeNil)
+				So(query.Result.SendReminder, ShouldBeFalse)
+				So(query.Result.Frequency, ShouldEqual, 0)
+			})
+		})
+
+		Convey("Can save when a notification was sent for an alert", func() {
+			sentAt := time.Unix(1000, 0)
+			cmd := &m.SetAlertNotificationStateCommand{OrgId: 1, AlertId: 2, NotifierId: 3, SentAt: sentAt}
+
+			err = SetAlertNotificationState(cmd)
+			So(err, ShouldBeNil)
+
+			Convey("and update it when sent again", func() {
+				cmd.SentAt = sentAt.Add(time.Hour)
+				err = SetAlertNotificationState(cmd)
+				So(err, ShouldBeNil)
+
+				query := &m.GetAlertNotificationStatesQuery{OrgId: 1, AlertId: 2}
+				err = GetAlertNotificationStates(query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 1)
+				So(query.Result[0].NotifierId, ShouldEqual, 3)
+				So(query.Result[0].SentAt.Unix(), ShouldEqual, sentAt.Add(time.Hour).Unix())
+			})
+		})
+
 		Convey("Can search using an array of ids", func() {
 			cmd1 := m.CreateAlertNotificationCommand{Name: "nagios", Type: "webhook", OrgId: 1, Settings: simplejson.New()}
 			cmd2 := m.CreateAlertNotificationCommand{Name: "slack", Type: "webhook", OrgId: 1, Settings: simplejson.New()}
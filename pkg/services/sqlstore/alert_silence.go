@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/go-xorm/xorm"
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateAlertSilence)
+	bus.AddHandler("sql", UpdateAlertSilence)
+	bus.AddHandler("sql", DeleteAlertSilence)
+	bus.AddHandler("sql", GetAlertSilenceById)
+	bus.AddHandler("sql", GetAlertSilences)
+}
+
+func CreateAlertSilence(cmd *m.CreateAlertSilenceCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		silence := &m.AlertSilence{
+			OrgId:       cmd.OrgId,
+			DashboardId: cmd.DashboardId,
+			AlertId:     cmd.AlertId,
+			Tag:         cmd.Tag,
+			NameRegex:   cmd.NameRegex,
+			StartsAt:    cmd.StartsAt,
+			EndsAt:      cmd.EndsAt,
+			Comment:     cmd.Comment,
+			CreatedBy:   cmd.UserId,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+
+		if _, err := sess.Insert(silence); err != nil {
+			return err
+		}
+
+		cmd.Result = silence
+		return nil
+	})
+}
+
+func UpdateAlertSilence(cmd *m.UpdateAlertSilenceCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		silence := m.AlertSilence{}
+
+		if has, err := sess.Where("id = ? AND org_id = ?", cmd.Id, cmd.OrgId).Get(&silence); err != nil {
+			return err
+		} else if !has {
+			return m.ErrAlertSilenceNotFound
+		}
+
+		silence.DashboardId = cmd.DashboardId
+		silence.AlertId = cmd.AlertId
+		silence.Tag = cmd.Tag
+		silence.NameRegex = cmd.NameRegex
+		silence.StartsAt = cmd.StartsAt
+		silence.EndsAt = cmd.EndsAt
+		silence.Comment = cmd.Comment
+		silence.Updated = time.Now()
+
+		if _, err := sess.Id(silence.Id).AllCols().Update(&silence); err != nil {
+			return err
+		}
+
+		cmd.Result = &silence
+		return nil
+	})
+}
+
+func DeleteAlertSilence(cmd *m.DeleteAlertSilenceCommand) error {
+	return inTransaction(func(sess *xorm.Session) error {
+		_, err := sess.Exec("DELETE FROM alert_silence WHERE id = ? AND org_id = ?", cmd.Id, cmd.OrgId)
+		return err
+	})
+}
+
+func GetAlertSilenceById(query *m.GetAlertSilenceByIdQuery) error {
+	silence := m.AlertSilence{}
+
+	if has, err := x.Where("id = ? AND org_id = ?", query.Id, query.OrgId).Get(&silence); err != nil {
+		return err
+	} else if !has {
+		return m.ErrAlertSilenceNotFound
+	}
+
+	query.Result = &silence
+	return nil
+}
+
+func GetAlertSilences(query *m.GetAlertSilencesQuery) error {
+	sess := x.Where("org_id = ?", query.OrgId)
+
+	if !query.ActiveAt.IsZero() {
+		sess.And("starts_at <= ? AND ends_at > ?", query.ActiveAt, query.ActiveAt)
+	}
+
+	silences := make([]*m.AlertSilence, 0)
+	if err := sess.Asc("starts_at").Find(&silences); err != nil {
+		return err
+	}
+
+	query.Result = silences
+	return nil
+}
@@ -0,0 +1,96 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertSilenceDataAccess(t *testing.T) {
+	Convey("Testing alert silence data access", t, func() {
+		InitTestDB(t)
+
+		now := time.Now()
+		cmd := &m.CreateAlertSilenceCommand{
+			OrgId:     1,
+			UserId:    2,
+			NameRegex: "^cpu",
+			StartsAt:  now.Add(-time.Hour),
+			EndsAt:    now.Add(time.Hour),
+			Comment:   "planned maintenance",
+		}
+
+		err := CreateAlertSilence(cmd)
+		So(err, ShouldBeNil)
+		So(cmd.Result.Id, ShouldNotEqual, 0)
+		So(cmd.Result.CreatedBy, ShouldEqual, 2)
+
+		expired := &m.CreateAlertSilenceCommand{
+			OrgId:    1,
+			AlertId:  10,
+			StartsAt: now.Add(-time.Hour * 2),
+			EndsAt:   now.Add(-time.Hour),
+		}
+		So(CreateAlertSilence(expired), ShouldBeNil)
+
+		Convey("Can get silence by id", func() {
+			query := &m.GetAlertSilenceByIdQuery{Id: cmd.Result.Id, OrgId: 1}
+			err := GetAlertSilenceById(query)
+
+			So(err, ShouldBeNil)
+			So(query.Result.NameRegex, ShouldEqual, "^cpu")
+			So(query.Result.Comment, ShouldEqual, "planned maintenance")
+		})
+
+		Convey("Cannot get silence from other org", func() {
+			query := &m.GetAlertSilenceByIdQuery{Id: cmd.Result.Id, OrgId: 2}
+			err := GetAlertSilenceById(query)
+
+			So(err, ShouldEqual, m.ErrAlertSilenceNotFound)
+		})
+
+		Convey("Can list all silences", func() {
+			query := &m.GetAlertSilencesQuery{OrgId: 1}
+			err := GetAlertSilences(query)
+
+			So(err, ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 2)
+		})
+
+		Convey("Can list active silences", func() {
+			query := &m.GetAlertSilencesQuery{OrgId: 1, ActiveAt: now}
+			err := GetAlertSilences(query)
+
+			So(err, ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 1)
+			So(query.Result[0].Id, ShouldEqual, cmd.Result.Id)
+		})
+
+		Convey("Can update silence", func() {
+			update := &m.UpdateAlertSilenceCommand{
+				Id:       cmd.Result.Id,
+				OrgId:    1,
+				StartsAt: cmd.StartsAt,
+				EndsAt:   cmd.EndsAt,
+				Comment:  "extended",
+			}
+
+			err := UpdateAlertSilence(update)
+			So(err, ShouldBeNil)
+			So(update.Result.Comment, ShouldEqual, "extended")
+			So(update.Result.NameRegex, ShouldEqual, "")
+			So(update.Result.CreatedBy, ShouldEqual, 2)
+		})
+
+		Convey("Can delete silence", func() {
+			err := DeleteAlertSilence(&m.DeleteAlertSilenceCommand{Id: cmd.Result.Id, OrgId: 1})
+			So(err, ShouldBeNil)
+
+			query := &m.GetAlertSilencesQuery{OrgId: 1}
+			So(GetAlertSilences(query), ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 1)
+		})
+	})
+}
@@ -140,6 +140,66 @@ func TestAlertingDataAccess(t *testing.T) {
 			})
 		})
 
+		Convey("Filtering alerts by severity and labels", func() {
+			labels1, _ := simplejson.NewJson([]byte(`{ "labels": { "team": "payments", "env": "prod" } }`))
+			labels2, _ := simplejson.NewJson([]byte(`{ "labels": { "team": "ops" } }`))
+
+			filterCmd := m.SaveAlertsCommand{
+				DashboardId: testDash.Id,
+				OrgId:       1,
+				UserId:      1,
+				Alerts: []*m.Alert{
+					{PanelId: 1, DashboardId: testDash.Id, OrgId: 1, Name: "a", Severity: "", Settings: simplejson.New()},
+					{PanelId: 2, DashboardId: testDash.Id, OrgId: 1, Name: "b", Severity: "warning", Settings: labels1},
+					{PanelId: 3, DashboardId: testDash.Id, OrgId: 1, Name: "c", Severity: "critical", Settings: labels2},
+				},
+			}
+
+			So(SaveAlerts(&filterCmd), ShouldBeNil)
+
+			names := func(query *m.GetAlertsQuery) []string {
+				query.OrgId = 1
+				So(HandleAlertsQuery(query), ShouldBeNil)
+				result := make([]string, 0)
+				for _, alert := range query.Result {
+					result = append(result, alert.Name)
+				}
+				return result
+			}
+
+			Convey("critical should include alerts without severity", func() {
+				So(names(&m.GetAlertsQuery{Severity: []string{"critical"}}), ShouldResemble, []string{"a", "c"})
+				So(names(&m.GetAlertsQuery{Severity: []string{"warning", "info"}}), ShouldResemble, []string{"b"})
+			})
+
+			Convey("labels should all match", func() {
+				So(names(&m.GetAlertsQuery{Labels: map[string]string{"team": "payments"}}), ShouldResemble, []string{"b"})
+				So(names(&m.GetAlertsQuery{Labels: map[string]string{"team": ""}}), ShouldResemble, []string{"b", "c"})
+				So(names(&m.GetAlertsQuery{Labels: map[string]string{"team": "ops", "env": "prod"}}), ShouldResemble, []string{})
+			})
+
+			Convey("limit should apply after ordering", func() {
+				So(names(&m.GetAlertsQuery{Limit: 1}), ShouldResemble, []string{"a"})
+			})
+
+			Convey("labels should be replaced when the alert changes", func() {
+				labels3, _ := simplejson.NewJson([]byte(`{ "labels": { "team": "ops" } }`))
+				filterCmd.Alerts[1].Settings = labels3
+				So(SaveAlerts(&filterCmd), ShouldBeNil)
+
+				So(names(&m.GetAlertsQuery{Labels: map[string]string{"team": "payments"}}), ShouldResemble, []string{})
+				So(names(&m.GetAlertsQuery{Labels: map[string]string{"team": "ops"}}), ShouldResemble, []string{"b", "c"})
+			})
+
+			Convey("labels should be removed with the alert", func() {
+				So(DeleteAlertById(&m.DeleteAlertCommand{AlertId: filterCmd.Alerts[1].Id}), ShouldBeNil)
+
+				count, err := x.Where("alert_id = ?", filterCmd.Alerts[1].Id).Count(&AlertLabel{})
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 0)
+			})
+		})
+
 		Convey("When dashboard is removed", func() {
 			items := []*m.Alert{
 				{
@@ -20,6 +20,7 @@ func init() {
 	bus.AddHandler("sql", GetDashboardTags)
 	bus.AddHandler("sql", GetDashboardSlugById)
 	bus.AddHandler("sql", GetDashboardsByPluginId)
+	bus.AddHandler("sql", GetDashboardTagsById)
 }
 
 func SaveDashboard(cmd *m.SaveDashboardCommand) error {
@@ -219,6 +220,22 @@ func GetDashboardTags(query *m.GetDashboardTagsQuery) error {
 	return err
 }
 
+func GetDashboardTagsById(query *m.GetDashboardTagsByIdQuery) error {
+	var rawSql = `SELECT term FROM dashboard_tag WHERE dashboard_id=?`
+
+	tags := make([]*DashboardTag, 0)
+	if err := x.Sql(rawSql, query.DashboardId).Find(&tags); err != nil {
+		return err
+	}
+
+	query.Result = make([]string, 0)
+	for _, tag := range tags {
+		query.Result = append(query.Result, tag.Term)
+	}
+
+	return nil
+}
+
 func DeleteDashboard(cmd *m.DeleteDashboardCommand) error {
 	return inTransaction2(func(sess *session) error {
 		dashboard := m.Dashboard{Slug: cmd.Slug, OrgId: cmd.OrgId}
@@ -243,7 +260,7 @@ func DeleteDashboard(cmd *m.DeleteDashboardCommand) error {
 			}
 		}
 
-		if err := DeleteAlertDefinition(dashboard.Id, sess.Session); err != nil {
+		if err := DeleteAlertDefinition(dashboard.Id, sess); err != nil {
 			return nil
 		}
 
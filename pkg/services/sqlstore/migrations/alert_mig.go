@@ -45,6 +45,10 @@ func addAlertMigrations(mg *Migrator) {
 	mg.AddMigration("add index alert state", NewAddIndexMigration(alertV1, alertV1.Indices[1]))
 	mg.AddMigration("add index alert dashboard_id", NewAddIndexMigration(alertV1, alertV1.Indices[2]))
 
+	mg.AddMigration("Add column pending_since", NewAddColumnMigration(alertV1, &Column{
+		Name: "pending_since", Type: DB_DateTime, Nullable: true,
+	}))
+
 	alert_notification := Table{
 		Name: "alert_notification",
 		Columns: []*Column{
@@ -67,4 +71,145 @@ func addAlertMigrations(mg *Migrator) {
 	}))
 	mg.AddMigration("add index alert_notification org_id & name", NewAddIndexMigration(alert_notification, alert_notification.Indices[0]))
 
+	mg.AddMigration("Add column send_reminder", NewAddColumnMigration(alert_notification, &Column{
+		Name: "send_reminder", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("Add column frequency", NewAddColumnMigration(alert_notification, &Column{
+		Name: "frequency", Type: DB_BigInt, Nullable: true,
+	}))
+
+	alert_notification_state := Table{
+		Name: "alert_notification_state",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "notifier_id", Type: DB_BigInt, Nullable: false},
+			{Name: "sent_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "alert_id", "notifier_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_notification_state table v1", NewAddTableMigration(alert_notification_state))
+	mg.AddMigration("add index alert_notification_state org_id & alert_id & notifier_id", NewAddIndexMigration(alert_notification_state, alert_notification_state.Indices[0]))
+
+	alert_silence := Table{
+		Name: "alert_silence",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "tag", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "name_regex", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "starts_at", Type: DB_DateTime, Nullable: false},
+			{Name: "ends_at", Type: DB_DateTime, Nullable: false},
+			{Name: "comment", Type: DB_Text, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "ends_at"}, Type: IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_silence table v1", NewAddTableMigration(alert_silence))
+	mg.AddMigration("add index alert_silence org_id & ends_at", NewAddIndexMigration(alert_silence, alert_silence.Indices[0]))
+
+	// instance keys of series with many tags do not fit in an indexed column,
+	// so they are kept as text and a hash of the key is indexed instead
+	alert_instance := Table{
+		Name: "alert_instance",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "instance_hash", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "instance_key", Type: DB_Text, Nullable: false},
+			{Name: "metric", Type: DB_Text, Nullable: false},
+			{Name: "tags", Type: DB_Text, Nullable: true},
+			{Name: "state", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "value", Type: DB_Double, Nullable: false},
+			{Name: "first_seen", Type: DB_DateTime, Nullable: false},
+			{Name: "resolved_at", Type: DB_DateTime, Nullable: true},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "alert_id", "instance_hash"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_instance table v1", NewAddTableMigration(alert_instance))
+	mg.AddMigration("add index alert_instance org_id & alert_id & instance_hash", NewAddIndexMigration(alert_instance, alert_instance.Indices[0]))
+
+	alert_notification_delivery := Table{
+		Name: "alert_notification_delivery",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "notifier_id", Type: DB_BigInt, Nullable: false},
+			{Name: "status", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "attempts", Type: DB_Int, Nullable: false},
+			{Name: "last_error", Type: DB_Text, Nullable: true},
+			{Name: "payload", Type: DB_Text, Nullable: false},
+			{Name: "next_attempt", Type: DB_DateTime, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"status", "next_attempt"}, Type: IndexType},
+			{Cols: []string{"org_id", "notifier_id"}, Type: IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_notification_delivery table v1", NewAddTableMigration(alert_notification_delivery))
+	mg.AddMigration("add index alert_notification_delivery status & next_attempt", NewAddIndexMigration(alert_notification_delivery, alert_notification_delivery.Indices[0]))
+	mg.AddMigration("add index alert_notification_delivery org_id & notifier_id", NewAddIndexMigration(alert_notification_delivery, alert_notification_delivery.Indices[1]))
+
+	alert_label := Table{
+		Name: "alert_label",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "value", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"alert_id", "name"}, Type: UniqueIndex},
+			{Cols: []string{"name", "value"}, Type: IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_label table v1", NewAddTableMigration(alert_label))
+	mg.AddMigration("add unique index alert_label alert_id & name", NewAddIndexMigration(alert_label, alert_label.Indices[0]))
+	mg.AddMigration("add index alert_label name & value", NewAddIndexMigration(alert_label, alert_label.Indices[1]))
+
+	alert_history_entry := Table{
+		Name: "alert_history_entry",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "alert_id", Type: DB_BigInt, Nullable: false},
+			{Name: "type", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "prev_state", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "new_state", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "eval_matches", Type: DB_Text, Nullable: true},
+			{Name: "error", Type: DB_Text, Nullable: true},
+			{Name: "notifier_id", Type: DB_BigInt, Nullable: false},
+			{Name: "notification_status", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "epoch", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "alert_id", "epoch"}, Type: IndexType},
+			{Cols: []string{"epoch"}, Type: IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_history_entry table v1", NewAddTableMigration(alert_history_entry))
+	mg.AddMigration("add index alert_history_entry org_id & alert_id & epoch", NewAddIndexMigration(alert_history_entry, alert_history_entry.Indices[0]))
+	mg.AddMigration("add index alert_history_entry epoch", NewAddIndexMigration(alert_history_entry, alert_history_entry.Indices[1]))
 }
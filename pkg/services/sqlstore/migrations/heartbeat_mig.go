@@ -1,18 +1,21 @@
 package migrations
 
-// // create table
-// 	mg.AddMigration("create alert table v1", NewAddTableMigration(alertV1))
-//
-// 	alert_heartbeat := Table{
-// 		Name: "alert_heartbeat",
-// 		Columns: []*Column{
-// 			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
-// 			{Name: "server_id", Type: DB_NVarchar, Length: 50, Nullable: false},
-// 			{Name: "created", Type: DB_DateTime, Nullable: false},
-// 			{Name: "updated", Type: DB_DateTime, Nullable: false},
-// 		},
-// 	}
-//
-// 	mg.AddMigration("create alert_heartbeat table v1", NewAddTableMigration(alert_heartbeat))
-//
-//
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addHeartbeatMigrations(mg *Migrator) {
+	alert_heartbeat := Table{
+		Name: "alert_heartbeat",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "server_id", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"server_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_heartbeat table v1", NewAddTableMigration(alert_heartbeat))
+	mg.AddMigration("add unique index alert_heartbeat server_id", NewAddIndexMigration(alert_heartbeat, alert_heartbeat.Indices[0]))
+}
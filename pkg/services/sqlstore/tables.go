@@ -7,3 +7,10 @@ type DashboardTag struct {
 	DashboardId int64
 	Term        string
 }
+
+type AlertLabel struct {
+	Id      int64
+	AlertId int64
+	Name    string
+	Value   string
+}
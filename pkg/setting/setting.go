@@ -145,7 +145,14 @@ var (
 	Quota QuotaSettings
 
 	// Alerting
-	ExecuteAlerts bool
+	ExecuteAlerts                                 bool
+	AlertingMaxConcurrentEvaluations              int
+	AlertingMaxConcurrentEvaluationsPerDataSource int
+	AlertingHistoryRetentionDays                  int
+
+	// Query cache
+	QueryCacheEnabled   bool
+	QueryCacheMaxSizeMb int
 
 	// logger
 	logger log.Logger
@@ -556,6 +563,13 @@ func NewConfigContext(args *CommandLineArgs) error {
 
 	alerting := Cfg.Section("alerting")
 	ExecuteAlerts = alerting.Key("execute_alerts").MustBool(true)
+	AlertingMaxConcurrentEvaluations = alerting.Key("max_concurrent_evaluations").MustInt(100)
+	AlertingMaxConcurrentEvaluationsPerDataSource = alerting.Key("max_concurrent_evaluations_per_datasource").MustInt(0)
+	AlertingHistoryRetentionDays = alerting.Key("history_retention_days").MustInt(30)
+
+	queryCache := Cfg.Section("query_cache")
+	QueryCacheEnabled = queryCache.Key("enabled").MustBool(true)
+	QueryCacheMaxSizeMb = queryCache.Key("max_size_mb").MustInt(100)
 
 	readSessionConfig()
 	readSmtpSettings()
@@ -39,7 +39,13 @@ func (bg *Batch) process(ctx context.Context, queryContext *QueryContext) {
 		return
 	}
 
-	res := executor.Execute(ctx, bg.Queries, queryContext)
+	var res *BatchResult
+	if queryResultCache != nil {
+		res = queryResultCache.execute(ctx, executor, bg.Queries, queryContext)
+	} else {
+		res = executor.Execute(ctx, bg.Queries, queryContext)
+	}
+
 	bg.Done = true
 	queryContext.ResultsChan <- res
 }
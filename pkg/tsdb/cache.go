@@ -0,0 +1,311 @@
+package tsdb
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// size in bytes of a TimePoint, two null.Float values
+const timePointSize = 32
+
+var (
+	queryResultCache *queryCache
+	timeNow          = time.Now
+	tlog             = log.New("tsdb")
+	// sharedQueryTimeout bounds queries run for the cache, which no longer
+	// have the deadline of the request that started them.
+	sharedQueryTimeout = 30 * time.Second
+)
+
+func Init() {
+	queryResultCache = nil
+	if setting.QueryCacheEnabled {
+		queryResultCache = newQueryCache(int64(setting.QueryCacheMaxSizeMb) * 1024 * 1024)
+	}
+}
+
+// queryCache keeps the results of queries for one query interval. Results
+// are removed least recently used first when the cache grows over maxSize,
+// and queries that are already running are waited for instead of being
+// sent to the data source again.
+type queryCache struct {
+	sync.Mutex
+	maxSize  int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List
+	inflight map[string]*inflightQuery
+}
+
+type cacheEntry struct {
+	key     string
+	result  *QueryResult
+	size    int64
+	expires time.Time
+}
+
+type inflightQuery struct {
+	key    string
+	ttl    time.Duration
+	done   chan struct{}
+	result *QueryResult
+	err    error
+}
+
+func newQueryCache(maxSize int64) *queryCache {
+	return &queryCache{
+		maxSize:  maxSize,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inflight: make(map[string]*inflightQuery),
+	}
+}
+
+// execute runs the queries that are not cached or running on the executor
+// and returns the results of all queries. Queries that are cached once done
+// run on a context of their own, so a request giving up on its queries does
+// not cancel them for the other requests waiting for them.
+func (c *queryCache) execute(ctx context.Context, executor Executor, queries QuerySlice, queryContext *QueryContext) *BatchResult {
+	result := &BatchResult{QueryResults: make(map[string]*QueryResult)}
+	uncached := make(QuerySlice, 0)
+	shared := make(QuerySlice, 0)
+	running := make(map[string]*inflightQuery)
+	waiting := make(map[string]*inflightQuery)
+
+	for _, query := range queries {
+		key, ok := cacheKey(query, queryContext.TimeRange)
+		if !ok {
+			uncached = append(uncached, query)
+			continue
+		}
+
+		c.Lock()
+		if cached := c.get(key); cached != nil {
+			c.Unlock()
+			metrics.M_Tsdb_Query_Cache_Hit.Inc(1)
+			result.QueryResults[query.RefId] = copyQueryResult(cached, query.RefId)
+			continue
+		}
+
+		if call, exists := c.inflight[key]; exists {
+			c.Unlock()
+			metrics.M_Tsdb_Query_Cache_Hit.Inc(1)
+			waiting[query.RefId] = call
+			continue
+		}
+
+		call := &inflightQuery{
+			key:  key,
+			ttl:  time.Duration(query.IntervalMs) * time.Millisecond,
+			done: make(chan struct{}),
+		}
+		c.inflight[key] = call
+		c.Unlock()
+
+		metrics.M_Tsdb_Query_Cache_Miss.Inc(1)
+		running[query.RefId] = call
+		waiting[query.RefId] = call
+		shared = append(shared, query)
+	}
+
+	if len(shared) > 0 {
+		go c.run(executor, shared, queryContext.TimeRange, running)
+	}
+
+	if len(uncached) > 0 {
+		res := executor.Execute(ctx, uncached, queryContext)
+		result.Error = res.Error
+		result.Timings = res.Timings
+		for refId, queryRes := range res.QueryResults {
+			result.QueryResults[refId] = queryRes
+		}
+	}
+
+	for refId, call := range waiting {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			result.QueryResults[refId] = &QueryResult{RefId: refId, Error: ctx.Err()}
+			continue
+		}
+
+		if call.err != nil {
+			result.QueryResults[refId] = &QueryResult{RefId: refId, Error: call.err}
+		} else {
+			result.QueryResults[refId] = copyQueryResult(call.result, refId)
+		}
+	}
+
+	return result
+}
+
+// run executes the queries with a timeout of their own and hands their
+// results to the requests waiting for them, also when the executor panics.
+func (c *queryCache) run(executor Executor, queries QuerySlice, timeRange *TimeRange, running map[string]*inflightQuery) {
+	result := &BatchResult{QueryResults: make(map[string]*QueryResult)}
+
+	defer func() {
+		if err := recover(); err != nil {
+			tlog.Error("Query Panic", "error", err, "stack", log.Stack(1))
+			result.Error = fmt.Errorf("Query failed: %v", err)
+		}
+
+		for refId, call := range running {
+			queryRes := result.QueryResults[refId]
+
+			switch {
+			case result.Error != nil:
+				call.err = result.Error
+			case queryRes == nil:
+				call.err = errors.New("Query returned no result")
+			case queryRes.Error != nil:
+				call.err = queryRes.Error
+			default:
+				call.result = copyQueryResult(queryRes, "")
+			}
+
+			c.Lock()
+			delete(c.inflight, call.key)
+			if call.result != nil {
+				c.add(call.key, call.result, call.ttl)
+			}
+			c.Unlock()
+
+			close(call.done)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sharedQueryTimeout)
+	defer cancel()
+
+	res := executor.Execute(ctx, queries, NewQueryContext(queries, timeRange))
+	result.Error = res.Error
+	for refId, queryRes := range res.QueryResults {
+		result.QueryResults[refId] = queryRes
+	}
+}
+
+// get returns the result cached for the key, or nil when it is missing or
+// expired. The lock must be held.
+func (c *queryCache) get(key string) *QueryResult {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if timeNow().After(entry.expires) {
+		c.remove(element)
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+	return entry.result
+}
+
+// add caches the result and removes the least recently used results until
+// the cache fits in its max size. The lock must be held.
+func (c *queryCache) add(key string, result *QueryResult, ttl time.Duration) {
+	size := int64(len(key)) + resultSize(result)
+	if size > c.maxSize {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	entry := &cacheEntry{key: key, result: result, size: size, expires: timeNow().Add(ttl)}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += size
+
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *queryCache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	c.lru.Remove(element)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// cacheKey returns the key of the query, which is made of the data source,
+// the query model without refId, the time range rounded to the interval and
+// the max data points. Queries without interval, like alert queries, queries
+// depending on other queries and queries of data sources that disabled the
+// cache are not cached.
+func cacheKey(query *Query, timeRange *TimeRange) (string, bool) {
+	if query.IntervalMs <= 0 || len(query.Depends) > 0 || query.DataSource == nil || query.Model == nil || timeRange == nil {
+		return "", false
+	}
+
+	if query.DataSource.JsonData != nil && query.DataSource.JsonData.Get("disableQueryCache").MustBool(false) {
+		return "", false
+	}
+
+	model, err := query.Model.Map()
+	if err != nil {
+		return "", false
+	}
+
+	normalized := make(map[string]interface{})
+	for k, v := range model {
+		if k != "refId" {
+			normalized[k] = v
+		}
+	}
+
+	modelJson, err := json.Marshal(normalized)
+	if err != nil {
+		return "", false
+	}
+
+	from := timeRange.GetFromAsMsEpoch() / query.IntervalMs * query.IntervalMs
+	to := timeRange.GetToAsMsEpoch() / query.IntervalMs * query.IntervalMs
+
+	return fmt.Sprintf("%d:%d:%d:%d:%d:%s", query.DataSource.Id, from, to, query.IntervalMs, query.MaxDataPoints, modelJson), true
+}
+
+// copyQueryResult returns a copy of the result for another query, sharing
+// the series and tables.
+func copyQueryResult(result *QueryResult, refId string) *QueryResult {
+	queryRes := *result
+	queryRes.RefId = refId
+	return &queryRes
+}
+
+// resultSize estimates the memory used by the result in bytes.
+func resultSize(result *QueryResult) int64 {
+	size := int64(0)
+
+	for _, series := range result.Series {
+		size += int64(len(series.Name)) + int64(len(series.Points))*timePointSize
+	}
+
+	for _, table := range result.Tables {
+		for _, column := range table.Columns {
+			size += int64(len(column.Text) + len(column.Type))
+		}
+		for _, row := range table.Rows {
+			for _, value := range row {
+				size += 16
+				if s, ok := value.(string); ok {
+					size += int64(len(s))
+				}
+			}
+		}
+	}
+
+	return size
+}
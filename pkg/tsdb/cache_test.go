@@ -0,0 +1,177 @@
+package tsdb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type countingExecutor struct {
+	lock    sync.Mutex
+	calls   int
+	started chan bool
+	release chan bool
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, queries QuerySlice, queryContext *QueryContext) *BatchResult {
+	e.lock.Lock()
+	e.calls++
+	e.lock.Unlock()
+
+	if e.started != nil {
+		e.started <- true
+		<-e.release
+	}
+
+	if ctx.Err() != nil {
+		return &BatchResult{Error: ctx.Err()}
+	}
+
+	result := &BatchResult{QueryResults: make(map[string]*QueryResult)}
+	for _, query := range queries {
+		queryRes := NewQueryResult()
+		queryRes.RefId = query.RefId
+		queryRes.Series = append(queryRes.Series, NewTimeSeries("series", NewTimeSeriesPointsFromArgs(1, 1000, 2, 2000)))
+		result.QueryResults[query.RefId] = queryRes
+	}
+	return result
+}
+
+func TestQueryCache(t *testing.T) {
+	Convey("Given a query cache", t, func() {
+		now := time.Unix(1500000000, 0)
+		timeNow = func() time.Time { return now }
+		defer func() { timeNow = time.Now }()
+
+		cache := newQueryCache(1024 * 1024)
+		executor := &countingExecutor{}
+		dsInfo := &DataSourceInfo{Id: 1, JsonData: simplejson.New()}
+
+		newQueryContext := func(refId string, fromMs string, toMs string) *QueryContext {
+			query := &Query{
+				RefId:         refId,
+				Model:         simplejson.NewFromAny(map[string]interface{}{"refId": refId, "target": "apps.*.count"}),
+				DataSource:    dsInfo,
+				IntervalMs:    60000,
+				MaxDataPoints: 100,
+			}
+			return NewQueryContext(QuerySlice{query}, NewTimeRange(fromMs, toMs))
+		}
+
+		execute := func(queryContext *QueryContext) *BatchResult {
+			return cache.execute(context.Background(), executor, queryContext.Queries, queryContext)
+		}
+
+		Convey("returns cached results for queries with another refId in the same interval", func() {
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+			result := execute(newQueryContext("B", "1499996410000", "1500000010000"))
+
+			So(executor.calls, ShouldEqual, 1)
+			So(result.QueryResults["B"].RefId, ShouldEqual, "B")
+			So(len(result.QueryResults["B"].Series[0].Points), ShouldEqual, 2)
+		})
+
+		Convey("runs queries again for the next interval", func() {
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+			execute(newQueryContext("A", "1499996460000", "1500000060000"))
+
+			So(executor.calls, ShouldEqual, 2)
+		})
+
+		Convey("runs queries again when the result expired", func() {
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+			now = now.Add(2 * time.Minute)
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+
+			So(executor.calls, ShouldEqual, 2)
+		})
+
+		Convey("does not cache queries of data sources that disabled the cache", func() {
+			dsInfo.JsonData.Set("disableQueryCache", true)
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+			execute(newQueryContext("A", "1499996400000", "1500000000000"))
+
+			So(executor.calls, ShouldEqual, 2)
+		})
+
+		Convey("does not cache queries without interval", func() {
+			queryContext := newQueryContext("A", "1499996400000", "1500000000000")
+			queryContext.Queries[0].IntervalMs = 0
+			execute(queryContext)
+			execute(queryContext)
+
+			So(executor.calls, ShouldEqual, 2)
+		})
+
+		Convey("removes least recently used results when full", func() {
+			first := newQueryContext("A", "1499996400000", "1500000000000")
+			second := newQueryContext("A", "1499992800000", "1500000000000")
+			third := newQueryContext("A", "1499989200000", "1500000000000")
+
+			execute(first)
+			cache.maxSize = 2 * cache.size
+
+			execute(second)
+			execute(first)
+			execute(third)
+			So(executor.calls, ShouldEqual, 3)
+			So(cache.size, ShouldBeLessThanOrEqualTo, cache.maxSize)
+
+			execute(first)
+			So(executor.calls, ShouldEqual, 3)
+
+			execute(second)
+			So(executor.calls, ShouldEqual, 4)
+		})
+
+		Convey("runs identical queries only once at a time", func() {
+			executor.started = make(chan bool)
+			executor.release = make(chan bool)
+
+			results := make(chan *BatchResult, 2)
+			go func() { results <- execute(newQueryContext("A", "1499996400000", "1500000000000")) }()
+			<-executor.started
+
+			go func() { results <- execute(newQueryContext("B", "1499996400000", "1500000000000")) }()
+			time.Sleep(10 * time.Millisecond)
+
+			close(executor.release)
+			first, second := <-results, <-results
+
+			So(executor.calls, ShouldEqual, 1)
+			So(len(first.QueryResults), ShouldEqual, 1)
+			So(len(second.QueryResults), ShouldEqual, 1)
+		})
+
+		Convey("keeps running queries for other requests when the first one gives up", func() {
+			executor.started = make(chan bool)
+			executor.release = make(chan bool)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			canceled := make(chan *BatchResult)
+			go func() {
+				queryContext := newQueryContext("A", "1499996400000", "1500000000000")
+				canceled <- cache.execute(ctx, executor, queryContext.Queries, queryContext)
+			}()
+			<-executor.started
+
+			results := make(chan *BatchResult)
+			go func() { results <- execute(newQueryContext("B", "1499996400000", "1500000000000")) }()
+			time.Sleep(10 * time.Millisecond)
+
+			cancel()
+			So((<-canceled).QueryResults["A"].Error, ShouldEqual, context.Canceled)
+
+			close(executor.release)
+			result := <-results
+
+			So(executor.calls, ShouldEqual, 1)
+			So(result.QueryResults["B"].Error, ShouldBeNil)
+			So(len(result.QueryResults["B"].Series), ShouldEqual, 1)
+		})
+	})
+}
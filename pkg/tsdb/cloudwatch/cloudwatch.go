@@ -0,0 +1,450 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// cloudWatchClient is the part of the CloudWatch api the executor uses.
+type cloudWatchClient interface {
+	GetMetricStatistics(ctx context.Context, input *GetMetricStatisticsInput) (*GetMetricStatisticsOutput, error)
+	ListMetricsPages(ctx context.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error
+}
+
+type CloudWatchExecutor struct {
+	*tsdb.DataSourceInfo
+	getClient func(region string) cloudWatchClient
+}
+
+func NewCloudWatchExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+	e := &CloudWatchExecutor{DataSourceInfo: dsInfo}
+	e.getClient = e.newClient
+	return e
+}
+
+var (
+	plog              log.Logger
+	aliasFormat       *regexp.Regexp
+	extendedStatistic *regexp.Regexp
+)
+
+const (
+	// maxDataPoints is the number of datapoints CloudWatch returns for a
+	// single GetMetricStatistics request.
+	maxDataPoints = 1440
+	minPeriod     = 60
+	// maxDimensionSets limits the GetMetricStatistics requests a dimension
+	// wildcard can expand to.
+	maxDimensionSets = 100
+)
+
+func init() {
+	plog = log.New("tsdb.cloudwatch")
+	tsdb.RegisterExecutor("cloudwatch", NewCloudWatchExecutor)
+	aliasFormat = regexp.MustCompile(`\{\{(.+?)\}\}`)
+	extendedStatistic = regexp.MustCompile(`^p\d{2}(\.\d{1,2})?$`)
+}
+
+func (e *CloudWatchExecutor) newClient(region string) cloudWatchClient {
+	assumeRoleArn := ""
+	if e.JsonData != nil {
+		assumeRoleArn = e.JsonData.Get("assumeRoleArn").MustString()
+	}
+
+	cfg := &aws.Config{
+		Region:      aws.String(region),
+		Credentials: GetCredentials(e.Database, region, assumeRoleArn),
+	}
+
+	return &sdkClient{cloudwatch.New(session.New(cfg), cfg)}
+}
+
+// sdkClient sends GetMetricStatistics with our own input and output types
+// so extended statistics are passed on to the api. Requests are canceled
+// with the context of the query.
+type sdkClient struct {
+	*cloudwatch.CloudWatch
+}
+
+func (c *sdkClient) GetMetricStatistics(ctx context.Context, input *GetMetricStatisticsInput) (*GetMetricStatisticsOutput, error) {
+	op := &request.Operation{
+		Name:       "GetMetricStatistics",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+
+	output := &GetMetricStatisticsOutput{}
+	req := c.NewRequest(op, input, output)
+	req.HTTPRequest.Cancel = ctx.Done()
+	return output, req.Send()
+}
+
+func (c *sdkClient) ListMetricsPages(ctx context.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+	req, _ := c.ListMetricsRequest(input)
+	// the build handlers run for the request of every page
+	req.Handlers.Build.PushBack(func(r *request.Request) {
+		r.HTTPRequest.Cancel = ctx.Done()
+	})
+
+	return req.EachPage(func(page interface{}, lastPage bool) bool {
+		return fn(page.(*cloudwatch.ListMetricsOutput), lastPage)
+	})
+}
+
+// Execute runs the queries in parallel, each one sending a request per
+// dimension set to the api of its region.
+func (e *CloudWatchExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) *tsdb.BatchResult {
+	return tsdb.ExecuteInParallel(queries, func(queryModel *tsdb.Query) *tsdb.QueryResult {
+		return e.executeQuery(ctx, queryModel, queryContext)
+	})
+}
+
+func (e *CloudWatchExecutor) executeQuery(ctx context.Context, queryModel *tsdb.Query, queryContext *tsdb.QueryContext) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+	queryRes.RefId = queryModel.RefId
+
+	query, err := parseQuery(queryModel.Model, e.JsonData)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+	query.RefId = queryModel.RefId
+
+	startTime, err := queryContext.TimeRange.ParseFrom()
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	endTime, err := queryContext.TimeRange.ParseTo()
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	if !startTime.Before(endTime) {
+		queryRes.Error = fmt.Errorf("Invalid time range: Start time must be before end time")
+		return queryRes
+	}
+
+	query.Period = getPeriod(query, startTime, endTime, time.Now())
+
+	client := e.getClient(query.Region)
+
+	dimensionSets, err := expandDimensions(ctx, client, query)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	for _, dimensions := range dimensionSets {
+		if ctx.Err() != nil {
+			queryRes.Error = ctx.Err()
+			return queryRes
+		}
+
+		input := &GetMetricStatisticsInput{
+			Namespace:  aws.String(query.Namespace),
+			MetricName: aws.String(query.MetricName),
+			Dimensions: dimensions,
+			StartTime:  aws.Time(startTime),
+			EndTime:    aws.Time(endTime),
+			Period:     aws.Int64(query.Period),
+		}
+		if len(query.Statistics) > 0 {
+			input.Statistics = aws.StringSlice(query.Statistics)
+		}
+		if len(query.ExtendedStatistics) > 0 {
+			input.ExtendedStatistics = aws.StringSlice(query.ExtendedStatistics)
+		}
+
+		resp, err := client.GetMetricStatistics(ctx, input)
+		if err != nil {
+			queryRes.Error = err
+			return queryRes
+		}
+
+		queryRes.Series = append(queryRes.Series, parseResponse(resp, query, dimensions)...)
+	}
+
+	return queryRes
+}
+
+func parseQuery(model *simplejson.Json, jsonData *simplejson.Json) (*CloudWatchQuery, error) {
+	query := &CloudWatchQuery{
+		Region:     model.Get("region").MustString(),
+		Namespace:  model.Get("namespace").MustString(),
+		MetricName: model.Get("metricName").MustString(),
+		Dimensions: make(map[string]string),
+		Alias:      model.Get("alias").MustString("{{metric}}_{{stat}}"),
+	}
+
+	if query.Region == "" || query.Region == "default" {
+		query.Region = ""
+		if jsonData != nil {
+			query.Region = jsonData.Get("defaultRegion").MustString()
+		}
+	}
+
+	if query.Namespace == "" || query.MetricName == "" {
+		return nil, fmt.Errorf("Query needs a namespace and a metric name")
+	}
+
+	for name, value := range model.Get("dimensions").MustMap() {
+		if s, ok := value.(string); ok {
+			query.Dimensions[name] = s
+		} else {
+			return nil, fmt.Errorf("Invalid value for dimension %s", name)
+		}
+	}
+
+	for _, stat := range model.Get("statistics").MustStringArray() {
+		if extendedStatistic.MatchString(stat) {
+			query.ExtendedStatistics = append(query.ExtendedStatistics, stat)
+		} else {
+			query.Statistics = append(query.Statistics, stat)
+		}
+	}
+
+	if len(query.Statistics) == 0 && len(query.ExtendedStatistics) == 0 {
+		return nil, fmt.Errorf("Query needs at least one statistic")
+	}
+
+	period, err := parsePeriod(model.Get("period"))
+	if err != nil {
+		return nil, err
+	}
+	query.Period = period
+
+	return query, nil
+}
+
+// parsePeriod reads the period of the query editor in seconds, it is either
+// a number of seconds or an interval like 5m.
+func parsePeriod(model *simplejson.Json) (int64, error) {
+	if seconds, err := model.Int64(); err == nil {
+		return seconds, nil
+	}
+
+	value := strings.TrimSpace(model.MustString(""))
+	if value == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+
+	period, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid period %s", value)
+	}
+
+	return int64(period / time.Second), nil
+}
+
+// getPeriod returns the period in seconds, CloudWatch only keeps data of
+// older metrics at coarser resolutions and returns at most 1440 datapoints
+// per request so the period grows with the age and the size of the range.
+func getPeriod(query *CloudWatchQuery, startTime, endTime, now time.Time) int64 {
+	period := query.Period
+	if period == 0 {
+		if query.Namespace == "AWS/EC2" {
+			period = 300
+		} else {
+			period = minPeriod
+		}
+	}
+
+	periodUnit := int64(minPeriod)
+	age := now.Sub(startTime)
+	if age > 63*24*time.Hour {
+		periodUnit = 60 * 60
+	} else if age > 15*24*time.Hour {
+		periodUnit = 60 * 5
+	}
+
+	if period < periodUnit {
+		period = periodUnit
+	}
+
+	rangeSeconds := int64(endTime.Sub(startTime) / time.Second)
+	if rangeSeconds/period >= maxDataPoints {
+		period = int64(math.Ceil(float64(rangeSeconds)/maxDataPoints/float64(periodUnit))) * periodUnit
+	}
+
+	return period
+}
+
+// expandDimensions returns the dimension sets to request statistics for.
+// Dimensions with a * value are looked up with ListMetrics and every metric
+// with exactly the dimensions of the query gets its own set, up to
+// maxDimensionSets of them.
+func expandDimensions(ctx context.Context, client cloudWatchClient, query *CloudWatchQuery) ([][]*cloudwatch.Dimension, error) {
+	names := make([]string, 0, len(query.Dimensions))
+	hasWildcard := false
+	for name, value := range query.Dimensions {
+		names = append(names, name)
+		if value == "*" {
+			hasWildcard = true
+		}
+	}
+	sort.Strings(names)
+
+	if !hasWildcard {
+		dimensions := make([]*cloudwatch.Dimension, 0, len(names))
+		for _, name := range names {
+			dimensions = append(dimensions, &cloudwatch.Dimension{
+				Name:  aws.String(name),
+				Value: aws.String(query.Dimensions[name]),
+			})
+		}
+		return [][]*cloudwatch.Dimension{dimensions}, nil
+	}
+
+	filters := make([]*cloudwatch.DimensionFilter, 0, len(names))
+	for _, name := range names {
+		filter := &cloudwatch.DimensionFilter{Name: aws.String(name)}
+		if value := query.Dimensions[name]; value != "*" {
+			filter.Value = aws.String(value)
+		}
+		filters = append(filters, filter)
+	}
+
+	params := &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String(query.Namespace),
+		MetricName: aws.String(query.MetricName),
+		Dimensions: filters,
+	}
+
+	result := make([][]*cloudwatch.Dimension, 0)
+	err := client.ListMetricsPages(ctx, params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		for _, metric := range page.Metrics {
+			if len(metric.Dimensions) == len(names) {
+				result = append(result, sortDimensions(metric.Dimensions))
+			}
+		}
+		return !lastPage && len(result) <= maxDimensionSets && ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if len(result) > maxDimensionSets {
+		return nil, fmt.Errorf("Dimension wildcard matches more than %d metrics, narrow down the dimensions", maxDimensionSets)
+	}
+
+	return result, nil
+}
+
+func sortDimensions(dimensions []*cloudwatch.Dimension) []*cloudwatch.Dimension {
+	sorted := append([]*cloudwatch.Dimension{}, dimensions...)
+	sort.Sort(byDimensionName(sorted))
+	return sorted
+}
+
+type byDimensionName []*cloudwatch.Dimension
+
+func (d byDimensionName) Len() int      { return len(d) }
+func (d byDimensionName) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byDimensionName) Less(i, j int) bool {
+	return aws.StringValue(d[i].Name) < aws.StringValue(d[j].Name)
+}
+
+type byTimestamp []*Datapoint
+
+func (d byTimestamp) Len() int      { return len(d) }
+func (d byTimestamp) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byTimestamp) Less(i, j int) bool {
+	return aws.TimeValue(d[i].Timestamp).Before(aws.TimeValue(d[j].Timestamp))
+}
+
+// parseResponse returns a series per statistic, gaps longer than the period
+// are marked with a null point so graphs do not connect them.
+func parseResponse(resp *GetMetricStatisticsOutput, query *CloudWatchQuery, dimensions []*cloudwatch.Dimension) tsdb.TimeSeriesSlice {
+	datapoints := append([]*Datapoint{}, resp.Datapoints...)
+	sort.Sort(byTimestamp(datapoints))
+
+	periodMs := float64(query.Period * 1000)
+	stats := append(append([]string{}, query.Statistics...), query.ExtendedStatistics...)
+	series := make(tsdb.TimeSeriesSlice, 0, len(stats))
+
+	for _, stat := range stats {
+		points := make(tsdb.TimeSeriesPoints, 0, len(datapoints))
+		lastTimestamp := float64(0)
+
+		for _, dp := range datapoints {
+			timestamp := float64(aws.TimeValue(dp.Timestamp).UnixNano() / int64(time.Millisecond))
+			if lastTimestamp != 0 && timestamp-lastTimestamp > periodMs {
+				points = append(points, tsdb.NewTimePoint(null.FloatFromPtr(nil), lastTimestamp+periodMs))
+			}
+			lastTimestamp = timestamp
+
+			points = append(points, tsdb.NewTimePoint(null.FloatFromPtr(getStatistic(dp, stat)), timestamp))
+		}
+
+		series = append(series, tsdb.NewTimeSeries(formatAlias(query, stat, dimensions), points))
+	}
+
+	return series
+}
+
+func getStatistic(dp *Datapoint, stat string) *float64 {
+	switch stat {
+	case "Average":
+		return dp.Average
+	case "Maximum":
+		return dp.Maximum
+	case "Minimum":
+		return dp.Minimum
+	case "Sum":
+		return dp.Sum
+	case "SampleCount":
+		return dp.SampleCount
+	default:
+		return dp.ExtendedStatistics[stat]
+	}
+}
+
+// formatAlias replaces {{region}}, {{namespace}}, {{metric}}, {{stat}} and
+// {{dimension name}} in the alias like the query editor does.
+func formatAlias(query *CloudWatchQuery, stat string, dimensions []*cloudwatch.Dimension) string {
+	data := map[string]string{
+		"region":    query.Region,
+		"namespace": query.Namespace,
+		"metric":    query.MetricName,
+		"stat":      stat,
+	}
+
+	for _, dimension := range dimensions {
+		data[aws.StringValue(dimension.Name)] = aws.StringValue(dimension.Value)
+	}
+
+	return aliasFormat.ReplaceAllStringFunc(query.Alias, func(in string) string {
+		key := aliasFormat.FindStringSubmatch(in)[1]
+		if value, ok := data[key]; ok && value != "" {
+			return value
+		}
+		return key
+	})
+}
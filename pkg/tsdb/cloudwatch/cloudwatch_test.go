@@ -0,0 +1,298 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type cloudWatchClientStub struct {
+	metrics  []*cloudwatch.Metric
+	output   *GetMetricStatisticsOutput
+	requests []*GetMetricStatisticsInput
+}
+
+func (c *cloudWatchClientStub) GetMetricStatistics(ctx context.Context, input *GetMetricStatisticsInput) (*GetMetricStatisticsOutput, error) {
+	c.requests = append(c.requests, input)
+	return c.output, nil
+}
+
+func (c *cloudWatchClientStub) ListMetricsPages(ctx context.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+	fn(&cloudwatch.ListMetricsOutput{Metrics: c.metrics}, true)
+	return nil
+}
+
+func newMetric(dimensions ...string) *cloudwatch.Metric {
+	metric := &cloudwatch.Metric{}
+	for i := 0; i < len(dimensions); i += 2 {
+		metric.Dimensions = append(metric.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(dimensions[i]),
+			Value: aws.String(dimensions[i+1]),
+		})
+	}
+	return metric
+}
+
+func TestCloudWatch(t *testing.T) {
+	Convey("CloudWatch", t, func() {
+		jsonData := simplejson.NewFromAny(map[string]interface{}{"defaultRegion": "us-east-1"})
+
+		Convey("can parse query", func() {
+			model, _ := simplejson.NewJson([]byte(`{
+				"region": "default",
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": "i-123"},
+				"statistics": ["Average", "p99", "p99.9"],
+				"period": "5m"
+			}`))
+
+			query, err := parseQuery(model, jsonData)
+			So(err, ShouldBeNil)
+			So(query.Region, ShouldEqual, "us-east-1")
+			So(query.Dimensions["InstanceId"], ShouldEqual, "i-123")
+			So(query.Statistics, ShouldResemble, []string{"Average"})
+			So(query.ExtendedStatistics, ShouldResemble, []string{"p99", "p99.9"})
+			So(query.Period, ShouldEqual, 300)
+			So(query.Alias, ShouldEqual, "{{metric}}_{{stat}}")
+
+			model.Set("statistics", []interface{}{})
+			_, err = parseQuery(model, jsonData)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("can parse query without json data", func() {
+			model, _ := simplejson.NewJson([]byte(`{
+				"region": "default",
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"statistics": ["Average"]
+			}`))
+
+			query, err := parseQuery(model, nil)
+			So(err, ShouldBeNil)
+			So(query.Region, ShouldEqual, "")
+		})
+
+		Convey("can calculate period", func() {
+			now := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+			query := &CloudWatchQuery{Namespace: "AWS/EC2"}
+
+			So(getPeriod(query, now.Add(-time.Hour), now, now), ShouldEqual, 300)
+
+			query = &CloudWatchQuery{Namespace: "AWS/ELB", Period: 10}
+			So(getPeriod(query, now.Add(-time.Hour), now, now), ShouldEqual, 60)
+
+			Convey("grows the period to stay under the datapoint limit", func() {
+				So(getPeriod(query, now.Add(-3*24*time.Hour), now, now), ShouldEqual, 180)
+			})
+
+			Convey("uses coarser periods for old data", func() {
+				So(getPeriod(query, now.Add(-20*24*time.Hour), now.Add(-19*24*time.Hour), now), ShouldEqual, 300)
+				So(getPeriod(query, now.Add(-70*24*time.Hour), now.Add(-69*24*time.Hour), now), ShouldEqual, 3600)
+			})
+		})
+
+		Convey("can expand dimension wildcards", func() {
+			client := &cloudWatchClientStub{metrics: []*cloudwatch.Metric{
+				newMetric("InstanceId", "i-1"),
+				newMetric("InstanceId", "i-2"),
+				newMetric("InstanceId", "i-3", "AutoScalingGroupName", "asg"),
+			}}
+			query := &CloudWatchQuery{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Dimensions: map[string]string{"InstanceId": "*"}}
+
+			sets, err := expandDimensions(context.TODO(), client, query)
+			So(err, ShouldBeNil)
+			So(len(sets), ShouldEqual, 2)
+			So(*sets[1][0].Value, ShouldEqual, "i-2")
+		})
+
+		Convey("limits expanded dimension wildcards", func() {
+			client := &cloudWatchClientStub{}
+			for i := 0; i <= maxDimensionSets; i++ {
+				client.metrics = append(client.metrics, newMetric("InstanceId", fmt.Sprintf("i-%d", i)))
+			}
+			query := &CloudWatchQuery{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Dimensions: map[string]string{"InstanceId": "*"}}
+
+			_, err := expandDimensions(context.TODO(), client, query)
+			So(err, ShouldNotBeNil)
+
+			client.metrics = client.metrics[:maxDimensionSets]
+			sets, err := expandDimensions(context.TODO(), client, query)
+			So(err, ShouldBeNil)
+			So(len(sets), ShouldEqual, maxDimensionSets)
+		})
+
+		Convey("stops expanding dimension wildcards when canceled", func() {
+			client := &cloudWatchClientStub{metrics: []*cloudwatch.Metric{newMetric("InstanceId", "i-1")}}
+			query := &CloudWatchQuery{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Dimensions: map[string]string{"InstanceId": "*"}}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := expandDimensions(ctx, client, query)
+			So(err, ShouldEqual, context.Canceled)
+		})
+
+		Convey("can parse response", func() {
+			start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+			resp := &GetMetricStatisticsOutput{Datapoints: []*Datapoint{
+				{Timestamp: aws.Time(start.Add(3 * time.Minute)), Average: aws.Float64(30), ExtendedStatistics: map[string]*float64{"p99": aws.Float64(33)}},
+				{Timestamp: aws.Time(start), Average: aws.Float64(10), ExtendedStatistics: map[string]*float64{"p99": aws.Float64(11)}},
+				{Timestamp: aws.Time(start.Add(time.Minute)), Average: aws.Float64(20), ExtendedStatistics: map[string]*float64{"p99": aws.Float64(22)}},
+			}}
+			query := &CloudWatchQuery{
+				Region:             "us-east-1",
+				MetricName:         "CPUUtilization",
+				Statistics:         []string{"Average"},
+				ExtendedStatistics: []string{"p99"},
+				Period:             60,
+				Alias:              "{{InstanceId}} {{stat}} {{unknown}}",
+			}
+			dimensions := []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}}
+
+			series := parseResponse(resp, query, dimensions)
+			So(len(series), ShouldEqual, 2)
+			So(series[0].Name, ShouldEqual, "i-1 Average unknown")
+			So(series[1].Name, ShouldEqual, "i-1 p99 unknown")
+
+			points := series[0].Points
+			So(len(points), ShouldEqual, 4)
+			So(points[0][0].Float64, ShouldEqual, 10)
+			So(points[2][0].Valid, ShouldBeFalse)
+			So(points[2][1].Float64, ShouldEqual, float64(start.Add(2*time.Minute).Unix()*1000))
+			So(series[1].Points[3][0].Float64, ShouldEqual, 33)
+		})
+
+		Convey("can execute queries", func() {
+			client := &cloudWatchClientStub{
+				metrics: []*cloudwatch.Metric{newMetric("InstanceId", "i-1"), newMetric("InstanceId", "i-2")},
+				output:  &GetMetricStatisticsOutput{},
+			}
+			executor := &CloudWatchExecutor{
+				DataSourceInfo: &tsdb.DataSourceInfo{JsonData: jsonData},
+				getClient:      func(region string) cloudWatchClient { return client },
+			}
+			model, _ := simplejson.NewJson([]byte(`{
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": "*"},
+				"statistics": ["Maximum"]
+			}`))
+			queries := tsdb.QuerySlice{{RefId: "A", Model: model}}
+			queryContext := tsdb.NewQueryContext(queries, tsdb.NewTimeRange("1h", "now"))
+
+			result := executor.Execute(context.TODO(), queries, queryContext)
+			So(result.Error, ShouldBeNil)
+			So(result.QueryResults["A"].Error, ShouldBeNil)
+			So(len(result.QueryResults["A"].Series), ShouldEqual, 2)
+			So(result.QueryResults["A"].Series[1].Name, ShouldEqual, "CPUUtilization_Maximum")
+			So(len(client.requests), ShouldEqual, 2)
+			So(*client.requests[0].Period, ShouldEqual, 300)
+			So(*client.requests[1].Dimensions[0].Value, ShouldEqual, "i-2")
+		})
+
+		Convey("stops sending requests when the query is canceled", func() {
+			client := &cloudWatchClientStub{
+				metrics: []*cloudwatch.Metric{newMetric("InstanceId", "i-1")},
+				output:  &GetMetricStatisticsOutput{},
+			}
+			executor := &CloudWatchExecutor{
+				DataSourceInfo: &tsdb.DataSourceInfo{JsonData: jsonData},
+				getClient:      func(region string) cloudWatchClient { return client },
+			}
+			model, _ := simplejson.NewJson([]byte(`{
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": "*"},
+				"statistics": ["Maximum"]
+			}`))
+			queries := tsdb.QuerySlice{{RefId: "A", Model: model}}
+			queryContext := tsdb.NewQueryContext(queries, tsdb.NewTimeRange("1h", "now"))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			result := executor.Execute(ctx, queries, queryContext)
+			So(result.QueryResults["A"].Error, ShouldEqual, context.Canceled)
+			So(len(client.requests), ShouldEqual, 0)
+		})
+
+		Convey("cancels api requests with the query", func() {
+			release := make(chan bool)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+			defer server.Close()
+			defer close(release)
+
+			cfg := &aws.Config{
+				Region:      aws.String("us-east-1"),
+				Endpoint:    aws.String(server.URL),
+				Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+				MaxRetries:  aws.Int(0),
+			}
+			client := &sdkClient{cloudwatch.New(session.New(cfg), cfg)}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			now := time.Now()
+			_, err := client.GetMetricStatistics(ctx, &GetMetricStatisticsInput{
+				Namespace:  aws.String("AWS/EC2"),
+				MetricName: aws.String("CPUUtilization"),
+				StartTime:  aws.Time(now.Add(-time.Hour)),
+				EndTime:    aws.Time(now),
+				Period:     aws.Int64(60),
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("sends extended statistics to the api", func() {
+			var form url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				form, _ = url.ParseQuery(string(body))
+				fmt.Fprint(w, `<GetMetricStatisticsResponse><GetMetricStatisticsResult><Datapoints><member>
+					<Timestamp>2017-03-01T00:00:00Z</Timestamp>
+					<ExtendedStatistics><entry><key>p99</key><value>42.5</value></entry></ExtendedStatistics>
+				</member></Datapoints><Label>CPUUtilization</Label></GetMetricStatisticsResult></GetMetricStatisticsResponse>`)
+			}))
+			defer server.Close()
+
+			cfg := &aws.Config{
+				Region:      aws.String("us-east-1"),
+				Endpoint:    aws.String(server.URL),
+				Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+			}
+			client := &sdkClient{cloudwatch.New(session.New(cfg), cfg)}
+
+			now := time.Now()
+			resp, err := client.GetMetricStatistics(context.TODO(), &GetMetricStatisticsInput{
+				Namespace:          aws.String("AWS/EC2"),
+				MetricName:         aws.String("CPUUtilization"),
+				StartTime:          aws.Time(now.Add(-time.Hour)),
+				EndTime:            aws.Time(now),
+				Period:             aws.Int64(60),
+				ExtendedStatistics: aws.StringSlice([]string{"p99"}),
+			})
+			So(err, ShouldBeNil)
+			So(form.Get("ExtendedStatistics.member.1"), ShouldEqual, "p99")
+			So(len(resp.Datapoints), ShouldEqual, 1)
+			So(*resp.Datapoints[0].ExtendedStatistics["p99"], ShouldEqual, 42.5)
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package cloudwatch
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// CloudWatchQuery is the query model of the CloudWatch query editor. A
+// dimension value of * matches every value of that dimension.
+type CloudWatchQuery struct {
+	RefId              string
+	Region             string
+	Namespace          string
+	MetricName         string
+	Dimensions         map[string]string
+	Statistics         []string
+	ExtendedStatistics []string
+	Period             int64
+	Alias              string
+}
+
+// GetMetricStatisticsInput and the types below mirror the ones of the
+// CloudWatch api with the extended statistics (percentiles) that the
+// vendored sdk does not know about yet.
+type GetMetricStatisticsInput struct {
+	_ struct{} `type:"structure"`
+
+	Dimensions         []*cloudwatch.Dimension `type:"list"`
+	EndTime            *time.Time              `type:"timestamp" timestampFormat:"iso8601" required:"true"`
+	ExtendedStatistics []*string               `type:"list"`
+	MetricName         *string                 `min:"1" type:"string" required:"true"`
+	Namespace          *string                 `min:"1" type:"string" required:"true"`
+	Period             *int64                  `min:"60" type:"integer" required:"true"`
+	StartTime          *time.Time              `type:"timestamp" timestampFormat:"iso8601" required:"true"`
+	Statistics         []*string               `type:"list"`
+}
+
+type GetMetricStatisticsOutput struct {
+	_ struct{} `type:"structure"`
+
+	Datapoints []*Datapoint `type:"list"`
+	Label      *string      `type:"string"`
+}
+
+type Datapoint struct {
+	_ struct{} `type:"structure"`
+
+	Average            *float64            `type:"double"`
+	ExtendedStatistics map[string]*float64 `type:"map"`
+	Maximum            *float64            `type:"double"`
+	Minimum            *float64            `type:"double"`
+	SampleCount        *float64            `type:"double"`
+	Sum                *float64            `type:"double"`
+	Timestamp          *time.Time          `type:"timestamp" timestampFormat:"iso8601"`
+	Unit               *string             `type:"string"`
+}
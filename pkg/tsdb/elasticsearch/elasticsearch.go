@@ -0,0 +1,183 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type ElasticsearchExecutor struct {
+	*tsdb.DataSourceInfo
+	QueryParser    *ElasticsearchQueryParser
+	ResponseParser *ResponseParser
+}
+
+func NewElasticsearchExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+	return &ElasticsearchExecutor{
+		DataSourceInfo: dsInfo,
+		QueryParser:    &ElasticsearchQueryParser{},
+		ResponseParser: &ResponseParser{},
+	}
+}
+
+var (
+	glog       log.Logger
+	HttpClient *http.Client
+)
+
+func init() {
+	glog = log.New("tsdb.elasticsearch")
+	tsdb.RegisterExecutor("elasticsearch", NewElasticsearchExecutor)
+
+	HttpClient = tsdb.GetDefaultClient()
+}
+
+// Execute sends all queries of the batch in a single multi search request,
+// the responses are in the same order as the queries.
+func (e *ElasticsearchExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, context *tsdb.QueryContext) *tsdb.BatchResult {
+	result := &tsdb.BatchResult{}
+
+	parsedQueries := make([]*Query, 0, len(queries))
+	for _, queryModel := range queries {
+		query, err := e.QueryParser.Parse(queryModel.Model, e.DataSourceInfo)
+		if err != nil {
+			return result.WithError(err)
+		}
+		query.RefId = queryModel.RefId
+		parsedQueries = append(parsedQueries, query)
+	}
+
+	payload, err := e.buildPayload(parsedQueries, context)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	if setting.Env == setting.DEV {
+		glog.Debug("Elasticsearch request", "payload", string(payload))
+	}
+
+	req, err := e.createRequest(payload)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	res, err := ctxhttp.Do(ctx, HttpClient, req)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	response, err := e.parseResponse(res)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	if len(response.Responses) != len(parsedQueries) {
+		return result.WithError(fmt.Errorf("Elasticsearch returned %d responses for %d queries", len(response.Responses), len(parsedQueries)))
+	}
+
+	result.QueryResults = make(map[string]*tsdb.QueryResult)
+	for i, query := range parsedQueries {
+		result.QueryResults[query.RefId] = e.ResponseParser.Parse(response.Responses[i], query)
+	}
+
+	return result
+}
+
+// buildPayload returns the newline delimited headers and bodies of the
+// multi search request.
+func (e *ElasticsearchExecutor) buildPayload(queries []*Query, context *tsdb.QueryContext) ([]byte, error) {
+	esVersion := 2
+	interval := ""
+	if e.JsonData != nil {
+		esVersion = e.JsonData.Get("esVersion").MustInt(2)
+		interval = e.JsonData.Get("interval").MustString("")
+	}
+
+	indices, err := NewIndexPattern(e.Database, interval).GetIndexList(context.TimeRange.MustGetFrom(), context.TimeRange.MustGetTo())
+	if err != nil {
+		return nil, err
+	}
+
+	searchType := "count"
+	if esVersion >= 5 {
+		searchType = "query_then_fetch"
+	}
+
+	var payload bytes.Buffer
+	for _, query := range queries {
+		body, err := query.Build(context, esVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		header := map[string]interface{}{
+			"search_type":        searchType,
+			"ignore_unavailable": true,
+			"index":              indices,
+		}
+
+		for _, part := range []interface{}{header, body} {
+			data, err := json.Marshal(part)
+			if err != nil {
+				return nil, err
+			}
+			payload.Write(data)
+			payload.WriteByte('\n')
+		}
+	}
+
+	return payload.Bytes(), nil
+}
+
+func (e *ElasticsearchExecutor) createRequest(payload []byte) (*http.Request, error) {
+	u, _ := url.Parse(e.Url)
+	u.Path = path.Join(u.Path, "_msearch")
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request. error: %v", err)
+	}
+
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	if e.BasicAuth {
+		req.SetBasicAuth(e.BasicAuthUser, e.BasicAuthPassword)
+	}
+
+	return req, nil
+}
+
+func (e *ElasticsearchExecutor) parseResponse(res *http.Response) (*MultiSearchResponse, error) {
+	body, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode/100 != 2 {
+		glog.Info("Request failed", "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("Request failed status: %v", res.Status)
+	}
+
+	var response MultiSearchResponse
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&response); err != nil {
+		glog.Info("Failed to unmarshal elasticsearch response", "error", err, "status", res.Status, "body", string(body))
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,64 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestElasticsearchExecutor(t *testing.T) {
+	Convey("Elasticsearch executor", t, func() {
+		var payload []byte
+		var requestPath string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestPath = r.URL.Path
+			payload, _ = ioutil.ReadAll(r.Body)
+
+			response, _ := ioutil.ReadFile("testdata/histogram_response.json")
+			w.Write(response)
+		}))
+		defer server.Close()
+
+		executor := NewElasticsearchExecutor(&tsdb.DataSourceInfo{
+			Url:      server.URL,
+			Database: "[logstash-]YYYY.MM.DD",
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"timeField": "@timestamp", "esVersion": 5, "interval": "Daily"}),
+		})
+
+		model, _ := simplejson.NewJson([]byte(`{"metrics": [{"id": "3", "type": "count"}, {"id": "1", "type": "avg", "field": "response_time"}]}`))
+		queries := tsdb.QuerySlice{{RefId: "B", Model: model}}
+		queryContext := tsdb.NewQueryContext(queries, tsdb.NewTimeRange("1500000000000", "1500003600000"))
+
+		result := executor.Execute(context.Background(), queries, queryContext)
+		So(result.Error, ShouldBeNil)
+
+		Convey("sends a multi search request", func() {
+			So(requestPath, ShouldEqual, "/_msearch")
+
+			lines := make([]string, 0)
+			scanner := bufio.NewScanner(bytes.NewReader(payload))
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			So(len(lines), ShouldEqual, 2)
+
+			header, err := simplejson.NewJson([]byte(lines[0]))
+			So(err, ShouldBeNil)
+			So(header.Get("search_type").MustString(), ShouldEqual, "query_then_fetch")
+			So(header.Get("index").GetIndex(0).MustString(), ShouldEqual, "logstash-2017.07.14")
+		})
+
+		Convey("returns the series of the query", func() {
+			So(len(result.QueryResults["B"].Series), ShouldEqual, 2)
+			So(result.QueryResults["B"].Series[1].Name, ShouldEqual, "Average response_time")
+		})
+	})
+}
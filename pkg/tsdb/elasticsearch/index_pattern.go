@@ -0,0 +1,118 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IndexPattern is the index of the data source, which can contain a date
+// in moment.js format like [logstash-]YYYY.MM.DD when the data source has an
+// index interval. Text in brackets is kept as is.
+type IndexPattern struct {
+	Pattern  string
+	Interval string
+}
+
+func NewIndexPattern(pattern, interval string) *IndexPattern {
+	return &IndexPattern{Pattern: pattern, Interval: interval}
+}
+
+// GetIndexList returns the indices that contain data between from and to,
+// all times are in UTC like in the query editor.
+func (ip *IndexPattern) GetIndexList(from, to time.Time) ([]string, error) {
+	if ip.Interval == "" {
+		return []string{ip.Pattern}, nil
+	}
+
+	start := ip.startOf(from.UTC())
+	end := ip.startOf(to.UTC())
+	if start.IsZero() {
+		return nil, fmt.Errorf("Unknown index interval %s", ip.Interval)
+	}
+
+	indices := make([]string, 0)
+	for t := start; !t.After(end); t = ip.next(t) {
+		indices = append(indices, formatIndex(ip.Pattern, t))
+	}
+
+	return indices, nil
+}
+
+func (ip *IndexPattern) startOf(t time.Time) time.Time {
+	switch ip.Interval {
+	case "Hourly":
+		return t.Truncate(time.Hour)
+	case "Daily":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "Weekly":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-weekday, 0, 0, 0, 0, time.UTC)
+	case "Monthly":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "Yearly":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Time{}
+}
+
+func (ip *IndexPattern) next(t time.Time) time.Time {
+	switch ip.Interval {
+	case "Hourly":
+		return t.Add(time.Hour)
+	case "Daily":
+		return t.AddDate(0, 0, 1)
+	case "Weekly":
+		return t.AddDate(0, 0, 7)
+	case "Monthly":
+		return t.AddDate(0, 1, 0)
+	}
+
+	return t.AddDate(1, 0, 0)
+}
+
+var momentTokens = []string{"GGGG", "YYYY", "WW", "YY", "MM", "DD", "HH"}
+
+func formatIndex(pattern string, t time.Time) string {
+	isoYear, isoWeek := t.ISOWeek()
+	values := map[string]string{
+		"GGGG": fmt.Sprintf("%04d", isoYear),
+		"YYYY": fmt.Sprintf("%04d", t.Year()),
+		"WW":   fmt.Sprintf("%02d", isoWeek),
+		"YY":   fmt.Sprintf("%02d", t.Year()%100),
+		"MM":   fmt.Sprintf("%02d", int(t.Month())),
+		"DD":   fmt.Sprintf("%02d", t.Day()),
+		"HH":   fmt.Sprintf("%02d", t.Hour()),
+	}
+
+	var result bytes.Buffer
+	for i := 0; i < len(pattern); {
+		if pattern[i] == '[' {
+			end := strings.IndexByte(pattern[i:], ']')
+			if end > 0 {
+				result.WriteString(pattern[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, token := range momentTokens {
+			if strings.HasPrefix(pattern[i:], token) {
+				result.WriteString(values[token])
+				i += len(token)
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			result.WriteByte(pattern[i])
+			i++
+		}
+	}
+
+	return result.String()
+}
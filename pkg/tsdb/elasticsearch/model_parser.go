@@ -0,0 +1,117 @@
+package elasticsearch
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type ElasticsearchQueryParser struct{}
+
+func (qp *ElasticsearchQueryParser) Parse(model *simplejson.Json, dsInfo *tsdb.DataSourceInfo) (*Query, error) {
+	timeField := model.Get("timeField").MustString("")
+	if dsInfo.JsonData != nil {
+		timeField = dsInfo.JsonData.Get("timeField").MustString(timeField)
+	}
+
+	if timeField == "" {
+		return nil, fmt.Errorf("Elasticsearch data source has no time field")
+	}
+
+	bucketAggs, err := qp.parseBucketAggs(model)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := qp.parseMetrics(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Query{
+		TimeField:  timeField,
+		RawQuery:   model.Get("query").MustString("*"),
+		Alias:      model.Get("alias").MustString(""),
+		BucketAggs: bucketAggs,
+		Metrics:    metrics,
+	}, nil
+}
+
+// parseBucketAggs requires a date histogram as the last bucket aggregation,
+// other queries do not return time series.
+func (qp *ElasticsearchQueryParser) parseBucketAggs(model *simplejson.Json) ([]*BucketAgg, error) {
+	bucketAggs := make([]*BucketAgg, 0)
+
+	for _, t := range model.Get("bucketAggs").MustArray() {
+		aggJson := simplejson.NewFromAny(t)
+		agg := &BucketAgg{
+			Id:       getId(aggJson),
+			Type:     aggJson.Get("type").MustString(),
+			Field:    aggJson.Get("field").MustString(""),
+			Settings: simplejson.NewFromAny(aggJson.Get("settings").MustMap()),
+		}
+
+		switch agg.Type {
+		case "terms", "filters", "date_histogram":
+		default:
+			return nil, fmt.Errorf("Elasticsearch bucket aggregation %s is not supported", agg.Type)
+		}
+
+		bucketAggs = append(bucketAggs, agg)
+	}
+
+	if len(bucketAggs) == 0 {
+		return []*BucketAgg{{Id: "2", Type: "date_histogram", Settings: simplejson.New()}}, nil
+	}
+
+	for i, agg := range bucketAggs {
+		isLast := i == len(bucketAggs)-1
+		if isLast != (agg.Type == "date_histogram") {
+			return nil, fmt.Errorf("Elasticsearch query must group by a single date histogram at the last level")
+		}
+	}
+
+	return bucketAggs, nil
+}
+
+func (qp *ElasticsearchQueryParser) parseMetrics(model *simplejson.Json) ([]*MetricAgg, error) {
+	metrics := make([]*MetricAgg, 0)
+
+	for _, t := range model.Get("metrics").MustArray() {
+		metricJson := simplejson.NewFromAny(t)
+		metric := &MetricAgg{
+			Id:       getId(metricJson),
+			Type:     metricJson.Get("type").MustString(),
+			Field:    metricJson.Get("field").MustString(""),
+			Hide:     metricJson.Get("hide").MustBool(false),
+			Settings: simplejson.NewFromAny(metricJson.Get("settings").MustMap()),
+		}
+
+		if _, exists := metricAggNames[metric.Type]; !exists {
+			return nil, fmt.Errorf("Elasticsearch metric %s is not supported", metric.Type)
+		}
+
+		if metric.Type != "count" && metric.Field == "" {
+			return nil, fmt.Errorf("Elasticsearch metric %s needs a field", metric.Type)
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	if len(metrics) == 0 {
+		return []*MetricAgg{{Id: "1", Type: "count", Settings: simplejson.New()}}, nil
+	}
+
+	return metrics, nil
+}
+
+// getId reads the id of an aggregation, which the query editor stores as a
+// string but older dashboards have as a number.
+func getId(model *simplejson.Json) string {
+	if id, err := model.Get("id").String(); err == nil {
+		return id
+	}
+
+	return fmt.Sprintf("%v", model.Get("id").Interface())
+}
@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestElasticsearchQueryParser(t *testing.T) {
+	Convey("Elasticsearch query parser", t, func() {
+		parser := &ElasticsearchQueryParser{}
+		dsInfo := &tsdb.DataSourceInfo{JsonData: simplejson.NewFromAny(map[string]interface{}{"timeField": "@timestamp"})}
+
+		parse := func(model string) (*Query, error) {
+			json, err := simplejson.NewJson([]byte(model))
+			So(err, ShouldBeNil)
+			return parser.Parse(json, dsInfo)
+		}
+
+		Convey("can parse query model", func() {
+			query, err := parse(`{
+				"query": "status:500",
+				"alias": "{{term host}}",
+				"metrics": [{"id": "1", "type": "avg", "field": "response_time", "hide": true}],
+				"bucketAggs": [
+					{"id": 3, "type": "terms", "field": "host", "settings": {"size": "5"}},
+					{"id": "2", "type": "date_histogram", "settings": {"interval": "1m"}}
+				]
+			}`)
+			So(err, ShouldBeNil)
+
+			So(query.TimeField, ShouldEqual, "@timestamp")
+			So(query.RawQuery, ShouldEqual, "status:500")
+			So(query.Alias, ShouldEqual, "{{term host}}")
+			So(query.Metrics[0].Type, ShouldEqual, "avg")
+			So(query.Metrics[0].Hide, ShouldBeTrue)
+			So(query.BucketAggs[0].Id, ShouldEqual, "3")
+			So(query.BucketAggs[0].Field, ShouldEqual, "host")
+			So(query.BucketAggs[1].Settings.Get("interval").MustString(), ShouldEqual, "1m")
+		})
+
+		Convey("uses defaults of the query editor", func() {
+			query, err := parse(`{}`)
+			So(err, ShouldBeNil)
+
+			So(query.RawQuery, ShouldEqual, "*")
+			So(query.Metrics[0].Type, ShouldEqual, "count")
+			So(query.BucketAggs[0].Type, ShouldEqual, "date_histogram")
+		})
+
+		Convey("rejects queries that do not return time series", func() {
+			_, err := parse(`{"metrics": [{"id": "1", "type": "raw_document"}], "bucketAggs": []}`)
+			So(err, ShouldNotBeNil)
+
+			_, err = parse(`{"bucketAggs": [{"id": "2", "type": "terms", "field": "host"}]}`)
+			So(err, ShouldNotBeNil)
+
+			_, err = parse(`{"metrics": [{"id": "1", "type": "moving_avg"}]}`)
+			So(err, ShouldNotBeNil)
+
+			_, err = parse(`{"metrics": [{"id": "1", "type": "avg"}]}`)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("needs a time field", func() {
+			_, err := parser.Parse(simplejson.New(), &tsdb.DataSourceInfo{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
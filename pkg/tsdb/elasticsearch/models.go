@@ -0,0 +1,39 @@
+package elasticsearch
+
+import "github.com/grafana/grafana/pkg/components/simplejson"
+
+// Query is the query model of the Elasticsearch query editor: a lucene
+// query, nested bucket aggregations that end with a date histogram and the
+// metrics calculated for every bucket of the histogram.
+type Query struct {
+	RefId      string
+	TimeField  string
+	RawQuery   string
+	Alias      string
+	BucketAggs []*BucketAgg
+	Metrics    []*MetricAgg
+}
+
+type BucketAgg struct {
+	Id       string
+	Type     string
+	Field    string
+	Settings *simplejson.Json
+}
+
+type MetricAgg struct {
+	Id       string
+	Type     string
+	Field    string
+	Hide     bool
+	Settings *simplejson.Json
+}
+
+type MultiSearchResponse struct {
+	Responses []*SearchResponse `json:"responses"`
+}
+
+type SearchResponse struct {
+	Error        map[string]interface{} `json:"error"`
+	Aggregations map[string]interface{} `json:"aggregations"`
+}
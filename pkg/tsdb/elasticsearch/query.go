@@ -0,0 +1,175 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+var metricAggNames = map[string]string{
+	"count":       "Count",
+	"avg":         "Average",
+	"sum":         "Sum",
+	"max":         "Max",
+	"min":         "Min",
+	"cardinality": "Unique Count",
+	"percentiles": "Percentiles",
+}
+
+// Build returns the search body of the query. Versions before 2 use the
+// filtered query and have no epoch_millis date format.
+func (query *Query) Build(queryContext *tsdb.QueryContext, esVersion int) (map[string]interface{}, error) {
+	from := queryContext.TimeRange.GetFromAsMsEpoch()
+	to := queryContext.TimeRange.GetToAsMsEpoch()
+
+	rangeFilter := map[string]interface{}{"gte": from, "lte": to}
+	if esVersion >= 2 {
+		rangeFilter["format"] = "epoch_millis"
+	}
+
+	queryString := map[string]interface{}{
+		"query_string": map[string]interface{}{
+			"analyze_wildcard": true,
+			"query":            query.RawQuery,
+		},
+	}
+	timeFilter := map[string]interface{}{
+		"range": map[string]interface{}{query.TimeField: rangeFilter},
+	}
+
+	var searchQuery map[string]interface{}
+	if esVersion >= 2 {
+		searchQuery = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   []interface{}{queryString},
+				"filter": []interface{}{timeFilter},
+			},
+		}
+	} else {
+		searchQuery = map[string]interface{}{
+			"filtered": map[string]interface{}{
+				"query":  queryString,
+				"filter": map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{timeFilter}}},
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"size":  0,
+		"query": searchQuery,
+	}
+
+	node := body
+	for _, bucketAgg := range query.BucketAggs {
+		esAgg, err := query.buildBucketAgg(bucketAgg, queryContext, esVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		aggs, ok := node["aggs"].(map[string]interface{})
+		if !ok {
+			aggs = make(map[string]interface{})
+			node["aggs"] = aggs
+		}
+
+		aggs[bucketAgg.Id] = esAgg
+		node = esAgg
+	}
+
+	metricAggs := make(map[string]interface{})
+	for _, metric := range query.Metrics {
+		if metric.Type == "count" {
+			continue
+		}
+
+		settings := metric.Settings.MustMap()
+		aggBody := map[string]interface{}{"field": metric.Field}
+		for key, value := range settings {
+			if value != nil {
+				aggBody[key] = value
+			}
+		}
+
+		metricAggs[metric.Id] = map[string]interface{}{metric.Type: aggBody}
+	}
+	node["aggs"] = metricAggs
+
+	return body, nil
+}
+
+func (query *Query) buildBucketAgg(bucketAgg *BucketAgg, queryContext *tsdb.QueryContext, esVersion int) (map[string]interface{}, error) {
+	switch bucketAgg.Type {
+	case "date_histogram":
+		interval := bucketAgg.Settings.Get("interval").MustString("auto")
+		if interval == "auto" {
+			interval = tsdb.CalculateInterval(queryContext.TimeRange)
+		}
+
+		histogram := map[string]interface{}{
+			"field":         query.TimeField,
+			"interval":      interval,
+			"min_doc_count": bucketAgg.Settings.Get("min_doc_count").MustInt(0),
+			"extended_bounds": map[string]interface{}{
+				"min": queryContext.TimeRange.GetFromAsMsEpoch(),
+				"max": queryContext.TimeRange.GetToAsMsEpoch(),
+			},
+		}
+		if esVersion >= 2 {
+			histogram["format"] = "epoch_millis"
+		}
+
+		return map[string]interface{}{"date_histogram": histogram}, nil
+	case "terms":
+		terms := map[string]interface{}{"field": bucketAgg.Field}
+		esAgg := map[string]interface{}{"terms": terms}
+
+		// the editor stores the size as a string, 0 means all terms which
+		// is no longer supported from version 5
+		if size, err := getInt(bucketAgg.Settings.Get("size")); err == nil {
+			if size == 0 && esVersion >= 5 {
+				size = 500
+			}
+			terms["size"] = size
+		}
+
+		orderBy, err := bucketAgg.Settings.Get("orderBy").String()
+		if err != nil {
+			return esAgg, nil
+		}
+
+		terms["order"] = map[string]interface{}{orderBy: bucketAgg.Settings.Get("order").MustString("desc")}
+
+		// ordering by a metric needs the metric at this level
+		for _, metric := range query.Metrics {
+			if metric.Id == orderBy && metric.Type != "count" {
+				esAgg["aggs"] = map[string]interface{}{
+					metric.Id: map[string]interface{}{metric.Type: map[string]interface{}{"field": metric.Field}},
+				}
+			}
+		}
+
+		return esAgg, nil
+	case "filters":
+		filters := make(map[string]interface{})
+		for _, filter := range bucketAgg.Settings.Get("filters").MustArray() {
+			filterQuery := simplejson.NewFromAny(filter).Get("query").MustString("*")
+			filters[filterQuery] = map[string]interface{}{
+				"query_string": map[string]interface{}{"query": filterQuery, "analyze_wildcard": true},
+			}
+		}
+
+		return map[string]interface{}{"filters": map[string]interface{}{"filters": filters}}, nil
+	}
+
+	return nil, fmt.Errorf("Elasticsearch bucket aggregation %s is not supported", bucketAgg.Type)
+}
+
+func getInt(model *simplejson.Json) (int, error) {
+	if value, err := model.Int(); err == nil {
+		return value, nil
+	}
+
+	return strconv.Atoi(model.MustString(""))
+}
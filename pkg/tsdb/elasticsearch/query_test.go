@@ -0,0 +1,124 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestElasticsearchQueryBuilder(t *testing.T) {
+	Convey("Elasticsearch query builder", t, func() {
+		queryContext := tsdb.NewQueryContext(nil, tsdb.NewTimeRange("1500000000000", "1500003600000"))
+
+		build := func(model string, esVersion int) *simplejson.Json {
+			query := parseQueryModel(model)
+			body, err := query.Build(queryContext, esVersion)
+			So(err, ShouldBeNil)
+
+			data, err := json.Marshal(body)
+			So(err, ShouldBeNil)
+			result, err := simplejson.NewJson(data)
+			So(err, ShouldBeNil)
+			return result
+		}
+
+		Convey("filters on the time range", func() {
+			body := build(`{"query": "status:500"}`, 5)
+
+			So(body.Get("size").MustInt(), ShouldEqual, 0)
+			So(body.GetPath("query", "bool", "must").GetIndex(0).GetPath("query_string", "query").MustString(), ShouldEqual, "status:500")
+
+			timeRange := body.GetPath("query", "bool", "filter").GetIndex(0).GetPath("range", "@timestamp")
+			So(timeRange.Get("gte").MustInt64(), ShouldEqual, 1500000000000)
+			So(timeRange.Get("lte").MustInt64(), ShouldEqual, 1500003600000)
+			So(timeRange.Get("format").MustString(), ShouldEqual, "epoch_millis")
+		})
+
+		Convey("uses filtered query before version 2", func() {
+			body := build(`{}`, 1)
+
+			So(body.GetPath("query", "filtered", "query", "query_string", "query").MustString(), ShouldEqual, "*")
+			_, hasFormat := body.GetPath("query", "filtered", "filter", "bool", "must").GetIndex(0).GetPath("range", "@timestamp").CheckGet("format")
+			So(hasFormat, ShouldBeFalse)
+		})
+
+		Convey("nests bucket and metric aggregations", func() {
+			body := build(`{
+				"metrics": [
+					{"id": "1", "type": "count"},
+					{"id": "4", "type": "percentiles", "field": "response_time", "settings": {"percents": ["95"]}}
+				],
+				"bucketAggs": [
+					{"id": "3", "type": "terms", "field": "host", "settings": {"size": "0", "orderBy": "4", "order": "asc"}},
+					{"id": "2", "type": "date_histogram", "settings": {"interval": "auto"}}
+				]
+			}`, 5)
+
+			terms := body.GetPath("aggs", "3")
+			So(terms.GetPath("terms", "field").MustString(), ShouldEqual, "host")
+			So(terms.GetPath("terms", "size").MustInt(), ShouldEqual, 500)
+			So(terms.GetPath("terms", "order", "4").MustString(), ShouldEqual, "asc")
+			So(terms.GetPath("aggs", "4", "percentiles", "field").MustString(), ShouldEqual, "response_time")
+
+			histogram := terms.GetPath("aggs", "2", "date_histogram")
+			So(histogram.Get("field").MustString(), ShouldEqual, "@timestamp")
+			So(histogram.Get("interval").MustString(), ShouldEqual, "2s")
+			So(histogram.GetPath("extended_bounds", "min").MustInt64(), ShouldEqual, 1500000000000)
+
+			metrics := terms.GetPath("aggs", "2", "aggs")
+			So(len(metrics.MustMap()), ShouldEqual, 1)
+			So(metrics.GetPath("4", "percentiles", "percents").GetIndex(0).MustString(), ShouldEqual, "95")
+		})
+
+		Convey("filters aggregation", func() {
+			body := build(`{
+				"bucketAggs": [
+					{"id": "3", "type": "filters", "settings": {"filters": [{"query": "status:500"}]}},
+					{"id": "2", "type": "date_histogram", "settings": {"interval": "1m"}}
+				]
+			}`, 2)
+
+			filter := body.GetPath("aggs", "3", "filters", "filters", "status:500", "query_string")
+			So(filter.Get("query").MustString(), ShouldEqual, "status:500")
+			So(body.GetPath("aggs", "3", "aggs", "2", "date_histogram", "interval").MustString(), ShouldEqual, "1m")
+		})
+	})
+
+	Convey("Elasticsearch index pattern", t, func() {
+		from := time.Date(2017, 12, 30, 22, 0, 0, 0, time.UTC)
+		to := time.Date(2018, 1, 2, 1, 0, 0, 0, time.UTC)
+
+		Convey("without interval", func() {
+			indices, err := NewIndexPattern("logstash-*", "").GetIndexList(from, to)
+			So(err, ShouldBeNil)
+			So(indices, ShouldResemble, []string{"logstash-*"})
+		})
+
+		Convey("daily", func() {
+			indices, err := NewIndexPattern("[logstash-]YYYY.MM.DD", "Daily").GetIndexList(from, to)
+			So(err, ShouldBeNil)
+			So(indices, ShouldResemble, []string{"logstash-2017.12.30", "logstash-2017.12.31", "logstash-2018.01.01", "logstash-2018.01.02"})
+		})
+
+		Convey("weekly uses iso weeks", func() {
+			indices, err := NewIndexPattern("[logs-]GGGG.WW", "Weekly").GetIndexList(from, to)
+			So(err, ShouldBeNil)
+			So(indices, ShouldResemble, []string{"logs-2017.52", "logs-2018.01"})
+		})
+
+		Convey("hourly", func() {
+			indices, err := NewIndexPattern("[logs-]YYYY.MM.DD.HH", "Hourly").GetIndexList(to.Add(-time.Hour), to)
+			So(err, ShouldBeNil)
+			So(indices, ShouldResemble, []string{"logs-2018.01.02.00", "logs-2018.01.02.01"})
+		})
+
+		Convey("unknown interval", func() {
+			_, err := NewIndexPattern("logs-YYYY", "Secondly").GetIndexList(from, to)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
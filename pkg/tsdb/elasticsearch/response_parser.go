@@ -0,0 +1,291 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type ResponseParser struct{}
+
+var aliasPattern = regexp.MustCompile(`\{\{([\s\S]+?)\}\}`)
+
+// seriesProp is a bucket key the series belongs to, like the value of a
+// terms aggregation. They are kept in the order of the aggregations.
+type seriesProp struct {
+	Name  string
+	Value string
+}
+
+type esSeries struct {
+	metric string
+	field  string
+	props  []seriesProp
+	points tsdb.TimeSeriesPoints
+}
+
+// Parse walks down the nested buckets of the response like the query
+// editor does, every combination of bucket keys and metric is a series.
+func (rp *ResponseParser) Parse(response *SearchResponse, query *Query) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+	queryRes.RefId = query.RefId
+
+	if response.Error != nil {
+		queryRes.Error = getErrorFromResponse(response.Error)
+		return queryRes
+	}
+
+	seriesList := make([]*esSeries, 0)
+	if err := rp.processBuckets(response.Aggregations, query, &seriesList, []seriesProp{}, 0); err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	rp.trimPoints(seriesList, query)
+
+	metricTypes := make(map[string]bool)
+	for _, series := range seriesList {
+		metricTypes[series.metric] = true
+	}
+
+	for _, series := range seriesList {
+		name := rp.getSeriesName(series, query, len(metricTypes))
+		queryRes.Series = append(queryRes.Series, tsdb.NewTimeSeries(name, series.points))
+	}
+
+	return queryRes
+}
+
+func (rp *ResponseParser) processBuckets(aggs map[string]interface{}, query *Query, seriesList *[]*esSeries, props []seriesProp, depth int) error {
+	maxDepth := len(query.BucketAggs) - 1
+
+	for _, aggDef := range query.BucketAggs {
+		esAgg, ok := aggs[aggDef.Id].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if depth == maxDepth {
+			return rp.processMetrics(esAgg, query, seriesList, props)
+		}
+
+		switch buckets := esAgg["buckets"].(type) {
+		case []interface{}:
+			for _, b := range buckets {
+				bucket, _ := b.(map[string]interface{})
+				key := formatKey(bucket["key"])
+				if keyAsString, ok := bucket["key_as_string"].(string); ok {
+					key = keyAsString
+				}
+
+				bucketProps := append(append([]seriesProp{}, props...), seriesProp{Name: aggDef.Field, Value: key})
+				if err := rp.processBuckets(bucket, query, seriesList, bucketProps, depth+1); err != nil {
+					return err
+				}
+			}
+		case map[string]interface{}:
+			names := make([]string, 0, len(buckets))
+			for name := range buckets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				bucket, _ := buckets[name].(map[string]interface{})
+				bucketProps := append(append([]seriesProp{}, props...), seriesProp{Name: "filter", Value: name})
+				if err := rp.processBuckets(bucket, query, seriesList, bucketProps, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (rp *ResponseParser) processMetrics(esAgg map[string]interface{}, query *Query, seriesList *[]*esSeries, props []seriesProp) error {
+	buckets, ok := esAgg["buckets"].([]interface{})
+	if !ok {
+		return errors.New("Elasticsearch response has no date histogram buckets")
+	}
+
+	for _, metric := range query.Metrics {
+		if metric.Hide {
+			continue
+		}
+
+		switch metric.Type {
+		case "count":
+			series := &esSeries{metric: "count", props: props}
+			for _, b := range buckets {
+				bucket := simplejson.NewFromAny(b)
+				series.points = append(series.points, tsdb.NewTimePoint(toFloat(bucket.Get("doc_count").Interface()), getKey(bucket)))
+			}
+			*seriesList = append(*seriesList, series)
+		case "percentiles":
+			if len(buckets) == 0 {
+				continue
+			}
+
+			percentiles := simplejson.NewFromAny(buckets[0]).GetPath(metric.Id, "values").MustMap()
+			names := make([]string, 0, len(percentiles))
+			for name := range percentiles {
+				names = append(names, name)
+			}
+			sort.Sort(byPercentile(names))
+
+			for _, name := range names {
+				series := &esSeries{metric: "p" + name, field: metric.Field, props: props}
+				for _, b := range buckets {
+					bucket := simplejson.NewFromAny(b)
+					value := bucket.GetPath(metric.Id, "values", name).Interface()
+					series.points = append(series.points, tsdb.NewTimePoint(toFloat(value), getKey(bucket)))
+				}
+				*seriesList = append(*seriesList, series)
+			}
+		default:
+			series := &esSeries{metric: metric.Type, field: metric.Field, props: props}
+			for _, b := range buckets {
+				bucket := simplejson.NewFromAny(b)
+				value := bucket.GetPath(metric.Id, "value").Interface()
+				series.points = append(series.points, tsdb.NewTimePoint(toFloat(value), getKey(bucket)))
+			}
+			*seriesList = append(*seriesList, series)
+		}
+	}
+
+	return nil
+}
+
+// trimPoints drops the first and last points of every series when the date
+// histogram has trim edges set, they are usually incomplete buckets.
+func (rp *ResponseParser) trimPoints(seriesList []*esSeries, query *Query) {
+	histogram := query.BucketAggs[len(query.BucketAggs)-1]
+	trim, err := getInt(histogram.Settings.Get("trimEdges"))
+	if err != nil || trim <= 0 {
+		return
+	}
+
+	for _, series := range seriesList {
+		if len(series.points) > trim*2 {
+			series.points = series.points[trim : len(series.points)-trim]
+		}
+	}
+}
+
+func (rp *ResponseParser) getSeriesName(series *esSeries, query *Query, metricTypeCount int) string {
+	metricName := series.metric
+	if name, exists := metricAggNames[series.metric]; exists {
+		metricName = name
+	}
+
+	if query.Alias != "" {
+		return aliasPattern.ReplaceAllStringFunc(query.Alias, func(match string) string {
+			group := strings.TrimSpace(match[2 : len(match)-2])
+
+			if strings.HasPrefix(group, "term ") {
+				return getProp(series.props, strings.TrimPrefix(group, "term "), match)
+			}
+			if value := getProp(series.props, group, ""); value != "" {
+				return value
+			}
+			if group == "metric" {
+				return metricName
+			}
+			if group == "field" {
+				return series.field
+			}
+
+			return match
+		})
+	}
+
+	if series.field != "" {
+		metricName += " " + series.field
+	}
+
+	if len(series.props) == 0 {
+		return metricName
+	}
+
+	values := make([]string, 0, len(series.props))
+	for _, prop := range series.props {
+		values = append(values, prop.Value)
+	}
+	name := strings.Join(values, " ")
+
+	if metricTypeCount == 1 {
+		return name
+	}
+
+	return name + " " + metricName
+}
+
+func getProp(props []seriesProp, name, defaultValue string) string {
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop.Value
+		}
+	}
+
+	return defaultValue
+}
+
+func getKey(bucket *simplejson.Json) float64 {
+	return toFloat(bucket.Get("key").Interface()).Float64
+}
+
+func formatKey(key interface{}) string {
+	switch value := key.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	}
+
+	return fmt.Sprintf("%v", key)
+}
+
+func toFloat(value interface{}) null.Float {
+	switch number := value.(type) {
+	case json.Number:
+		if f, err := number.Float64(); err == nil {
+			return null.FloatFrom(f)
+		}
+	case float64:
+		return null.FloatFrom(number)
+	}
+
+	return null.FloatFromPtr(nil)
+}
+
+func getErrorFromResponse(esError map[string]interface{}) error {
+	errJson := simplejson.NewFromAny(esError)
+
+	reason := errJson.Get("root_cause").GetIndex(0).Get("reason").MustString("")
+	if reason == "" {
+		reason = errJson.Get("reason").MustString("Unknown elasticsearch error response")
+	}
+
+	return fmt.Errorf("Elasticsearch error: %s", reason)
+}
+
+// byPercentile sorts percentile names like "99.9" by their numeric value.
+type byPercentile []string
+
+func (p byPercentile) Len() int      { return len(p) }
+func (p byPercentile) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byPercentile) Less(i, j int) bool {
+	left, _ := strconv.ParseFloat(p[i], 64)
+	right, _ := strconv.ParseFloat(p[j], 64)
+	return left < right
+}
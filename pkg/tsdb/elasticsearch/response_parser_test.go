@@ -0,0 +1,117 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func loadResponseFixture(name string) *SearchResponse {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	So(err, ShouldBeNil)
+
+	var response MultiSearchResponse
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	So(dec.Decode(&response), ShouldBeNil)
+	So(len(response.Responses), ShouldEqual, 1)
+
+	return response.Responses[0]
+}
+
+func parseQueryModel(model string) *Query {
+	json, err := simplejson.NewJson([]byte(model))
+	So(err, ShouldBeNil)
+
+	query, err := (&ElasticsearchQueryParser{}).Parse(json, &tsdb.DataSourceInfo{JsonData: simplejson.NewFromAny(map[string]interface{}{"timeField": "@timestamp"})})
+	So(err, ShouldBeNil)
+	query.RefId = "A"
+
+	return query
+}
+
+func TestElasticsearchResponseParser(t *testing.T) {
+	Convey("Elasticsearch response parser", t, func() {
+		parser := &ResponseParser{}
+
+		Convey("date histogram with count and average", func() {
+			query := parseQueryModel(`{
+				"metrics": [{"id": "3", "type": "count"}, {"id": "1", "type": "avg", "field": "response_time"}],
+				"bucketAggs": [{"id": "2", "type": "date_histogram", "settings": {"interval": "auto"}}]
+			}`)
+
+			result := parser.Parse(loadResponseFixture("histogram_response.json"), query)
+			So(result.Error, ShouldBeNil)
+			So(result.RefId, ShouldEqual, "A")
+			So(len(result.Series), ShouldEqual, 2)
+
+			So(result.Series[0].Name, ShouldEqual, "Count")
+			So(len(result.Series[0].Points), ShouldEqual, 4)
+			So(result.Series[0].Points[1][0].Float64, ShouldEqual, 15)
+			So(result.Series[0].Points[1][1].Float64, ShouldEqual, 1500000060000)
+
+			So(result.Series[1].Name, ShouldEqual, "Average response_time")
+			So(result.Series[1].Points[0][0].Float64, ShouldEqual, 120.5)
+			So(result.Series[1].Points[2][0].Valid, ShouldBeFalse)
+		})
+
+		Convey("trims edges of the histogram", func() {
+			query := parseQueryModel(`{
+				"metrics": [{"id": "1", "type": "count"}],
+				"bucketAggs": [{"id": "2", "type": "date_histogram", "settings": {"trimEdges": "1"}}]
+			}`)
+			query.Metrics[0].Id = "1"
+
+			result := parser.Parse(loadResponseFixture("histogram_response.json"), query)
+			So(len(result.Series[0].Points), ShouldEqual, 2)
+			So(result.Series[0].Points[0][0].Float64, ShouldEqual, 15)
+		})
+
+		Convey("terms group by with percentiles", func() {
+			query := parseQueryModel(`{
+				"metrics": [{"id": "1", "type": "percentiles", "field": "response_time", "settings": {"percents": ["50", "95"]}}],
+				"bucketAggs": [
+					{"id": "3", "type": "terms", "field": "host", "settings": {"size": "10", "orderBy": "_term"}},
+					{"id": "2", "type": "date_histogram", "settings": {"interval": "1m"}}
+				]
+			}`)
+
+			result := parser.Parse(loadResponseFixture("terms_percentiles_response.json"), query)
+			So(result.Error, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 4)
+
+			So(result.Series[0].Name, ShouldEqual, "web-01 p50.0 response_time")
+			So(result.Series[1].Name, ShouldEqual, "web-01 p95.0 response_time")
+			So(result.Series[1].Points[1][0].Float64, ShouldEqual, 610)
+			So(result.Series[3].Name, ShouldEqual, "web-02 p95.0 response_time")
+			So(result.Series[3].Points[0][0].Float64, ShouldEqual, 300)
+		})
+
+		Convey("uses the alias", func() {
+			query := parseQueryModel(`{
+				"alias": "{{term host}} {{metric}} {{unknown}}",
+				"metrics": [{"id": "1", "type": "percentiles", "field": "response_time"}],
+				"bucketAggs": [
+					{"id": "3", "type": "terms", "field": "host"},
+					{"id": "2", "type": "date_histogram"}
+				]
+			}`)
+
+			result := parser.Parse(loadResponseFixture("terms_percentiles_response.json"), query)
+			So(result.Series[0].Name, ShouldEqual, "web-01 p50.0 {{unknown}}")
+		})
+
+		Convey("returns the error of the response", func() {
+			query := parseQueryModel(`{"metrics": [{"id": "1", "type": "count"}]}`)
+
+			result := parser.Parse(loadResponseFixture("error_response.json"), query)
+			So(result.Error, ShouldNotBeNil)
+			So(result.Error.Error(), ShouldEqual, "Elasticsearch error: Failed to parse query [status:>]")
+		})
+	})
+}
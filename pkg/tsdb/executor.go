@@ -1,6 +1,9 @@
 package tsdb
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 type Executor interface {
 	Execute(ctx context.Context, queries QuerySlice, query *QueryContext) *BatchResult
@@ -24,3 +27,29 @@ func getExecutorFor(dsInfo *DataSourceInfo) Executor {
 func RegisterExecutor(pluginId string, fn GetExecutorFn) {
 	registry[pluginId] = fn
 }
+
+// ExecuteInParallel runs executeQuery for every query of the batch in its
+// own goroutine. Executors return errors of a query in its result so the
+// other queries of the batch still return data.
+func ExecuteInParallel(queries QuerySlice, executeQuery func(query *Query) *QueryResult) *BatchResult {
+	result := &BatchResult{QueryResults: make(map[string]*QueryResult)}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, query := range queries {
+		wg.Add(1)
+		go func(query *Query) {
+			defer wg.Done()
+
+			queryRes := executeQuery(query)
+
+			lock.Lock()
+			result.QueryResults[query.RefId] = queryRes
+			lock.Unlock()
+		}(query)
+	}
+
+	wg.Wait()
+	return result
+}
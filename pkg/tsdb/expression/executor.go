@@ -0,0 +1,83 @@
+package expression
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+const (
+	// PluginId is the data source type of expression queries
+	PluginId = "__expression"
+	// DataSourceId keeps expression queries in a batch of their own
+	DataSourceId int64 = -1
+)
+
+// ExpressionExecutor evaluates expressions over the results of the
+// queries they depend on, so they must be sent with Query.Depends set.
+type ExpressionExecutor struct {
+	*tsdb.DataSourceInfo
+}
+
+func NewExpressionExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+	return &ExpressionExecutor{DataSourceInfo: dsInfo}
+}
+
+func init() {
+	tsdb.RegisterExecutor(PluginId, NewExpressionExecutor)
+}
+
+// NewDataSourceInfo returns the data source to use for expression queries.
+func NewDataSourceInfo() *tsdb.DataSourceInfo {
+	return &tsdb.DataSourceInfo{
+		Id:       DataSourceId,
+		Name:     "Expression",
+		PluginId: PluginId,
+	}
+}
+
+func (e *ExpressionExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, context *tsdb.QueryContext) *tsdb.BatchResult {
+	result := &tsdb.BatchResult{
+		QueryResults: make(map[string]*tsdb.QueryResult),
+	}
+
+	for _, query := range queries {
+		queryResult := tsdb.NewQueryResult()
+		queryResult.RefId = query.RefId
+
+		if series, err := e.evaluate(query, context); err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Series = series
+		}
+
+		result.QueryResults[query.RefId] = queryResult
+	}
+
+	return result
+}
+
+func (e *ExpressionExecutor) evaluate(query *tsdb.Query, context *tsdb.QueryContext) (tsdb.TimeSeriesSlice, error) {
+	expr, err := Parse(query.Model.Get("expression").MustString())
+	if err != nil {
+		return nil, err
+	}
+
+	context.Lock.RLock()
+	defer context.Lock.RUnlock()
+
+	vars := make(map[string]tsdb.TimeSeriesSlice)
+	for _, refId := range expr.Refs {
+		queryResult, exists := context.Results[refId]
+		if !exists {
+			return nil, fmt.Errorf("Expression depends on query %s which has no result", refId)
+		}
+		if queryResult.Error != nil {
+			return nil, queryResult.Error
+		}
+		vars[refId] = queryResult.Series
+	}
+
+	return expr.Evaluate(vars)
+}
@@ -0,0 +1,307 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+	"gopkg.in/guregu/null.v3"
+)
+
+// Expression is parsed math over the results of other queries. Queries
+// are referenced with $RefId, e.g. `abs($A - $B) / $B * 100 > 5`.
+type Expression struct {
+	Text string
+	Refs []string
+	root node
+}
+
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, fmt.Errorf("Unexpected %s at position %d", t.value, t.pos)
+	}
+
+	return &Expression{Text: text, Refs: p.refs, root: root}, nil
+}
+
+// Evaluate calculates the expression point by point. Series from two
+// references are joined by name, and points by the nearest timestamp within
+// one step, so series of data sources with different timestamps can be
+// combined. A reference returning a single series is applied to every
+// series of the other side.
+func (e *Expression) Evaluate(vars map[string]tsdb.TimeSeriesSlice) (tsdb.TimeSeriesSlice, error) {
+	result, err := e.root.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.isScalar {
+		point := tsdb.NewTimePoint(null.FloatFrom(result.scalar), 0)
+		return tsdb.TimeSeriesSlice{tsdb.NewTimeSeries(e.Text, tsdb.TimeSeriesPoints{point})}, nil
+	}
+
+	return result.series, nil
+}
+
+type value struct {
+	isScalar bool
+	scalar   float64
+	series   tsdb.TimeSeriesSlice
+}
+
+type node interface {
+	eval(vars map[string]tsdb.TimeSeriesSlice) (value, error)
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n *numberNode) eval(vars map[string]tsdb.TimeSeriesSlice) (value, error) {
+	return value{isScalar: true, scalar: n.value}, nil
+}
+
+type refNode struct {
+	refId string
+}
+
+func (n *refNode) eval(vars map[string]tsdb.TimeSeriesSlice) (value, error) {
+	series, exists := vars[n.refId]
+	if !exists {
+		return value{}, fmt.Errorf("No result for query %s", n.refId)
+	}
+
+	return value{series: series}, nil
+}
+
+var functions = map[string]func(float64) float64{
+	"abs": math.Abs,
+}
+
+type funcNode struct {
+	name string
+	fn   func(float64) float64
+	arg  node
+}
+
+func (n *funcNode) eval(vars map[string]tsdb.TimeSeriesSlice) (value, error) {
+	arg, err := n.arg.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+
+	if arg.isScalar {
+		return value{isScalar: true, scalar: n.fn(arg.scalar)}, nil
+	}
+
+	result := make(tsdb.TimeSeriesSlice, 0, len(arg.series))
+	for _, series := range arg.series {
+		points := make(tsdb.TimeSeriesPoints, 0, len(series.Points))
+		for _, point := range series.Points {
+			if point[0].Valid {
+				point[0] = null.FloatFrom(n.fn(point[0].Float64))
+			}
+			points = append(points, point)
+		}
+		result = append(result, tsdb.NewTimeSeries(series.Name, points))
+	}
+
+	return value{series: result}, nil
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(vars map[string]tsdb.TimeSeriesSlice) (value, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch {
+	case left.isScalar && right.isScalar:
+		result := applyOperator(n.op, null.FloatFrom(left.scalar), null.FloatFrom(right.scalar))
+		if !result.Valid {
+			return value{}, fmt.Errorf("Division by zero")
+		}
+		return value{isScalar: true, scalar: result.Float64}, nil
+	case left.isScalar:
+		return value{series: mapSeries(right.series, func(v null.Float) null.Float {
+			return applyOperator(n.op, null.FloatFrom(left.scalar), v)
+		})}, nil
+	case right.isScalar:
+		return value{series: mapSeries(left.series, func(v null.Float) null.Float {
+			return applyOperator(n.op, v, null.FloatFrom(right.scalar))
+		})}, nil
+	}
+
+	return value{series: joinSeries(n.op, left.series, right.series)}, nil
+}
+
+func mapSeries(slice tsdb.TimeSeriesSlice, fn func(null.Float) null.Float) tsdb.TimeSeriesSlice {
+	result := make(tsdb.TimeSeriesSlice, 0, len(slice))
+	for _, series := range slice {
+		points := make(tsdb.TimeSeriesPoints, 0, len(series.Points))
+		for _, point := range series.Points {
+			points = append(points, tsdb.TimePoint{fn(point[0]), point[1]})
+		}
+		result = append(result, tsdb.NewTimeSeries(series.Name, points))
+	}
+	return result
+}
+
+func joinSeries(op string, left, right tsdb.TimeSeriesSlice) tsdb.TimeSeriesSlice {
+	result := make(tsdb.TimeSeriesSlice, 0)
+
+	switch {
+	case len(left) == 1 && len(right) == 1:
+		result = append(result, joinPoints(op, left[0].Name, left[0], right[0]))
+	case len(right) == 1:
+		for _, l := range left {
+			result = append(result, joinPoints(op, l.Name, l, right[0]))
+		}
+	case len(left) == 1:
+		for _, r := range right {
+			result = append(result, joinPoints(op, r.Name, left[0], r))
+		}
+	default:
+		for _, l := range left {
+			for _, r := range right {
+				if l.Name == r.Name {
+					result = append(result, joinPoints(op, l.Name, l, r))
+					break
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// joinPoints joins every left point with the nearest right point within one
+// step, the largest point interval of the two series, as series of different
+// data sources rarely share timestamps.
+func joinPoints(op string, name string, left, right *tsdb.TimeSeries) *tsdb.TimeSeries {
+	rightPoints := make(tsdb.TimeSeriesPoints, len(right.Points))
+	copy(rightPoints, right.Points)
+	sort.Sort(byTime(rightPoints))
+
+	step := math.Max(pointInterval(left.Points), pointInterval(rightPoints))
+
+	points := make(tsdb.TimeSeriesPoints, 0, len(left.Points))
+	for _, point := range left.Points {
+		if rightPoint, found := nearestPoint(rightPoints, point[1].Float64, step); found {
+			points = append(points, tsdb.TimePoint{applyOperator(op, point[0], rightPoint[0]), point[1]})
+		}
+	}
+
+	return tsdb.NewTimeSeries(name, points)
+}
+
+// nearestPoint returns the point of the sorted points closest to timestamp
+// and at most step away from it. Any distance is accepted when step is 0,
+// which means neither series has more than one point.
+func nearestPoint(points tsdb.TimeSeriesPoints, timestamp float64, step float64) (tsdb.TimePoint, bool) {
+	i := sort.Search(len(points), func(i int) bool { return points[i][1].Float64 >= timestamp })
+
+	nearest := -1
+	if i < len(points) {
+		nearest = i
+	}
+	if i > 0 && (nearest == -1 || timestamp-points[i-1][1].Float64 < points[i][1].Float64-timestamp) {
+		nearest = i - 1
+	}
+
+	if nearest == -1 {
+		return tsdb.TimePoint{}, false
+	}
+
+	if step > 0 && math.Abs(points[nearest][1].Float64-timestamp) > step {
+		return tsdb.TimePoint{}, false
+	}
+
+	return points[nearest], true
+}
+
+// pointInterval returns the smallest time between two points, or 0 for
+// series with less than two points.
+func pointInterval(points tsdb.TimeSeriesPoints) float64 {
+	interval := float64(0)
+	for i := 1; i < len(points); i++ {
+		diff := math.Abs(points[i][1].Float64 - points[i-1][1].Float64)
+		if diff > 0 && (interval == 0 || diff < interval) {
+			interval = diff
+		}
+	}
+	return interval
+}
+
+type byTime tsdb.TimeSeriesPoints
+
+func (p byTime) Len() int           { return len(p) }
+func (p byTime) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byTime) Less(i, j int) bool { return p[i][1].Float64 < p[j][1].Float64 }
+
+// applyOperator returns null if any operand is null or on division by zero.
+// Comparisons return 1 when true and 0 when false.
+func applyOperator(op string, a, b null.Float) null.Float {
+	if !a.Valid || !b.Valid {
+		return null.FloatFromPtr(nil)
+	}
+
+	x, y := a.Float64, b.Float64
+	switch op {
+	case "+":
+		return null.FloatFrom(x + y)
+	case "-":
+		return null.FloatFrom(x - y)
+	case "*":
+		return null.FloatFrom(x * y)
+	case "/":
+		if y == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		return null.FloatFrom(x / y)
+	case "<":
+		return boolToFloat(x < y)
+	case ">":
+		return boolToFloat(x > y)
+	case "<=":
+		return boolToFloat(x <= y)
+	case ">=":
+		return boolToFloat(x >= y)
+	case "==":
+		return boolToFloat(x == y)
+	case "!=":
+		return boolToFloat(x != y)
+	}
+
+	return null.FloatFromPtr(nil)
+}
+
+func boolToFloat(b bool) null.Float {
+	if b {
+		return null.FloatFrom(1)
+	}
+	return null.FloatFrom(0)
+}
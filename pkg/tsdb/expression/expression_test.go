@@ -0,0 +1,186 @@
+package expression
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExpressionParsing(t *testing.T) {
+	Convey("When parsing expressions", t, func() {
+		Convey("Should collect references", func() {
+			expr, err := Parse("abs($A - $B) / $B * 100 > 5")
+			So(err, ShouldBeNil)
+			So(expr.Refs, ShouldResemble, []string{"A", "B"})
+		})
+
+		Convey("Should respect operator precedence", func() {
+			tests := map[string]float64{
+				"1 + 2 * 3":        7,
+				"(1 + 2) * 3":      9,
+				"10 - 4 - 3":       3,
+				"-2 * 3":           -6,
+				"abs(1 - 3) / 4":   0.5,
+				"1 + 2 > 2":        1,
+				"2 * 2 <= 3":       0,
+				"1 == 1":           1,
+				"1 != 1":           0,
+				"abs(-1.5) >= 1.5": 1,
+			}
+
+			for text, expected := range tests {
+				expr, err := Parse(text)
+				So(err, ShouldBeNil)
+
+				result, err := expr.Evaluate(nil)
+				So(err, ShouldBeNil)
+				So(result[0].Points[0][0].Float64, ShouldEqual, expected)
+			}
+		})
+
+		Convey("Should return errors for invalid expressions", func() {
+			for _, text := range []string{"", "1 +", "(1 + 2", "$", "sqrt(4)", "1 = 2", "1 2", "abs 1", "#A"} {
+				_, err := Parse(text)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestExpressionEvaluation(t *testing.T) {
+	Convey("When evaluating expressions over series", t, func() {
+		vars := map[string]tsdb.TimeSeriesSlice{
+			"A": {
+				tsdb.NewTimeSeries("server1", tsdb.NewTimeSeriesPointsFromArgs(10, 1, 20, 2)),
+				tsdb.NewTimeSeries("server2", tsdb.NewTimeSeriesPointsFromArgs(30, 1, 40, 2)),
+			},
+			"B": {
+				tsdb.NewTimeSeries("server2", tsdb.NewTimeSeriesPointsFromArgs(10, 1, 0, 2)),
+				tsdb.NewTimeSeries("server1", tsdb.NewTimeSeriesPointsFromArgs(5, 1, 10, 2, 99, 3)),
+			},
+			"Total": {
+				tsdb.NewTimeSeries("requests", tsdb.NewTimeSeriesPointsFromArgs(100, 1, 200, 2)),
+			},
+		}
+
+		Convey("Should join series by name and timestamp", func() {
+			expr, _ := Parse("$A / $B")
+			result, err := expr.Evaluate(vars)
+
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Name, ShouldEqual, "server1")
+			So(len(result[0].Points), ShouldEqual, 2)
+			So(result[0].Points[0][0].Float64, ShouldEqual, 2)
+			So(result[0].Points[1][0].Float64, ShouldEqual, 2)
+
+			Convey("Division by zero should be null", func() {
+				So(result[1].Name, ShouldEqual, "server2")
+				So(result[1].Points[0][0].Float64, ShouldEqual, 3)
+				So(result[1].Points[1][0].Valid, ShouldBeFalse)
+			})
+		})
+
+		Convey("Should join series of data sources with offset timestamps", func() {
+			offsetVars := map[string]tsdb.TimeSeriesSlice{
+				"Graphite":   {tsdb.NewTimeSeries("errors", tsdb.NewTimeSeriesPointsFromArgs(5, 60000, 10, 120000, 15, 180000))},
+				"Prometheus": {tsdb.NewTimeSeries("requests", tsdb.NewTimeSeriesPointsFromArgs(100, 77000, 200, 137000))},
+			}
+
+			expr, _ := Parse("$Graphite / $Prometheus")
+			result, err := expr.Evaluate(offsetVars)
+
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 1)
+			So(len(result[0].Points), ShouldEqual, 3)
+			So(result[0].Points[0][0].Float64, ShouldEqual, 0.05)
+			So(result[0].Points[1][0].Float64, ShouldEqual, 0.05)
+			So(result[0].Points[1][1].Float64, ShouldEqual, 120000)
+			So(result[0].Points[2][0].Float64, ShouldEqual, 0.075)
+
+			Convey("Points further than one step apart should not be joined", func() {
+				offsetVars["Prometheus"][0].Points = tsdb.NewTimeSeriesPointsFromArgs(100, 230000, 200, 290000)
+				result, _ := expr.Evaluate(offsetVars)
+				So(len(result[0].Points), ShouldEqual, 1)
+				So(result[0].Points[0][1].Float64, ShouldEqual, 180000)
+			})
+		})
+
+		Convey("Should apply a single series to all series", func() {
+			expr, _ := Parse("$A / $Total * 100")
+			result, err := expr.Evaluate(vars)
+
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[1].Name, ShouldEqual, "server2")
+			So(result[1].Points[1][0].Float64, ShouldEqual, 20)
+		})
+
+		Convey("Should apply functions and comparisons to points", func() {
+			expr, _ := Parse("abs($B - 20) > 12")
+			result, err := expr.Evaluate(vars)
+
+			So(err, ShouldBeNil)
+			So(result[1].Points[0][0].Float64, ShouldEqual, 1)
+			So(result[1].Points[1][0].Float64, ShouldEqual, 0)
+			So(result[1].Points[2][0].Float64, ShouldEqual, 1)
+		})
+
+		Convey("Should return error for missing reference", func() {
+			expr, _ := Parse("$C * 2")
+			_, err := expr.Evaluate(vars)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type staticExecutor struct {
+	series map[string]tsdb.TimeSeriesSlice
+}
+
+func (e *staticExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, context *tsdb.QueryContext) *tsdb.BatchResult {
+	result := &tsdb.BatchResult{QueryResults: make(map[string]*tsdb.QueryResult)}
+	for _, query := range queries {
+		result.QueryResults[query.RefId] = &tsdb.QueryResult{RefId: query.RefId, Series: e.series[query.RefId]}
+	}
+	return result
+}
+
+func TestExpressionExecutor(t *testing.T) {
+	Convey("When executing request with an expression query", t, func() {
+		executor := &staticExecutor{series: map[string]tsdb.TimeSeriesSlice{
+			"A": {tsdb.NewTimeSeries("errors", tsdb.NewTimeSeriesPointsFromArgs(5, 1))},
+			"B": {tsdb.NewTimeSeries("requests", tsdb.NewTimeSeriesPointsFromArgs(50, 1))},
+		}}
+		tsdb.RegisterExecutor("static", func(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+			return executor
+		})
+
+		req := &tsdb.Request{
+			TimeRange: tsdb.NewTimeRange("5m", "now"),
+			Queries: tsdb.QuerySlice{
+				{RefId: "A", DataSource: &tsdb.DataSourceInfo{Id: 1, PluginId: "static"}},
+				{RefId: "B", DataSource: &tsdb.DataSourceInfo{Id: 2, PluginId: "static"}},
+				{
+					RefId:      "C",
+					DataSource: NewDataSourceInfo(),
+					Depends:    []string{"A", "B"},
+					Model:      simplejson.NewFromAny(map[string]interface{}{"expression": "$A / $B"}),
+				},
+			},
+		}
+
+		res, err := tsdb.HandleRequest(context.TODO(), req)
+		So(err, ShouldBeNil)
+
+		Convey("Should evaluate expression after its dependencies", func() {
+			So(res.Results["C"].Error, ShouldBeNil)
+			So(len(res.Results["C"].Series), ShouldEqual, 1)
+			So(res.Results["C"].Series[0].Points[0][0].Float64, ShouldEqual, 0.1)
+		})
+	})
+}
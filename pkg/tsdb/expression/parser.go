@@ -0,0 +1,241 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenNumber
+	tokenRef
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	pos   int
+}
+
+func tokenize(text string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLeftParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRightParen, ")", i})
+			i++
+		case r == '+' || r == '-' || r == '*' || r == '/':
+			tokens = append(tokens, token{tokenOperator, string(r), i})
+			i++
+		case r == '<' || r == '>' || r == '=' || r == '!':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("Unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len(op)
+		case r == '$':
+			start := i
+			i++
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("Missing query reference after $ at position %d", start)
+			}
+			tokens = append(tokens, token{tokenRef, string(runes[start+1 : i]), start})
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("Unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// parser is a recursive descent parser with the following precedence,
+// from lowest to highest: comparison, + -, * /, unary -.
+type parser struct {
+	tokens []token
+	pos    int
+	refs   []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	for isOperator(p.peek(), "<", ">", "<=", ">=", "==", "!=") {
+		op := p.next().value
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for isOperator(p.peek(), "+", "-") {
+		op := p.next().value
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for isOperator(p.peek(), "*", "/") {
+		op := p.next().value
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if isOperator(p.peek(), "-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "*", left: &numberNode{value: -1}, right: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.typ {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %s at position %d", t.value, t.pos)
+		}
+		return &numberNode{value: value}, nil
+	case tokenRef:
+		p.addRef(t.value)
+		return &refNode{refId: t.value}, nil
+	case tokenIdent:
+		fn, exists := functions[t.value]
+		if !exists {
+			return nil, fmt.Errorf("Unknown function %s at position %d", t.value, t.pos)
+		}
+		if p.next().typ != tokenLeftParen {
+			return nil, fmt.Errorf("Expected ( after %s at position %d", t.value, t.pos)
+		}
+		arg, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().typ != tokenRightParen {
+			return nil, fmt.Errorf("Missing ) for %s at position %d", t.value, t.pos)
+		}
+		return &funcNode{name: t.value, fn: fn, arg: arg}, nil
+	case tokenLeftParen:
+		inner, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().typ != tokenRightParen {
+			return nil, fmt.Errorf("Missing ) for ( at position %d", t.pos)
+		}
+		return inner, nil
+	case tokenEOF:
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("Unexpected %s at position %d", t.value, t.pos)
+}
+
+func (p *parser) addRef(refId string) {
+	for _, ref := range p.refs {
+		if ref == refId {
+			return
+		}
+	}
+	p.refs = append(p.refs, refId)
+}
+
+func isOperator(t token, ops ...string) bool {
+	if t.typ != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			return true
+		}
+	}
+	return false
+}
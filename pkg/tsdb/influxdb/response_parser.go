@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -21,16 +22,111 @@ func init() {
 	legendFormat = regexp.MustCompile(`\[\[(\w+?)*\]\]*|\$\s*(\w+?)*`)
 }
 
+// Parse returns series, or tables for the table result format and for
+// results without a time column like those of SHOW queries.
 func (rp *ResponseParser) Parse(response *Response, query *Query) *tsdb.QueryResult {
 	queryRes := tsdb.NewQueryResult()
 
 	for _, result := range response.Results {
+		if len(result.Series) == 0 {
+			continue
+		}
+
+		if query.ResultFormat == "table" || !hasTimeColumn(result.Series[0]) {
+			queryRes.Tables = append(queryRes.Tables, rp.transformRowsToTable(result.Series))
+			continue
+		}
+
 		queryRes.Series = append(queryRes.Series, rp.transformRows(result.Series, queryRes, query)...)
 	}
 
 	return queryRes
 }
 
+func hasTimeColumn(row Row) bool {
+	return len(row.Columns) > 0 && row.Columns[0] == "time"
+}
+
+// transformRowsToTable puts the rows in a single table with a column per
+// tag after the time column, like the table result format of the query
+// editor. The columns are the ones of the first row.
+func (rp *ResponseParser) transformRowsToTable(rows []Row) *tsdb.Table {
+	table := tsdb.NewTable()
+
+	first := rows[0]
+	tagKeys := make([]string, 0, len(first.Tags))
+	for key := range first.Tags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+
+	valueOffset := 0
+	if hasTimeColumn(first) {
+		table.Columns = append(table.Columns, tsdb.TableColumn{Text: "Time", Type: tsdb.ColumnTypeTime})
+		valueOffset = 1
+	}
+
+	for _, key := range tagKeys {
+		table.Columns = append(table.Columns, tsdb.TableColumn{Text: key, Type: tsdb.ColumnTypeString})
+	}
+
+	for i, column := range first.Columns[valueOffset:] {
+		table.Columns = append(table.Columns, tsdb.TableColumn{Text: column, Type: getColumnType(rows, i+valueOffset)})
+	}
+
+	for _, row := range rows {
+		for _, values := range row.Values {
+			rowValues := make(tsdb.RowValues, 0, len(table.Columns))
+
+			if valueOffset == 1 {
+				timestamp := rp.parseValue(values[0])
+				if timestamp.Valid {
+					rowValues = append(rowValues, timestamp.Float64*1000)
+				} else {
+					rowValues = append(rowValues, nil)
+				}
+			}
+
+			for _, key := range tagKeys {
+				rowValues = append(rowValues, row.Tags[key])
+			}
+
+			for i := valueOffset; i < len(values); i++ {
+				if number, ok := values[i].(json.Number); ok {
+					value := rp.parseValue(number)
+					if value.Valid {
+						rowValues = append(rowValues, value.Float64)
+					} else {
+						rowValues = append(rowValues, nil)
+					}
+				} else {
+					rowValues = append(rowValues, values[i])
+				}
+			}
+
+			table.Rows = append(table.Rows, rowValues)
+		}
+	}
+
+	return table
+}
+
+func getColumnType(rows []Row, index int) tsdb.ColumnType {
+	for _, row := range rows {
+		for _, values := range row.Values {
+			if index >= len(values) || values[index] == nil {
+				continue
+			}
+			if _, ok := values[index].(json.Number); ok {
+				return tsdb.ColumnTypeNumber
+			}
+			return tsdb.ColumnTypeString
+		}
+	}
+
+	return tsdb.ColumnTypeString
+}
+
 func (rp *ResponseParser) transformRows(rows []Row, queryResult *tsdb.QueryResult, query *Query) tsdb.TimeSeriesSlice {
 	var result tsdb.TimeSeriesSlice
 
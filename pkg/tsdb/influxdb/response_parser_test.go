@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -160,5 +161,70 @@ func TestInfluxdbResponseParser(t *testing.T) {
 				})
 			})
 		})
+
+		Convey("Response parser with table result", func() {
+			parser := &ResponseParser{}
+
+			response := &Response{
+				Results: []Result{
+					Result{
+						Series: []Row{
+							{
+								Name:    "cpu",
+								Columns: []string{"time", "mean", "host"},
+								Tags:    map[string]string{"datacenter": "America"},
+								Values: [][]interface{}{
+									{json.Number("111"), json.Number("222"), "server1"},
+									{json.Number("112"), nil, "server2"},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			result := parser.Parse(response, &Query{ResultFormat: "table"})
+
+			So(len(result.Series), ShouldEqual, 0)
+			So(len(result.Tables), ShouldEqual, 1)
+
+			table := result.Tables[0]
+			So(table.Columns, ShouldResemble, []tsdb.TableColumn{
+				{Text: "Time", Type: tsdb.ColumnTypeTime},
+				{Text: "datacenter", Type: tsdb.ColumnTypeString},
+				{Text: "mean", Type: tsdb.ColumnTypeNumber},
+				{Text: "host", Type: tsdb.ColumnTypeString},
+			})
+			So(table.Rows[0], ShouldResemble, tsdb.RowValues{float64(111000), "America", float64(222), "server1"})
+			So(table.Rows[1][2], ShouldBeNil)
+		})
+
+		Convey("Response parser with show query result", func() {
+			parser := &ResponseParser{}
+
+			response := &Response{
+				Results: []Result{
+					Result{
+						Series: []Row{
+							{
+								Name:    "cpu",
+								Columns: []string{"key", "value"},
+								Values: [][]interface{}{
+									{"host", "server1"},
+									{"host", "server2"},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			result := parser.Parse(response, &Query{ResultFormat: "time_series"})
+
+			So(len(result.Series), ShouldEqual, 0)
+			So(len(result.Tables), ShouldEqual, 1)
+			So(result.Tables[0].Columns[1], ShouldResemble, tsdb.TableColumn{Text: "value", Type: tsdb.ColumnTypeString})
+			So(result.Tables[0].Rows[1], ShouldResemble, tsdb.RowValues{"host", "server2"})
+		})
 	})
 }
@@ -2,6 +2,7 @@ package tsdb
 
 import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	m "github.com/grafana/grafana/pkg/models"
 	"gopkg.in/guregu/null.v3"
 )
 
@@ -42,6 +43,22 @@ type DataSourceInfo struct {
 	JsonData          *simplejson.Json
 }
 
+func NewDataSourceInfo(ds *m.DataSource) *DataSourceInfo {
+	return &DataSourceInfo{
+		Id:                ds.Id,
+		Name:              ds.Name,
+		PluginId:          ds.Type,
+		Url:               ds.Url,
+		User:              ds.User,
+		Password:          ds.Password,
+		Database:          ds.Database,
+		BasicAuth:         ds.BasicAuth,
+		BasicAuthUser:     ds.BasicAuthUser,
+		BasicAuthPassword: ds.BasicAuthPassword,
+		JsonData:          ds.JsonData,
+	}
+}
+
 type BatchTiming struct {
 	TimeElapsed int64
 }
@@ -58,9 +75,50 @@ func (br *BatchResult) WithError(err error) *BatchResult {
 }
 
 type QueryResult struct {
-	Error  error           `json:"error"`
-	RefId  string          `json:"refId"`
-	Series TimeSeriesSlice `json:"series"`
+	Error       error           `json:"-"`
+	ErrorString string          `json:"error,omitempty"`
+	RefId       string          `json:"refId"`
+	Series      TimeSeriesSlice `json:"series"`
+	Tables      []*Table        `json:"tables"`
+}
+
+// Table is a tabular query result. Values of time columns are epochs in
+// milliseconds and values of number columns are float64.
+type Table struct {
+	Columns []TableColumn `json:"columns"`
+	Rows    []RowValues   `json:"rows"`
+}
+
+type ColumnType string
+
+const (
+	ColumnTypeTime   ColumnType = "time"
+	ColumnTypeNumber ColumnType = "number"
+	ColumnTypeString ColumnType = "string"
+)
+
+type TableColumn struct {
+	Text string     `json:"text"`
+	Type ColumnType `json:"type,omitempty"`
+}
+
+type RowValues []interface{}
+
+func NewTable(columns ...TableColumn) *Table {
+	return &Table{
+		Columns: columns,
+		Rows:    make([]RowValues, 0),
+	}
+}
+
+// ColumnIndex returns the index of the column with the name, or -1.
+func (t *Table) ColumnIndex(name string) int {
+	for i, column := range t.Columns {
+		if column.Text == name {
+			return i
+		}
+	}
+	return -1
 }
 
 type TimeSeries struct {
@@ -75,6 +133,7 @@ type TimeSeriesSlice []*TimeSeries
 func NewQueryResult() *QueryResult {
 	return &QueryResult{
 		Series: make(TimeSeriesSlice, 0),
+		Tables: make([]*Table, 0),
 	}
 }
 
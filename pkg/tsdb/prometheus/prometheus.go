@@ -3,12 +3,15 @@ package prometheus
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/log"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/prometheus/client_golang/api/prometheus"
@@ -24,19 +27,27 @@ func NewPrometheusExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
 }
 
 var (
-	plog         log.Logger
-	legendFormat *regexp.Regexp
+	plog          log.Logger
+	legendFormat  *regexp.Regexp
+	httpTransport *http.Transport
+)
+
+const (
+	statusAPIError = 422
+	maxPoints      = 11000
 )
 
 func init() {
 	plog = log.New("tsdb.prometheus")
 	tsdb.RegisterExecutor("prometheus", NewPrometheusExecutor)
 	legendFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
+	httpTransport = tsdb.GetDefaultClient().Transport.(*http.Transport)
 }
 
 func (e *PrometheusExecutor) getClient() (prometheus.QueryAPI, error) {
 	cfg := prometheus.Config{
-		Address: e.DataSourceInfo.Url,
+		Address:   e.DataSourceInfo.Url,
+		Transport: &apiTransport{Transport: httpTransport, dsInfo: e.DataSourceInfo},
 	}
 
 	client, err := prometheus.New(cfg)
@@ -47,37 +58,83 @@ func (e *PrometheusExecutor) getClient() (prometheus.QueryAPI, error) {
 	return prometheus.NewQueryAPI(client), nil
 }
 
-func (e *PrometheusExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) *tsdb.BatchResult {
-	result := &tsdb.BatchResult{}
+// apiTransport adds the basic auth of the data source to requests. Newer
+// Prometheus versions answer failed queries with 400 or 503 instead of 422,
+// the client only reads the error details from 422 responses so json error
+// responses are passed on with that status.
+type apiTransport struct {
+	*http.Transport
+	dsInfo *tsdb.DataSourceInfo
+}
 
-	client, err := e.getClient()
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.dsInfo.BasicAuth {
+		authReq := new(http.Request)
+		*authReq = *req
+		authReq.Header = make(http.Header, len(req.Header))
+		for key, values := range req.Header {
+			authReq.Header[key] = values
+		}
+		authReq.SetBasicAuth(t.dsInfo.BasicAuthUser, t.dsInfo.BasicAuthPassword)
+		req = authReq
+	}
+
+	res, err := t.Transport.RoundTrip(req)
 	if err != nil {
-		return result.WithError(err)
+		return nil, err
+	}
+
+	if (res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusServiceUnavailable) &&
+		strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		res.StatusCode = statusAPIError
 	}
 
-	query, err := parseQuery(queries, queryContext)
+	return res, nil
+}
+
+// Execute runs the queries in parallel on one api client, so a slow range
+// query does not hold back the other panels of the batch.
+func (e *PrometheusExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) *tsdb.BatchResult {
+	client, err := e.getClient()
 	if err != nil {
+		result := &tsdb.BatchResult{QueryResults: make(map[string]*tsdb.QueryResult)}
 		return result.WithError(err)
 	}
 
-	timeRange := prometheus.Range{
-		Start: query.Start,
-		End:   query.End,
-		Step:  query.Step,
+	return tsdb.ExecuteInParallel(queries, func(queryModel *tsdb.Query) *tsdb.QueryResult {
+		return e.executeQuery(ctx, client, queryModel, queryContext)
+	})
+}
+
+func (e *PrometheusExecutor) executeQuery(ctx context.Context, client prometheus.QueryAPI, queryModel *tsdb.Query, queryContext *tsdb.QueryContext) *tsdb.QueryResult {
+	query, err := parseQuery(queryModel, queryContext)
+	if err != nil {
+		return &tsdb.QueryResult{RefId: queryModel.RefId, Error: err}
 	}
 
-	value, err := client.QueryRange(ctx, query.Expr, timeRange)
+	var value pmodel.Value
+	if query.Instant {
+		value, err = client.Query(ctx, query.Expr, query.End)
+	} else {
+		timeRange := prometheus.Range{
+			Start: query.Start,
+			End:   query.End,
+			Step:  query.Step,
+		}
+		value, err = client.QueryRange(ctx, query.Expr, timeRange)
+	}
 
 	if err != nil {
-		return result.WithError(err)
+		plog.Debug("Query failed", "expr", query.Expr, "error", err)
+		return &tsdb.QueryResult{RefId: query.RefId, Error: err}
 	}
 
-	queryResult, err := parseResponse(value, query)
+	queryRes, err := parseResponse(value, query)
 	if err != nil {
-		return result.WithError(err)
+		return &tsdb.QueryResult{RefId: query.RefId, Error: err}
 	}
-	result.QueryResults = queryResult
-	return result
+
+	return queryRes
 }
 
 func formatLegend(metric pmodel.Metric, query *PrometheusQuery) string {
@@ -99,17 +156,10 @@ func formatLegend(metric pmodel.Metric, query *PrometheusQuery) string {
 	return string(result)
 }
 
-func parseQuery(queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) (*PrometheusQuery, error) {
-	queryModel := queries[0]
-
+func parseQuery(queryModel *tsdb.Query, queryContext *tsdb.QueryContext) (*PrometheusQuery, error) {
 	expr, err := queryModel.Model.Get("expr").String()
 	if err != nil {
-		return nil, err
-	}
-
-	step, err := queryModel.Model.Get("step").Int64()
-	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Query %s has no expression", queryModel.RefId)
 	}
 
 	format := queryModel.Model.Get("legendFormat").MustString("")
@@ -124,36 +174,117 @@ func parseQuery(queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) (*Prom
 		return nil, err
 	}
 
+	step, err := getStep(queryModel, end.Sub(start))
+	if err != nil {
+		return nil, err
+	}
+
 	return &PrometheusQuery{
+		RefId:        queryModel.RefId,
 		Expr:         expr,
-		Step:         time.Second * time.Duration(step),
+		Step:         step,
 		LegendFormat: format,
+		Instant:      queryModel.Model.Get("instant").MustBool(false),
 		Start:        start,
 		End:          end,
 	}, nil
 }
 
-func parseResponse(value pmodel.Value, query *PrometheusQuery) (map[string]*tsdb.QueryResult, error) {
-	queryResults := make(map[string]*tsdb.QueryResult)
-	queryRes := tsdb.NewQueryResult()
+// getStep calculates the step like the query editor does: the step entered
+// in the editor, or else the interval of the request, times the resolution
+// factor. Requests without an interval, like the ones from alerting, use the
+// step the editor stored with the query. The step is made larger when it
+// would return more than max data points, Prometheus refuses queries with
+// more than 11000 points.
+func getStep(queryModel *tsdb.Query, timeRange time.Duration) (time.Duration, error) {
+	interval, err := parseStep(queryModel.Model.Get("interval"))
+	if err != nil {
+		return 0, err
+	}
 
-	data, ok := value.(pmodel.Matrix)
-	if !ok {
-		return queryResults, fmt.Errorf("Unsupported result format: %s", value.Type().String())
+	if interval == 0 {
+		interval = time.Duration(queryModel.IntervalMs) * time.Millisecond
 	}
 
-	for _, v := range data {
-		series := tsdb.TimeSeries{
-			Name: formatLegend(v.Metric, query),
+	var step time.Duration
+	if interval > 0 {
+		intervalFactor := queryModel.Model.Get("intervalFactor").MustInt64(1)
+		if intervalFactor < 1 {
+			intervalFactor = 1
 		}
-
-		for _, k := range v.Values {
-			series.Points = append(series.Points, tsdb.NewTimePoint(null.FloatFrom(float64(k.Value)), float64(k.Timestamp.Unix()*1000)))
+		step = interval * time.Duration(intervalFactor)
+	} else {
+		step, err = parseStep(queryModel.Model.Get("step"))
+		if err != nil {
+			return 0, err
 		}
+	}
+
+	if queryModel.MaxDataPoints > 0 && step < timeRange/time.Duration(queryModel.MaxDataPoints) {
+		step = timeRange / time.Duration(queryModel.MaxDataPoints)
+	}
+
+	if step < timeRange/maxPoints {
+		step = timeRange / maxPoints
+	}
+
+	if step < time.Second {
+		step = time.Second
+	}
+
+	return step, nil
+}
+
+func parseStep(model *simplejson.Json) (time.Duration, error) {
+	if seconds, err := model.Float64(); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	value := strings.TrimSpace(model.MustString(""))
+	if value == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	step, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid step %s", value)
+	}
 
-		queryRes.Series = append(queryRes.Series, &series)
+	return step, nil
+}
+
+func parseResponse(value pmodel.Value, query *PrometheusQuery) (*tsdb.QueryResult, error) {
+	queryRes := tsdb.NewQueryResult()
+	queryRes.RefId = query.RefId
+
+	switch data := value.(type) {
+	case pmodel.Matrix:
+		for _, v := range data {
+			series := tsdb.TimeSeries{
+				Name: formatLegend(v.Metric, query),
+			}
+
+			for _, k := range v.Values {
+				series.Points = append(series.Points, tsdb.NewTimePoint(null.FloatFrom(float64(k.Value)), float64(k.Timestamp.Unix()*1000)))
+			}
+
+			queryRes.Series = append(queryRes.Series, &series)
+		}
+	case pmodel.Vector:
+		for _, v := range data {
+			point := tsdb.NewTimePoint(null.FloatFrom(float64(v.Value)), float64(v.Timestamp.Unix()*1000))
+			queryRes.Series = append(queryRes.Series, tsdb.NewTimeSeries(formatLegend(v.Metric, query), tsdb.TimeSeriesPoints{point}))
+		}
+	case *pmodel.Scalar:
+		point := tsdb.NewTimePoint(null.FloatFrom(float64(data.Value)), float64(data.Timestamp.Unix()*1000))
+		queryRes.Series = append(queryRes.Series, tsdb.NewTimeSeries(query.Expr, tsdb.TimeSeriesPoints{point}))
+	default:
+		return nil, fmt.Errorf("Unsupported result format: %s", value.Type().String())
 	}
 
-	queryResults["A"] = queryRes
-	return queryResults, nil
+	return queryRes, nil
 }
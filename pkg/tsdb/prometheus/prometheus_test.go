@@ -1,12 +1,27 @@
 package prometheus
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
 	p "github.com/prometheus/common/model"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+func newQuery(refId string, model string) *tsdb.Query {
+	json, err := simplejson.NewJson([]byte(model))
+	So(err, ShouldBeNil)
+
+	return &tsdb.Query{RefId: refId, Model: json}
+}
+
 func TestPrometheus(t *testing.T) {
 	Convey("Prometheus", t, func() {
 
@@ -36,5 +51,118 @@ func TestPrometheus(t *testing.T) {
 
 			So(formatLegend(metric, query), ShouldEqual, `http_request_total{app="backend", device="mobile"}`)
 		})
+
+		Convey("calculating step", func() {
+			hour := time.Hour
+
+			Convey("uses the step entered in the editor", func() {
+				step, err := getStep(newQuery("A", `{"interval": "2m"}`), hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, 2*time.Minute)
+
+				query := newQuery("A", `{"interval": "10s", "intervalFactor": 3}`)
+				query.IntervalMs = 1000
+				step, err = getStep(query, hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, 30*time.Second)
+
+				_, err = getStep(newQuery("A", `{"interval": "abc"}`), hour)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("uses interval and max data points", func() {
+				query := newQuery("A", `{"intervalFactor": 2, "step": 300}`)
+				query.IntervalMs = 10000
+				step, err := getStep(query, hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, 20*time.Second)
+
+				query.MaxDataPoints = 60
+				step, err = getStep(query, hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, time.Minute)
+			})
+
+			Convey("uses the stored step without interval", func() {
+				step, err := getStep(newQuery("A", `{"step": 30}`), hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, 30*time.Second)
+			})
+
+			Convey("stays within the limits of prometheus", func() {
+				step, err := getStep(newQuery("A", `{}`), hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, time.Second)
+
+				step, err = getStep(newQuery("A", `{"step": 1}`), 24*hour)
+				So(err, ShouldBeNil)
+				So(step, ShouldEqual, 24*hour/maxPoints)
+			})
+		})
+
+		Convey("executing queries", func() {
+			var lock sync.Mutex
+			var requests []*http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lock.Lock()
+				requests = append(requests, r)
+				lock.Unlock()
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Query().Get("query") {
+				case "broken{":
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error at char 8"}`)
+				case "up":
+					fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api"},"value":[1500000000,"1"]}]}}`)
+				default:
+					fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"api"},"values":[[1500000000,"1"],[1500000060,"2"]]}]}}`)
+				}
+			}))
+			defer server.Close()
+
+			executor := &PrometheusExecutor{&tsdb.DataSourceInfo{Url: server.URL, BasicAuth: true, BasicAuthUser: "user", BasicAuthPassword: "pwd"}}
+			queries := tsdb.QuerySlice{
+				newQuery("A", `{"expr": "rate(http_requests_total[5m])", "step": 60, "legendFormat": "{{job}}"}`),
+				newQuery("B", `{"expr": "up", "instant": true}`),
+				newQuery("C", `{"expr": "broken{"}`),
+			}
+			queryContext := tsdb.NewQueryContext(queries, tsdb.NewTimeRange("1h", "now"))
+
+			result := executor.Execute(context.Background(), queries, queryContext)
+			So(result.Error, ShouldBeNil)
+			So(len(requests), ShouldEqual, 3)
+
+			Convey("runs every query", func() {
+				So(result.QueryResults["A"].Series[0].Name, ShouldEqual, "api")
+				So(len(result.QueryResults["A"].Series[0].Points), ShouldEqual, 2)
+
+				So(len(result.QueryResults["B"].Series[0].Points), ShouldEqual, 1)
+				So(result.QueryResults["B"].Series[0].Points[0][1].Float64, ShouldEqual, 1500000000000)
+			})
+
+			Convey("uses instant queries", func() {
+				for _, req := range requests {
+					if req.URL.Query().Get("query") == "up" {
+						So(req.URL.Path, ShouldEqual, "/api/v1/query")
+					} else {
+						So(req.URL.Path, ShouldEqual, "/api/v1/query_range")
+					}
+				}
+			})
+
+			Convey("sends basic auth", func() {
+				user, pwd, ok := requests[0].BasicAuth()
+				So(ok, ShouldBeTrue)
+				So(user, ShouldEqual, "user")
+				So(pwd, ShouldEqual, "pwd")
+			})
+
+			Convey("returns prometheus errors in the query result", func() {
+				So(result.QueryResults["C"].Error, ShouldNotBeNil)
+				So(result.QueryResults["C"].Error.Error(), ShouldEqual, "bad_data: parse error at char 8")
+			})
+		})
 	})
 }
@@ -3,9 +3,11 @@ package prometheus
 import "time"
 
 type PrometheusQuery struct {
+	RefId        string
 	Expr         string
 	Step         time.Duration
 	LegendFormat string
+	Instant      bool
 	Start        time.Time
 	End          time.Time
 }
@@ -35,9 +35,11 @@ func HandleRequest(ctx context.Context, req *Request) (*Response, error) {
 				return nil, batchResult.Error
 			}
 
+			context.Lock.Lock()
 			for refId, result := range batchResult.QueryResults {
 				context.Results[refId] = result
 			}
+			context.Lock.Unlock()
 
 			for _, batch := range batches {
 				// not interested in started batches
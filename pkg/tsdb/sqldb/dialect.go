@@ -0,0 +1,83 @@
+package sqldb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// sqlDialect holds what differs between the supported databases, the
+// connection string of a data source, which makes its sessions read only, and
+// the sql the macros expand to.
+type sqlDialect struct {
+	driverName       string
+	connectionString func(dsInfo *tsdb.DataSourceInfo) string
+	timeFilter       func(column string, from, to int64) string
+	timeGroup        func(column string, seconds int64) string
+}
+
+// MySQL sessions are made read only by the driver setting tx_read_only when
+// it connects, ddl statements commit the transaction and run in a read only
+// transaction of their own.
+var mysqlDialect = &sqlDialect{
+	driverName: "mysql",
+	connectionString: func(dsInfo *tsdb.DataSourceInfo) string {
+		cfg := &mysql.Config{
+			User:      dsInfo.User,
+			Passwd:    dsInfo.Password,
+			Net:       "tcp",
+			Addr:      dsInfo.Url,
+			DBName:    dsInfo.Database,
+			Collation: "utf8mb4_unicode_ci",
+			ParseTime: true,
+			Params:    map[string]string{"tx_read_only": "1"},
+		}
+
+		if strings.HasPrefix(dsInfo.Url, "/") {
+			cfg.Net = "unix"
+		}
+
+		return cfg.FormatDSN()
+	},
+	timeFilter: func(column string, from, to int64) string {
+		return fmt.Sprintf("%s BETWEEN FROM_UNIXTIME(%d) AND FROM_UNIXTIME(%d)", column, from, to)
+	},
+	timeGroup: func(column string, seconds int64) string {
+		return fmt.Sprintf("FLOOR(UNIX_TIMESTAMP(%s)/%d)*%d", column, seconds, seconds)
+	},
+}
+
+// PostgreSQL sessions are made read only by the connection string.
+var postgresDialect = &sqlDialect{
+	driverName: "postgres",
+	connectionString: func(dsInfo *tsdb.DataSourceInfo) string {
+		sslmode := "verify-full"
+		if dsInfo.JsonData != nil {
+			sslmode = dsInfo.JsonData.Get("sslmode").MustString(sslmode)
+		}
+
+		params := url.Values{}
+		params.Set("sslmode", sslmode)
+		params.Set("default_transaction_read_only", "on")
+
+		u := &url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(dsInfo.User, dsInfo.Password),
+			Host:     dsInfo.Url,
+			Path:     "/" + dsInfo.Database,
+			RawQuery: params.Encode(),
+		}
+
+		return u.String()
+	},
+	timeFilter: func(column string, from, to int64) string {
+		return fmt.Sprintf("%s BETWEEN to_timestamp(%d) AND to_timestamp(%d)", column, from, to)
+	},
+	timeGroup: func(column string, seconds int64) string {
+		return fmt.Sprintf("floor(extract(epoch from %s)/%d)*%d", column, seconds, seconds)
+	},
+}
@@ -0,0 +1,119 @@
+package sqldb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	macroFunction *regexp.Regexp
+	macroInterval *regexp.Regexp
+	intervalUnits *regexp.Regexp
+)
+
+func init() {
+	macroFunction = regexp.MustCompile(`\$__(\w+)\(([^\)]*)\)`)
+	macroInterval = regexp.MustCompile(`\$__interval\b`)
+	intervalUnits = regexp.MustCompile(`^(\d+)(ms|s|m|h|d|w|y)$`)
+}
+
+// interpolate replaces the macros of a raw sql query. $__timeFilter(column)
+// checks that the column is within the time range, $__timeGroup(column,
+// interval) rounds the column down to the interval in epoch seconds and
+// $__interval is the interval of the query, like 5m.
+func interpolate(dialect *sqlDialect, rawSql string, from, to time.Time, interval time.Duration) (string, error) {
+	rawSql = macroInterval.ReplaceAllString(rawSql, formatInterval(interval))
+
+	var macroErr error
+	sql := macroFunction.ReplaceAllStringFunc(rawSql, func(match string) string {
+		groups := macroFunction.FindStringSubmatch(match)
+		args := splitArgs(groups[2])
+
+		result, err := evaluateMacro(dialect, groups[1], args, from, to)
+		if err != nil && macroErr == nil {
+			macroErr = err
+		}
+		return result
+	})
+
+	if macroErr != nil {
+		return "", macroErr
+	}
+
+	return sql, nil
+}
+
+func evaluateMacro(dialect *sqlDialect, name string, args []string, from, to time.Time) (string, error) {
+	switch name {
+	case "timeFilter":
+		if len(args) != 1 || args[0] == "" {
+			return "", fmt.Errorf("Macro $__timeFilter needs a time column")
+		}
+		return dialect.timeFilter(args[0], from.Unix(), to.Unix()), nil
+	case "timeGroup":
+		if len(args) != 2 {
+			return "", fmt.Errorf("Macro $__timeGroup needs a time column and an interval")
+		}
+		interval, err := parseInterval(args[1])
+		if err != nil {
+			return "", err
+		}
+		seconds := int64(interval / time.Second)
+		if seconds < 1 {
+			seconds = 1
+		}
+		return dialect.timeGroup(args[0], seconds), nil
+	default:
+		return "", fmt.Errorf("Unknown macro $__%s", name)
+	}
+}
+
+func splitArgs(args string) []string {
+	result := make([]string, 0)
+	for _, arg := range strings.Split(args, ",") {
+		result = append(result, strings.Trim(strings.TrimSpace(arg), `'"`))
+	}
+	return result
+}
+
+// parseInterval reads an interval in seconds or with a unit like 10s, 5m or
+// 1d.
+func parseInterval(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	groups := intervalUnits.FindStringSubmatch(value)
+	if groups == nil {
+		return 0, fmt.Errorf("Invalid interval %s", value)
+	}
+
+	count, _ := strconv.ParseInt(groups[1], 10, 64)
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"y":  365 * 24 * time.Hour,
+	}
+
+	return time.Duration(count) * units[groups[2]], nil
+}
+
+func formatInterval(interval time.Duration) string {
+	switch {
+	case interval >= time.Hour && interval%time.Hour == 0:
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	case interval >= time.Minute && interval%time.Minute == 0:
+		return fmt.Sprintf("%dm", interval/time.Minute)
+	case interval >= time.Second && interval%time.Second == 0:
+		return fmt.Sprintf("%ds", interval/time.Second)
+	default:
+		return fmt.Sprintf("%dms", interval/time.Millisecond)
+	}
+}
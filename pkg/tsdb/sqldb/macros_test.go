@@ -0,0 +1,62 @@
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMacros(t *testing.T) {
+	Convey("SQL macros", t, func() {
+		from := time.Unix(1500000000, 0)
+		to := time.Unix(1500003600, 0)
+
+		Convey("can interpolate mysql macros", func() {
+			sql, err := interpolate(mysqlDialect, "SELECT $__timeGroup(created, $__interval) AS time, count(*) AS value FROM orders WHERE $__timeFilter(created)", from, to, 5*time.Minute)
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual, "SELECT FLOOR(UNIX_TIMESTAMP(created)/300)*300 AS time, count(*) AS value FROM orders WHERE created BETWEEN FROM_UNIXTIME(1500000000) AND FROM_UNIXTIME(1500003600)")
+		})
+
+		Convey("can interpolate postgres macros", func() {
+			sql, err := interpolate(postgresDialect, "SELECT $__timeGroup(created, '1h') AS time FROM orders WHERE $__timeFilter(created)", from, to, time.Minute)
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual, "SELECT floor(extract(epoch from created)/3600)*3600 AS time FROM orders WHERE created BETWEEN to_timestamp(1500000000) AND to_timestamp(1500003600)")
+		})
+
+		Convey("returns errors for invalid macros", func() {
+			_, err := interpolate(mysqlDialect, "SELECT $__unknown(created)", from, to, time.Minute)
+			So(err, ShouldNotBeNil)
+
+			_, err = interpolate(mysqlDialect, "SELECT $__timeGroup(created, 5 minutes)", from, to, time.Minute)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("can format intervals", func() {
+			So(formatInterval(2*time.Hour), ShouldEqual, "2h")
+			So(formatInterval(90*time.Second), ShouldEqual, "90s")
+			So(formatInterval(200*time.Millisecond), ShouldEqual, "200ms")
+		})
+
+		Convey("can build connection strings", func() {
+			dsInfo := &tsdb.DataSourceInfo{
+				Url:      "db:5432",
+				User:     "grafana",
+				Password: "p@ss",
+				Database: "kpi",
+				JsonData: simplejson.NewFromAny(map[string]interface{}{"sslmode": "disable"}),
+			}
+
+			So(postgresDialect.connectionString(dsInfo), ShouldEqual, "postgres://grafana:p%40ss@db:5432/kpi?default_transaction_read_only=on&sslmode=disable")
+
+			dsInfo.Url = "db:3306"
+			So(mysqlDialect.connectionString(dsInfo), ShouldEqual, "grafana:p@ss@tcp(db:3306)/kpi?collation=utf8mb4_unicode_ci&parseTime=true&tx_read_only=1")
+
+			dsInfo.Url = "db:5432"
+			dsInfo.JsonData = nil
+			So(postgresDialect.connectionString(dsInfo), ShouldEqual, "postgres://grafana:p%40ss@db:5432/kpi?default_transaction_read_only=on&sslmode=verify-full")
+		})
+	})
+}
@@ -0,0 +1,398 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	_ "github.com/lib/pq"
+
+	"github.com/grafana/grafana/pkg/log"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// SqlExecutor runs the raw sql of queries against MySQL and PostgreSQL data
+// sources. Queries are single statements run in read only transactions of
+// read only sessions, the database user of the data source should still only
+// be granted SELECT.
+type SqlExecutor struct {
+	*tsdb.DataSourceInfo
+	dialect *sqlDialect
+}
+
+func NewMysqlExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+	return &SqlExecutor{DataSourceInfo: dsInfo, dialect: mysqlDialect}
+}
+
+func NewPostgresExecutor(dsInfo *tsdb.DataSourceInfo) tsdb.Executor {
+	return &SqlExecutor{DataSourceInfo: dsInfo, dialect: postgresDialect}
+}
+
+var (
+	slog        log.Logger
+	engineCache = struct {
+		sync.Mutex
+		dbs map[int64]*cachedDB
+	}{dbs: make(map[int64]*cachedDB)}
+)
+
+const (
+	defaultMaxRows     = 100000
+	maxOpenConnections = 10
+)
+
+type cachedDB struct {
+	connectionString string
+	db               *sql.DB
+}
+
+func init() {
+	slog = log.New("tsdb.sql")
+	tsdb.RegisterExecutor("mysql", NewMysqlExecutor)
+	tsdb.RegisterExecutor("postgres", NewPostgresExecutor)
+}
+
+// getDB returns the connection pool of the data source, it is opened again
+// when the settings of the data source have changed. Connections are not kept
+// idle, so session settings a query changed do not outlive it.
+func (e *SqlExecutor) getDB() (*sql.DB, error) {
+	engineCache.Lock()
+	defer engineCache.Unlock()
+
+	connectionString := e.dialect.connectionString(e.DataSourceInfo)
+
+	if cached, ok := engineCache.dbs[e.Id]; ok {
+		if cached.connectionString == connectionString {
+			return cached.db, nil
+		}
+		cached.db.Close()
+		delete(engineCache.dbs, e.Id)
+	}
+
+	db, err := sql.Open(e.dialect.driverName, connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(maxOpenConnections)
+	db.SetMaxIdleConns(0)
+
+	engineCache.dbs[e.Id] = &cachedDB{connectionString: connectionString, db: db}
+	return db, nil
+}
+
+func (e *SqlExecutor) Execute(ctx context.Context, queries tsdb.QuerySlice, queryContext *tsdb.QueryContext) *tsdb.BatchResult {
+	result := &tsdb.BatchResult{QueryResults: make(map[string]*tsdb.QueryResult)}
+
+	db, err := e.getDB()
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	for _, query := range queries {
+		result.QueryResults[query.RefId] = e.executeQuery(db, query, queryContext)
+	}
+
+	return result
+}
+
+func (e *SqlExecutor) executeQuery(db *sql.DB, query *tsdb.Query, queryContext *tsdb.QueryContext) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+	queryRes.RefId = query.RefId
+
+	rawSql := query.Model.Get("rawSql").MustString()
+	if rawSql == "" {
+		queryRes.Error = fmt.Errorf("Query is missing rawSql")
+		return queryRes
+	}
+
+	from, err := queryContext.TimeRange.ParseFrom()
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	to, err := queryContext.TimeRange.ParseTo()
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	interval := time.Duration(query.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval, _ = parseInterval(tsdb.CalculateInterval(queryContext.TimeRange))
+	}
+
+	interpolatedSql, err := interpolate(e.dialect, rawSql, from, to, interval)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	maxRows := defaultMaxRows
+	if e.JsonData != nil {
+		maxRows = e.JsonData.Get("maxRows").MustInt(defaultMaxRows)
+	}
+
+	columns, rows, err := e.runReadOnly(db, interpolatedSql, maxRows)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	switch query.Model.Get("format").MustString("time_series") {
+	case "table":
+		queryRes.Tables = append(queryRes.Tables, transformToTable(columns, rows))
+	default:
+		queryRes.Series, err = transformToTimeSeries(columns, rows)
+		if err != nil {
+			queryRes.Error = err
+		}
+	}
+
+	return queryRes
+}
+
+// runReadOnly runs the query in a transaction, which is read only as the
+// sessions of the connection string are, and rolls it back afterwards. The
+// query is prepared so it can not hold more than one statement.
+func (e *SqlExecutor) runReadOnly(db *sql.DB, query string, maxRows int) ([]string, [][]interface{}, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			slog.Warn("Failed to roll back query transaction", "datasource", e.Name, "error", err)
+		}
+	}()
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return readRows(rows, maxRows)
+}
+
+// readRows reads all rows of the result, failing when there are more than
+// maxRows of them.
+func readRows(rows *sql.Rows, maxRows int) ([]string, [][]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([][]interface{}, 0)
+	for rows.Next() {
+		if len(result) >= maxRows {
+			return nil, nil, fmt.Errorf("Query returned more than %d rows, limit the query or raise maxRows of the data source", maxRows)
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+
+		for i, value := range values {
+			if bytes, ok := value.([]byte); ok {
+				values[i] = string(bytes)
+			}
+		}
+
+		result = append(result, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, result, nil
+}
+
+// transformToTable returns the rows as a table. MySQL returns numbers as
+// text, so columns where every value is a number are number columns.
+func transformToTable(columns []string, rows [][]interface{}) *tsdb.Table {
+	table := tsdb.NewTable()
+
+	for i, name := range columns {
+		table.Columns = append(table.Columns, tsdb.TableColumn{Text: name, Type: getColumnType(name, i, rows)})
+	}
+
+	for _, row := range rows {
+		values := make(tsdb.RowValues, len(row))
+		for i, value := range row {
+			switch table.Columns[i].Type {
+			case tsdb.ColumnTypeTime:
+				if timestamp, err := toEpochMs(value); err == nil {
+					values[i] = timestamp
+				}
+			case tsdb.ColumnTypeNumber:
+				if number, err := toFloat(value); err == nil && number.Valid {
+					values[i] = number.Float64
+				}
+			default:
+				values[i] = value
+			}
+		}
+		table.Rows = append(table.Rows, values)
+	}
+
+	return table
+}
+
+func getColumnType(name string, index int, rows [][]interface{}) tsdb.ColumnType {
+	switch strings.ToLower(name) {
+	case "time", "time_sec":
+		return tsdb.ColumnTypeTime
+	}
+
+	columnType := tsdb.ColumnType("")
+	for _, row := range rows {
+		var valueType tsdb.ColumnType
+		switch value := row[index].(type) {
+		case nil:
+			continue
+		case time.Time:
+			valueType = tsdb.ColumnTypeTime
+		case int64, float64, float32:
+			valueType = tsdb.ColumnTypeNumber
+		case string:
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				valueType = tsdb.ColumnTypeNumber
+			} else {
+				valueType = tsdb.ColumnTypeString
+			}
+		default:
+			valueType = tsdb.ColumnTypeString
+		}
+
+		if columnType != "" && columnType != valueType {
+			return tsdb.ColumnTypeString
+		}
+		columnType = valueType
+	}
+
+	if columnType == "" {
+		return tsdb.ColumnTypeString
+	}
+
+	return columnType
+}
+
+// transformToTimeSeries turns rows with a time column, in epoch seconds or
+// as a time, into a series per value column. Rows with a metric column are
+// split into a series per metric.
+func transformToTimeSeries(columns []string, rows [][]interface{}) (tsdb.TimeSeriesSlice, error) {
+	timeIndex, metricIndex := -1, -1
+	valueIndexes := make([]int, 0)
+
+	for i, name := range columns {
+		switch strings.ToLower(name) {
+		case "time", "time_sec":
+			timeIndex = i
+		case "metric":
+			metricIndex = i
+		default:
+			valueIndexes = append(valueIndexes, i)
+		}
+	}
+
+	if timeIndex == -1 {
+		return nil, fmt.Errorf("Found no column named time or time_sec")
+	}
+
+	if len(valueIndexes) == 0 {
+		return nil, fmt.Errorf("Found no value column")
+	}
+
+	seriesByName := make(map[string]*tsdb.TimeSeries)
+	result := make(tsdb.TimeSeriesSlice, 0)
+
+	for _, row := range rows {
+		timestamp, err := toEpochMs(row[timeIndex])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, valueIndex := range valueIndexes {
+			name := columns[valueIndex]
+			if metricIndex != -1 {
+				metric := fmt.Sprintf("%v", row[metricIndex])
+				if len(valueIndexes) == 1 {
+					name = metric
+				} else {
+					name = metric + " " + name
+				}
+			}
+
+			value, err := toFloat(row[valueIndex])
+			if err != nil {
+				return nil, fmt.Errorf("Column %s: %v", columns[valueIndex], err)
+			}
+
+			series, ok := seriesByName[name]
+			if !ok {
+				series = tsdb.NewTimeSeries(name, make(tsdb.TimeSeriesPoints, 0))
+				seriesByName[name] = series
+				result = append(result, series)
+			}
+
+			series.Points = append(series.Points, tsdb.NewTimePoint(value, timestamp))
+		}
+	}
+
+	return result, nil
+}
+
+func toEpochMs(value interface{}) (float64, error) {
+	if t, ok := value.(time.Time); ok {
+		return float64(t.UnixNano() / int64(time.Millisecond)), nil
+	}
+
+	seconds, err := toFloat(value)
+	if err != nil || !seconds.Valid {
+		return 0, fmt.Errorf("Time column must be a time or epoch seconds, got %v", value)
+	}
+
+	return seconds.Float64 * 1000, nil
+}
+
+func toFloat(value interface{}) (null.Float, error) {
+	switch v := value.(type) {
+	case nil:
+		return null.FloatFromPtr(nil), nil
+	case int64:
+		return null.FloatFrom(float64(v)), nil
+	case float64:
+		return null.FloatFrom(v), nil
+	case float32:
+		return null.FloatFrom(float64(v)), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return null.Float{}, fmt.Errorf("Value %s is not a number", v)
+		}
+		return null.FloatFrom(f), nil
+	default:
+		return null.Float{}, fmt.Errorf("Value %v of type %T is not a number", v, v)
+	}
+}
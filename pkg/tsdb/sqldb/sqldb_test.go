@@ -0,0 +1,179 @@
+package sqldb
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	_ "github.com/mattn/go-sqlite3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSqlExecutor(t *testing.T) {
+	Convey("SQL executor", t, func() {
+		Convey("can transform rows to time series", func() {
+			columns := []string{"time", "metric", "value"}
+			rows := [][]interface{}{
+				{int64(1500000000), "eu", "10.5"},
+				{int64(1500000000), "us", float64(20)},
+				{time.Unix(1500000060, 0), "eu", nil},
+			}
+
+			series, err := transformToTimeSeries(columns, rows)
+			So(err, ShouldBeNil)
+			So(len(series), ShouldEqual, 2)
+			So(series[0].Name, ShouldEqual, "eu")
+			So(len(series[0].Points), ShouldEqual, 2)
+			So(series[0].Points[0][0].Float64, ShouldEqual, 10.5)
+			So(series[0].Points[0][1].Float64, ShouldEqual, 1500000000000)
+			So(series[0].Points[1][0].Valid, ShouldBeFalse)
+			So(series[0].Points[1][1].Float64, ShouldEqual, 1500000060000)
+			So(series[1].Name, ShouldEqual, "us")
+		})
+
+		Convey("names series by metric and column when there are several value columns", func() {
+			columns := []string{"time_sec", "metric", "min", "max"}
+			rows := [][]interface{}{{int64(1500000000), "eu", int64(1), int64(5)}}
+
+			series, err := transformToTimeSeries(columns, rows)
+			So(err, ShouldBeNil)
+			So(len(series), ShouldEqual, 2)
+			So(series[0].Name, ShouldEqual, "eu min")
+			So(series[1].Name, ShouldEqual, "eu max")
+		})
+
+		Convey("returns error for rows without time or numeric values", func() {
+			_, err := transformToTimeSeries([]string{"value"}, [][]interface{}{{int64(1)}})
+			So(err, ShouldNotBeNil)
+
+			_, err = transformToTimeSeries([]string{"time", "value"}, [][]interface{}{{int64(1), "high"}})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("can transform rows to a table", func() {
+			table := transformToTable([]string{"time", "country", "orders", "code"}, [][]interface{}{
+				{time.Unix(1500000000, 0), "se", "12", "046"},
+				{int64(1500000060), "no", nil, "n/a"},
+			})
+			So(table.Columns[1].Text, ShouldEqual, "country")
+			So(table.Columns[0].Type, ShouldEqual, tsdb.ColumnTypeTime)
+			So(table.Columns[1].Type, ShouldEqual, tsdb.ColumnTypeString)
+			So(table.Columns[2].Type, ShouldEqual, tsdb.ColumnTypeNumber)
+			So(table.Columns[3].Type, ShouldEqual, tsdb.ColumnTypeString)
+			So(table.Rows[0][0], ShouldEqual, float64(1500000000000))
+			So(table.Rows[1][0], ShouldEqual, float64(1500000060000))
+			So(table.Rows[0][1], ShouldEqual, "se")
+			So(table.Rows[0][2], ShouldEqual, float64(12))
+			So(table.Rows[1][2], ShouldBeNil)
+			So(table.Rows[0][3], ShouldEqual, "046")
+		})
+
+		Convey("reads rows up to the limit", func() {
+			db, err := sql.Open("sqlite3", ":memory:")
+			So(err, ShouldBeNil)
+			defer db.Close()
+
+			_, err = db.Exec("CREATE TABLE orders (created INTEGER, country TEXT, amount REAL)")
+			So(err, ShouldBeNil)
+			_, err = db.Exec("INSERT INTO orders VALUES (1500000000, 'se', 10.5), (1500000060, 'no', 3)")
+			So(err, ShouldBeNil)
+
+			rows, err := db.Query("SELECT created AS time, country AS metric, amount AS value FROM orders ORDER BY created")
+			So(err, ShouldBeNil)
+			columns, values, err := readRows(rows, 10)
+			rows.Close()
+			So(err, ShouldBeNil)
+			So(columns, ShouldResemble, []string{"time", "metric", "value"})
+			So(len(values), ShouldEqual, 2)
+			So(values[0][1], ShouldEqual, "se")
+
+			rows, err = db.Query("SELECT * FROM orders")
+			So(err, ShouldBeNil)
+			_, _, err = readRows(rows, 1)
+			rows.Close()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("runs queries read only", func() {
+			dir, err := ioutil.TempDir("", "sqldb")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "kpi.db")
+			db, err := sql.Open("sqlite3", path)
+			So(err, ShouldBeNil)
+			defer db.Close()
+
+			_, err = db.Exec("CREATE TABLE orders (created INTEGER, country TEXT, amount REAL)")
+			So(err, ShouldBeNil)
+			_, err = db.Exec("INSERT INTO orders VALUES (1500000000, 'se', 10.5)")
+			So(err, ShouldBeNil)
+
+			executor := &SqlExecutor{
+				DataSourceInfo: &tsdb.DataSourceInfo{Id: -1, Name: "kpi"},
+				dialect: &sqlDialect{
+					driverName: "sqlite3",
+					connectionString: func(dsInfo *tsdb.DataSourceInfo) string {
+						return "file:" + path + "?mode=ro"
+					},
+				},
+			}
+
+			readOnlyDB, err := executor.getDB()
+			So(err, ShouldBeNil)
+			defer func() {
+				engineCache.Lock()
+				delete(engineCache.dbs, executor.Id)
+				engineCache.Unlock()
+				readOnlyDB.Close()
+			}()
+
+			countOrders := func() int {
+				var count int
+				So(db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count), ShouldBeNil)
+				return count
+			}
+
+			Convey("returns the rows of the query", func() {
+				_, values, err := executor.runReadOnly(readOnlyDB, "SELECT country FROM orders", 10)
+				So(err, ShouldBeNil)
+				So(values, ShouldResemble, [][]interface{}{{"se"}})
+			})
+
+			Convey("fails to write", func() {
+				_, _, err := executor.runReadOnly(readOnlyDB, "DELETE FROM orders", 10)
+				So(err, ShouldNotBeNil)
+				So(countOrders(), ShouldEqual, 1)
+			})
+
+			Convey("does not run statements after the first one", func() {
+				executor.runReadOnly(db, "SELECT 1; DELETE FROM orders", 10)
+				So(countOrders(), ShouldEqual, 1)
+			})
+
+			Convey("closes the connection afterwards", func() {
+				executor.runReadOnly(readOnlyDB, "SELECT country FROM orders", 10)
+				So(readOnlyDB.Stats().OpenConnections, ShouldEqual, 0)
+			})
+		})
+
+		Convey("runs queries of data sources without json data", func() {
+			executor := &SqlExecutor{DataSourceInfo: &tsdb.DataSourceInfo{Name: "kpi"}, dialect: mysqlDialect}
+			query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"rawSql": "SELECT 1"})}
+			queryContext := &tsdb.QueryContext{TimeRange: tsdb.NewTimeRange("5m", "now")}
+
+			db, err := sql.Open("sqlite3", ":memory:")
+			So(err, ShouldBeNil)
+			defer db.Close()
+
+			res := executor.executeQuery(db, query, queryContext)
+			So(res.Error, ShouldNotBeNil)
+			So(res.Error.Error(), ShouldContainSubstring, "time")
+		})
+	})
+}
@@ -119,6 +119,32 @@ func init() {
 			return queryRes
 		},
 	})
+
+	registerScenario(&Scenario{
+		Id:   "table_static",
+		Name: "Table Static",
+		Handler: func(query *tsdb.Query, context *tsdb.QueryContext) *tsdb.QueryResult {
+			timeWalkerMs := context.TimeRange.GetFromAsMsEpoch()
+			to := context.TimeRange.GetToAsMsEpoch()
+			step := (to - timeWalkerMs) / 10
+
+			table := tsdb.NewTable(
+				tsdb.TableColumn{Text: "Time", Type: tsdb.ColumnTypeTime},
+				tsdb.TableColumn{Text: "Message", Type: tsdb.ColumnTypeString},
+				tsdb.TableColumn{Text: "Description", Type: tsdb.ColumnTypeString},
+				tsdb.TableColumn{Text: "Value", Type: tsdb.ColumnTypeNumber},
+			)
+
+			for i := int64(0); i < 10 && timeWalkerMs < to; i++ {
+				table.Rows = append(table.Rows, tsdb.RowValues{float64(timeWalkerMs), "This is a message", "Description", float64(23.1)})
+				timeWalkerMs += step
+			}
+
+			queryRes := tsdb.NewQueryResult()
+			queryRes.Tables = append(queryRes.Tables, table)
+			return queryRes
+		},
+	})
 }
 
 func registerScenario(scenario *Scenario) {
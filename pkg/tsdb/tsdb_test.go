@@ -166,6 +166,21 @@ func TestMetricQuery(t *testing.T) {
 	})
 }
 
+func TestExecuteInParallel(t *testing.T) {
+	Convey("When executing queries in parallel", t, func() {
+		queries := QuerySlice{{RefId: "A"}, {RefId: "B"}, {RefId: "C"}}
+
+		result := ExecuteInParallel(queries, func(query *Query) *QueryResult {
+			return &QueryResult{RefId: query.RefId, Series: TimeSeriesSlice{&TimeSeries{Name: query.RefId + "res"}}}
+		})
+
+		Convey("Should return the result of every query", func() {
+			So(len(result.QueryResults), ShouldEqual, 3)
+			So(result.QueryResults["B"].Series[0].Name, ShouldEqual, "Bres")
+		})
+	})
+}
+
 func registerFakeExecutor() *FakeExecutor {
 	executor := NewFakeExecutor(nil)
 	RegisterExecutor("test", func(dsInfo *DataSourceInfo) Executor {
@@ -18,6 +18,7 @@ var alertQueryDef = new QueryPartDef({
 
 var conditionTypes = [
   {text: 'Query', value: 'query'},
+  {text: 'Expression', value: 'expression'},
 ];
 
 var evalFunctions = [
@@ -25,7 +26,14 @@ var evalFunctions = [
   {text: 'IS BELOW', value: 'lt'},
   {text: 'IS OUTSIDE RANGE', value: 'outside_range'},
   {text: 'IS WITHIN RANGE', value: 'within_range'},
-  {text: 'HAS NO VALUE' , value: 'no_value'}
+  {text: 'HAS NO VALUE' , value: 'no_value'},
+  {text: 'DEVIATES FROM MEAN', value: 'zscore'},
+  {text: 'DEVIATES FROM FORECAST', value: 'holt_winters'},
+];
+
+var evalOperators = [
+  {text: 'OR', value: 'or'},
+  {text: 'AND', value: 'and'},
 ];
 
 var reducerTypes = [
@@ -35,6 +43,14 @@ var reducerTypes = [
   {text: 'sum()' , value: 'sum'},
   {text: 'count()', value: 'count'},
   {text: 'last()', value: 'last'},
+  {text: 'median()', value: 'median'},
+  {text: 'p90()', value: 'p90'},
+  {text: 'p95()', value: 'p95'},
+  {text: 'p99()', value: 'p99'},
+  {text: 'diff()', value: 'diff'},
+  {text: 'percent_diff()', value: 'percent_diff'},
+  {text: 'stddev()', value: 'stddev'},
+  {text: 'count_non_null()', value: 'count_non_null'},
 ];
 
 var noDataModes = [
@@ -43,6 +59,13 @@ var noDataModes = [
   {text: 'Keep Last State', value: 'keep_state'},
 ];
 
+var severityTypes = [
+  {text: 'Critical', value: 'critical'},
+  {text: 'Error', value: 'error'},
+  {text: 'Warning', value: 'warning'},
+  {text: 'Info', value: 'info'},
+];
+
 var executionErrorModes = [
   {text: 'Alerting', value: 'alerting'},
   {text: 'Keep Last State', value: 'keep_state'},
@@ -116,7 +139,9 @@ export default {
   getStateDisplayModel: getStateDisplayModel,
   conditionTypes: conditionTypes,
   evalFunctions: evalFunctions,
+  evalOperators: evalOperators,
   noDataModes: noDataModes,
+  severityTypes: severityTypes,
   executionErrorModes: executionErrorModes,
   reducerTypes: reducerTypes,
   createReducerPart: createReducerPart,
@@ -18,7 +18,10 @@ export class AlertTabCtrl {
   alert: any;
   conditionModels: any;
   evalFunctions: any;
+  evalOperators: any;
   noDataModes: any;
+  severityTypes: any;
+  labels: any;
   executionErrorModes: any;
   addNotificationSegment;
   notifications;
@@ -41,8 +44,10 @@ export class AlertTabCtrl {
     this.$scope.ctrl = this;
     this.subTabIndex = 0;
     this.evalFunctions = alertDef.evalFunctions;
+    this.evalOperators = alertDef.evalOperators;
     this.conditionTypes = alertDef.conditionTypes;
     this.noDataModes = alertDef.noDataModes;
+    this.severityTypes = alertDef.severityTypes;
     this.executionErrorModes = alertDef.executionErrorModes;
     this.appSubUrl = config.appSubUrl;
   }
@@ -91,6 +96,11 @@ export class AlertTabCtrl {
       case "slack": return "fa fa-slack";
       case "webhook": return "fa fa-cubes";
       case "pagerduty": return "fa fa-bullhorn";
+      case "opsgenie": return "fa fa-bell";
+      case "victorops": return "fa fa-pagelines";
+      case "teams": return "fa fa-windows";
+      case "telegram": return "fa fa-paper-plane";
+      case "prometheus-alertmanager": return "fa fa-bell";
     }
   }
 
@@ -131,6 +141,24 @@ export class AlertTabCtrl {
     this.alertNotifications.splice(index, 1);
   }
 
+  addLabel() {
+    this.labels.push({key: '', value: ''});
+  }
+
+  removeLabel(index) {
+    this.labels.splice(index, 1);
+    this.labelsChanged();
+  }
+
+  labelsChanged() {
+    this.alert.labels = _.reduce(this.labels, (memo, label) => {
+      if (label.key && label.value) {
+        memo[label.key] = label.value;
+      }
+      return memo;
+    }, {});
+  }
+
   initModel() {
     var alert = this.alert = this.panel.alert;
     if (!alert) {
@@ -143,10 +171,16 @@ export class AlertTabCtrl {
     }
 
     alert.noDataState = alert.noDataState || 'no_data';
+    alert.severity = alert.severity || 'critical';
     alert.executionErrorState = alert.executionErrorState || 'alerting';
     alert.frequency = alert.frequency || '60s';
     alert.handler = alert.handler || 1;
     alert.notifications = alert.notifications || [];
+    alert.labels = alert.labels || {};
+
+    this.labels = _.map(alert.labels, (value, key) => {
+      return {key: key, value: value};
+    });
 
     var defaultName = this.panel.title + ' alert';
     alert.name = alert.name || defaultName;
@@ -188,12 +222,22 @@ export class AlertTabCtrl {
     }
   }
 
-  buildDefaultCondition() {
+  buildDefaultCondition(type?) {
+    if (type === 'expression') {
+      return {
+        type: 'expression',
+        expression: '',
+        evaluator: {type: 'gt', params: [null]},
+        operator: {type: 'and'},
+      };
+    }
+
     return {
       type: 'query',
       query: {params: ['A', '5m', 'now']},
       reducer: {type: 'avg', params: []},
       evaluator: {type: 'gt', params: [null]},
+      operator: {type: 'and'},
     };
   }
 
@@ -247,10 +291,17 @@ export class AlertTabCtrl {
   buildConditionModel(source) {
     var cm: any = {source: source, type: source.type};
 
-    cm.queryPart = new QueryPart(source.query, alertDef.alertQueryDef);
-    cm.reducerPart = alertDef.createReducerPart(source.reducer);
+    if (source.type === 'query') {
+      cm.queryPart = new QueryPart(source.query, alertDef.alertQueryDef);
+      cm.reducerPart = alertDef.createReducerPart(source.reducer);
+    }
     cm.evaluator = source.evaluator;
 
+    if (!source.operator) {
+      source.operator = {type: 'and'};
+    }
+    cm.operator = source.operator;
+
     return cm;
   }
 
@@ -295,7 +346,7 @@ export class AlertTabCtrl {
   }
 
   addCondition(type) {
-    var condition = this.buildDefaultCondition();
+    var condition = this.buildDefaultCondition(type);
     // add to persited model
     this.alert.conditions.push(condition);
     // add to view model
@@ -350,6 +401,15 @@ export class AlertTabCtrl {
       }
       case "no_value": {
         evaluator.params = [];
+        break;
+      }
+      case "zscore": {
+        evaluator.params = [3, 0];
+        break;
+      }
+      case "holt_winters": {
+        evaluator.params = [3, 24, 0.5, 0.1, 0.1];
+        break;
       }
     }
 
@@ -387,6 +447,25 @@ export class AlertTabCtrl {
       this.testing = false;
     });
   }
+
+  backtest() {
+    this.testing = true;
+    this.testResult = null;
+
+    var payload = {
+      dashboard: this.dashboardSrv.getCurrent().getSaveModelClone(),
+      panelId: this.panelCtrl.panel.id,
+      from: 'now-168h',
+      to: 'now',
+    };
+
+    return this.backendSrv.post('/api/alerts/backtest', payload).then(res => {
+      this.testResult = res;
+      this.testing = false;
+    }, () => {
+      this.testing = false;
+    });
+  }
 }
 
 /** @ngInject */
@@ -20,8 +20,12 @@ export class AlertNotificationEditCtrl {
         settings: {
           httpMethod: 'POST',
           autoResolve: true,
+          autoClose: true,
+          apiVersion: 'v2',
+          uploadImage: true,
         },
-        isDefault: false
+        isDefault: false,
+        sendReminder: false
       };
     }
   }
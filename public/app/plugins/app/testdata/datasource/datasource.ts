@@ -4,9 +4,12 @@ import _ from 'lodash';
 import angular from 'angular';
 
 class TestDataDatasource {
+  id: any;
 
   /** @ngInject */
-  constructor(private backendSrv, private $q) {}
+  constructor(instanceSettings, private backendSrv, private $q) {
+    this.id = instanceSettings.id;
+  }
 
   query(options) {
     var queries = _.filter(options.targets, item => {
@@ -14,6 +17,7 @@ class TestDataDatasource {
     }).map(item => {
       return {
         refId: item.refId,
+        datasourceId: this.id,
         scenarioId: item.scenarioId,
         intervalMs: options.intervalMs,
         maxDataPoints: options.maxDataPoints,
@@ -41,6 +45,14 @@ class TestDataDatasource {
               datapoints: series.points
             });
           }
+
+          for (let table of queryRes.tables || []) {
+            data.push({
+              type: 'table',
+              columns: table.columns,
+              rows: table.rows
+            });
+          }
         });
       }
 
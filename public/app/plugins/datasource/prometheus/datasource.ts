@@ -83,6 +83,7 @@ export function PrometheusDatasource(instanceSettings, $q, backendSrv, templateS
       var query: any = {};
       query.expr = templateSrv.replace(target.expr, options.scopedVars, self.interpolateQueryExpr);
       query.requestId = options.panelId + target.refId;
+      query.instant = target.instant;
 
       var interval = templateSrv.replace(target.interval, options.scopedVars) || options.interval;
       var intervalFactor = target.intervalFactor || 1;
@@ -132,6 +133,9 @@ export function PrometheusDatasource(instanceSettings, $q, backendSrv, templateS
     }
 
     var url = '/api/v1/query_range?query=' + encodeURIComponent(query.expr) + '&start=' + start + '&end=' + end + '&step=' + query.step;
+    if (query.instant) {
+      url = '/api/v1/query?query=' + encodeURIComponent(query.expr) + '&time=' + end;
+    }
     return this._request('GET', url, query.requestId);
   };
 
@@ -236,6 +240,12 @@ export function PrometheusDatasource(instanceSettings, $q, backendSrv, templateS
 
     metricLabel = this.createMetricLabel(md.metric, options);
 
+    // instant queries return a single value per series
+    if (md.value) {
+      var value = parseFloat(md.value[1]);
+      return { target: metricLabel, datapoints: [[_.isNaN(value) ? null : value, md.value[0] * 1000]] };
+    }
+
     var stepMs = parseInt(options.step) * 1000;
     var baseTimestamp = start * 1000;
     _.each(md.values, function(value) {